@@ -2,6 +2,9 @@ package configs
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -21,5 +24,19 @@ func LoadConfig(path, name string) *Config {
 		return nil
 	}
 
+	// a missing pin file is created with the first pin change, the pin of the config is
+	// used until then
+	if conf.HSM.PinFile != "" {
+		pin, err := ioutil.ReadFile(conf.HSM.PinFile)
+		if os.IsNotExist(err) {
+			return conf
+		}
+		if err != nil {
+			fmt.Printf("failed to read pin file: %v", err)
+			return nil
+		}
+		conf.HSM.Pin = strings.TrimSpace(string(pin))
+	}
+
 	return conf
 }
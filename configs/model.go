@@ -1,18 +1,161 @@
 package configs
 
+import "time"
+
 type (
 	Config struct {
-		ModulePath string  `mapstructure:"module_path"`
-		HSM        HSM     `mapstructure:"hsm"`
-		Servers    Servers `mapstructure:"servers"`
+		ModulePath string   `mapstructure:"module_path"`
+		HSM        HSM      `mapstructure:"hsm"`
+		Servers    Servers  `mapstructure:"servers"`
+		Ceremony   Ceremony `mapstructure:"ceremony"`
+		Backup     Backup   `mapstructure:"backup"`
+		Auth       Auth     `mapstructure:"auth"`
+		Audit      Audit    `mapstructure:"audit"`
+		Rotation   Rotation `mapstructure:"rotation"`
+		BYOK       BYOK     `mapstructure:"byok"`
+		Metadata   Metadata `mapstructure:"metadata"`
+		Approval   Approval `mapstructure:"approval"`
+
+		KeyTemplates []KeyTemplate `mapstructure:"key_templates"`
+		KeyAliases   []KeyAlias    `mapstructure:"key_aliases"`
 	}
 
+	// PinFile holds the user PIN instead of Pin when set, a PIN change rewrites it.
 	HSM struct {
-		SlotID   uint   `mapstructure:"slot_id"`
-		Pin      string `mapstructure:"pin"`
-		KeyType  string `mapstructure:"key_type"`
-		N2kLabel string `mapstructure:"n2k_label"`
-		IVSize   int    `mapstructure:"iv_size"`
+		SlotID    uint      `mapstructure:"slot_id"`
+		Pin       string    `mapstructure:"pin"`
+		PinFile   string    `mapstructure:"pin_file"`
+		PinPolicy PINPolicy `mapstructure:"pin_policy"`
+		KeyType   string    `mapstructure:"key_type"`
+		N2kLabel  string    `mapstructure:"n2k_label"`
+		IVSize    int       `mapstructure:"iv_size"`
+	}
+
+	// PINPolicy of the user and SO PINs set by the token administration, on top of the
+	// length limits of the token. MinClasses is the number of character classes (lower
+	// case, upper case, digit, other) a PIN mixes.
+	PINPolicy struct {
+		MinLength  int `mapstructure:"min_length"`
+		MaxLength  int `mapstructure:"max_length"`
+		MinClasses int `mapstructure:"min_classes"`
+	}
+
+	Ceremony struct {
+		Components    int         `mapstructure:"components"`
+		SigningKey    string      `mapstructure:"signing_key"`
+		TranscriptDir string      `mapstructure:"transcript_dir"`
+		Custodians    []Custodian `mapstructure:"custodians"`
+	}
+
+	// SecretHash is the hex SHA-256 of the custodian passphrase.
+	Custodian struct {
+		Name       string `mapstructure:"name"`
+		SecretHash string `mapstructure:"secret_hash"`
+	}
+
+	// The bundle of wrapped keys is written to Dir, Threshold of the KEK shares restore it.
+	// Each share goes to the destination of its custodian, one custodian per share; with
+	// no custodians the shares are printed once and not stored.
+	Backup struct {
+		Dir        string           `mapstructure:"dir"`
+		Shares     int              `mapstructure:"shares"`
+		Threshold  int              `mapstructure:"threshold"`
+		Custodians []ShareCustodian `mapstructure:"custodians"`
+	}
+
+	// Path is the existing directory only the custodian reaches, a removable medium for
+	// instance, it cannot be inside the backup directory.
+	ShareCustodian struct {
+		Name string `mapstructure:"name"`
+		Path string `mapstructure:"path"`
+	}
+
+	Auth struct {
+		Tokens []APIToken `mapstructure:"tokens"`
+	}
+
+	// TokenHash is the hex SHA-256 of the bearer token. The calls of a token with a tenant
+	// only reach the keys labelled <tenant>:<label>, they name them <label>.
+	APIToken struct {
+		Name      string   `mapstructure:"name"`
+		TokenHash string   `mapstructure:"token_hash"`
+		Roles     []string `mapstructure:"roles"`
+		Tenant    string   `mapstructure:"tenant"`
+	}
+
+	// Path of the JSON lines audit log, events only go to the standard logger when empty.
+	Audit struct {
+		Path string `mapstructure:"path"`
+	}
+
+	// CheckInterval is how often the scheduler looks for key rings due for rotation.
+	Rotation struct {
+		CheckInterval time.Duration    `mapstructure:"check_interval"`
+		Policies      []RotationPolicy `mapstructure:"policies"`
+	}
+
+	// A key ring is rotated when its primary version is older than Period or has been used
+	// MaxOperations times, a zero value disables the criterion.
+	RotationPolicy struct {
+		Label         string        `mapstructure:"label"`
+		Period        time.Duration `mapstructure:"period"`
+		MaxOperations int           `mapstructure:"max_operations"`
+	}
+
+	// TokenTTL is how long an import token and its wrapping key stay valid.
+	BYOK struct {
+		TokenTTL time.Duration `mapstructure:"token_ttl"`
+	}
+
+	// Path of the key metadata store sealed under the AES key KeyLabel, the key is
+	// generated when missing.
+	Metadata struct {
+		Path     string `mapstructure:"path"`
+		KeyLabel string `mapstructure:"key_label"`
+	}
+
+	// The Operations (delete-key, export-keys, change-pin) wait for Threshold approvals of distinct Approvers given
+	// within TTL. Each approver approves with the API token of its name, which needs the
+	// approver role, and signs with the PEM key PublicKey (RSA, EC or Ed25519).
+	Approval struct {
+		Threshold  int           `mapstructure:"threshold"`
+		TTL        time.Duration `mapstructure:"ttl"`
+		Operations []string      `mapstructure:"operations"`
+		Approvers  []Approver    `mapstructure:"approvers"`
+	}
+
+	Approver struct {
+		Name      string `mapstructure:"name"`
+		PublicKey string `mapstructure:"public_key"`
+	}
+
+	// KeyTemplate names the attributes of the keys created or imported with it. Class is
+	// secret or private for a key pair, Size is in bits, the curve size for EC and 0 for DES.
+	// Purpose is pin-encryption (zpk) or pin-verification (pvk) for the keys that only serve
+	// the PIN operations, empty for the others. RetailMac gives a non-extractable DES2 key
+	// the K1 key of the retail MAC (ISO 9797-1 algorithm 3) of more than one block.
+	KeyTemplate struct {
+		Name              string   `mapstructure:"name"`
+		Class             string   `mapstructure:"class"`
+		KeyType           string   `mapstructure:"key_type"`
+		Size              int      `mapstructure:"size"`
+		Usage             []string `mapstructure:"usage"`
+		Extractable       bool     `mapstructure:"extractable"`
+		WrapWithTrusted   bool     `mapstructure:"wrap_with_trusted"`
+		AllowedMechanisms []string `mapstructure:"allowed_mechanisms"`
+		Purpose           string   `mapstructure:"purpose"`
+		RetailMac         bool     `mapstructure:"retail_mac"`
+	}
+
+	// KeyAlias maps the type of Encrypt and Decrypt requests to the key ring of Label, or
+	// of the key with the hex CKA_ID ID. Algorithm is CBC or GCM, empty lets the request
+	// choose. Policy is encrypt-decrypt (default), encrypt-only or decrypt-only.
+	KeyAlias struct {
+		Type      string `mapstructure:"type"`
+		Label     string `mapstructure:"label"`
+		ID        string `mapstructure:"id"`
+		Algorithm string `mapstructure:"algorithm"`
+		Policy    string `mapstructure:"policy"`
 	}
 
 	Servers struct {
@@ -8,6 +8,7 @@ import (
 	"syscall"
 
 	"hsm/configs"
+	"hsm/pkg/cli"
 	grpc_server "hsm/pkg/crypto/v1"
 )
 
@@ -22,6 +23,14 @@ func main() {
 	if conf == nil {
 		panic("failed to load config")
 	}
+
+	// command line tools
+	if len(os.Args) > 1 {
+		if err := cli.Run(conf, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	fmt.Printf("config: %+v\n", *conf)
 
 	// grpc server
@@ -0,0 +1,442 @@
+// Package approval puts sensitive operations under dual control: the operation is held
+// in a pending request until a quorum of distinct approvers signed it with their own
+// keys, and runs once the last approval is in. Pending requests are kept in memory and
+// do not survive a restart.
+package approval
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"hsm/configs"
+	"hsm/pkg/audit"
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+)
+
+const defaultTTL = 24 * time.Hour
+
+// operations that can be put under dual control
+const (
+	OpDeleteKey  = "delete-key"
+	OpExportKeys = "export-keys" // backup of the extractable keys
+	OpChangePIN  = "change-pin"  // InitPIN and SetPIN of a token
+)
+
+var operations = []string{OpDeleteKey, OpExportKeys, OpChangePIN}
+
+// status of a request
+const (
+	StatusPending  = "PENDING"
+	StatusExecuted = "EXECUTED"
+	StatusFailed   = "FAILED"
+	StatusExpired  = "EXPIRED"
+	StatusCanceled = "CANCELED" // the target of the operation is gone
+)
+
+var (
+	ErrNotFound = errors.New("approval request not found")
+	// ErrRefused is returned for approvals that cannot count: unknown approver, bad
+	// signature, second approval of the same approver or approval of one's own request.
+	ErrRefused = errors.New("approval refused")
+	// ErrClosed is returned for approvals of requests that are no longer pending.
+	ErrClosed = errors.New("approval request is closed")
+)
+
+// Approval is one signed approval of a request.
+type Approval struct {
+	Approver   string    `json:"approver"`
+	ApprovedAt time.Time `json:"approvedAt"`
+}
+
+// Request is a sensitive operation waiting for approvals. Error is the failure of the
+// operation when its status is FAILED. The secret values of the operation, PINs, are
+// only handed to its executor: they are not listed, audited or signed, and are dropped
+// once the request is closed.
+type Request struct {
+	ID        string            `json:"id"`
+	Operation string            `json:"operation"`
+	Target    string            `json:"target"`
+	Params    map[string]string `json:"params,omitempty"`
+	Requester string            `json:"requester"`
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Approvals []Approval        `json:"approvals"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+
+	secret map[string]string
+}
+
+// Executor runs the operation of an approved request.
+type Executor func(r *Request) error
+
+// Manager holds the pending requests and the approvers of the config.
+type Manager struct {
+	threshold  int
+	ttl        time.Duration
+	operations map[string]bool
+	approvers  map[string]crypto.PublicKey
+	audit      *audit.Logger
+
+	mu        sync.Mutex
+	requests  map[string]*Request
+	executors map[string]Executor
+}
+
+// NewManager checks the approval config, guarded operations need a threshold of at least
+// two approvers and no more than there are. Every approver needs an API token of its name
+// with the approver role: approvals are given with that token.
+func NewManager(conf *configs.Config, audit *audit.Logger) (*Manager, error) {
+	c := conf.Approval
+	m := &Manager{
+		threshold:  c.Threshold,
+		ttl:        c.TTL,
+		operations: map[string]bool{},
+		approvers:  map[string]crypto.PublicKey{},
+		audit:      audit,
+		requests:   map[string]*Request{},
+		executors:  map[string]Executor{},
+	}
+	if m.ttl <= 0 {
+		m.ttl = defaultTTL
+	}
+
+	for _, a := range c.Approvers {
+		if a.Name == "" {
+			return nil, fmt.Errorf("approver without name")
+		}
+		if _, ok := m.approvers[a.Name]; ok {
+			return nil, fmt.Errorf("duplicate approver %s", a.Name)
+		}
+		if !approverToken(conf, a.Name) {
+			return nil, fmt.Errorf("approver %s has no API token of its name with the %s role", a.Name, auth.RoleApprover)
+		}
+		pub, err := ParsePublicKey([]byte(a.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("approver %s: %v", a.Name, err)
+		}
+		m.approvers[a.Name] = pub
+	}
+
+	for _, op := range c.Operations {
+		if !known(op) {
+			return nil, fmt.Errorf("unknown approval operation %s", op)
+		}
+		m.operations[op] = true
+	}
+	if len(m.operations) > 0 && (m.threshold < 2 || m.threshold > len(m.approvers)) {
+		return nil, fmt.Errorf("approval threshold %d needs to be between 2 and the %d approvers", m.threshold, len(m.approvers))
+	}
+	return m, nil
+}
+
+// ParsePublicKey reads a PEM PKIX public key: RSA, EC or Ed25519.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// Threshold is the number of approvals an operation waits for.
+func (m *Manager) Threshold() int {
+	return m.threshold
+}
+
+// Guarded reports whether the operation needs approvals.
+func (m *Manager) Guarded(op string) bool {
+	return m.operations[op]
+}
+
+// Handle sets the executor of the operation.
+func (m *Manager) Handle(op string, run Executor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executors[op] = run
+}
+
+// Guards reports whether the config puts the operation under dual control, for the
+// commands that run outside the service and cannot wait for approvals.
+func Guards(conf *configs.Config, op string) bool {
+	for _, o := range conf.Approval.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Submit creates the pending request of a guarded operation on the target.
+func (m *Manager) Submit(op, target string, params map[string]string, requester string) (*Request, error) {
+	return m.SubmitSecret(op, target, params, nil, requester)
+}
+
+// SubmitSecret is Submit for an operation that needs secret values, see Request.
+func (m *Manager) SubmitSecret(op, target string, params, secret map[string]string, requester string) (*Request, error) {
+	if !m.Guarded(op) {
+		return nil, fmt.Errorf("operation %s is not under dual control", op)
+	}
+
+	now := time.Now().UTC()
+	r := &Request{
+		ID:        hex.EncodeToString(hsm_api.GenIV(16)),
+		Operation: op,
+		Target:    target,
+		Params:    params,
+		Requester: requester,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+		Approvals: []Approval{},
+		Status:    StatusPending,
+		secret:    secret,
+	}
+
+	m.mu.Lock()
+	m.requests[r.ID] = r
+	m.mu.Unlock()
+
+	m.log(requester, "approval-requested", r, nil)
+	return r.copy(), nil
+}
+
+// Get returns a copy of the request.
+func (m *Manager) Get(id string) (*Request, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.expire(r)
+	return r.copy(), nil
+}
+
+// List returns copies of the requests, the oldest first.
+func (m *Manager) List() []*Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]*Request, 0, len(m.requests))
+	for _, r := range m.requests {
+		m.expire(r)
+		list = append(list, r.copy())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Approve adds the approval of the approver, the name of the API token of the caller, and
+// signature is over the Payload of the request.
+// The operation runs on the approval that completes the quorum, its failure is kept in
+// the request and returned.
+func (m *Manager) Approve(id, approver string, signature []byte) (*Request, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.expire(r)
+	if r.Status != StatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrClosed, r.Status)
+	}
+
+	if err := m.check(r, approver, signature); err != nil {
+		m.log(approver, "approval-refused", r, map[string]string{"reason": err.Error()})
+		return nil, fmt.Errorf("%w: %v", ErrRefused, err)
+	}
+	r.Approvals = append(r.Approvals, Approval{Approver: approver, ApprovedAt: time.Now().UTC()})
+	m.log(approver, "approval-granted", r, nil)
+
+	if len(r.Approvals) < m.threshold {
+		return r.copy(), nil
+	}
+
+	run, ok := m.executors[r.Operation]
+	if !ok {
+		r.Status, r.Error = StatusFailed, "no executor for the operation"
+	} else if err := run(r.withSecret()); err != nil {
+		r.Status, r.Error = StatusFailed, err.Error()
+	} else {
+		r.Status = StatusExecuted
+	}
+	r.secret = nil
+
+	if r.Status == StatusFailed {
+		m.log(approver, "approval-failed", r, map[string]string{"error": r.Error})
+		return r.copy(), fmt.Errorf("approved operation failed: %s", r.Error)
+	}
+	m.log(approver, "approval-executed", r, nil)
+	return r.copy(), nil
+}
+
+// Cancel closes the pending requests of the operation on the target that match, when the
+// target they name is gone. It returns the number of requests closed.
+func (m *Manager) Cancel(op, target string, match func(r *Request) bool, actor, reason string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, r := range m.requests {
+		m.expire(r)
+		if r.Status != StatusPending || r.Operation != op || r.Target != target || !match(r) {
+			continue
+		}
+		r.Status, r.Error = StatusCanceled, reason
+		r.secret = nil
+		m.log(actor, "approval-canceled", r, map[string]string{"reason": reason})
+		n++
+	}
+	return n
+}
+
+// Payload is what approvers sign: the JSON of the request fields that define the
+// operation, the approvals and status left out.
+func (r *Request) Payload() []byte {
+	b, _ := json.Marshal(struct {
+		ID        string            `json:"id"`
+		Operation string            `json:"operation"`
+		Target    string            `json:"target"`
+		Params    map[string]string `json:"params,omitempty"`
+		Requester string            `json:"requester"`
+		ExpiresAt time.Time         `json:"expiresAt"`
+	}{r.ID, r.Operation, r.Target, r.Params, r.Requester, r.ExpiresAt})
+	return b
+}
+
+// check refuses unknown approvers, requesters approving their own request, second
+// approvals and bad signatures. Called with the lock held.
+func (m *Manager) check(r *Request, approver string, signature []byte) error {
+	pub, ok := m.approvers[approver]
+	if !ok {
+		return fmt.Errorf("unknown approver %s", approver)
+	}
+	if approver == r.Requester {
+		return fmt.Errorf("%s cannot approve its own request", approver)
+	}
+	for _, a := range r.Approvals {
+		if a.Approver == approver {
+			return fmt.Errorf("%s already approved", approver)
+		}
+	}
+	if !verify(pub, r.Payload(), signature) {
+		return fmt.Errorf("invalid signature of %s", approver)
+	}
+	return nil
+}
+
+// expire closes a pending request past its expiry. Called with the lock held.
+func (m *Manager) expire(r *Request) {
+	if r.Status == StatusPending && time.Now().After(r.ExpiresAt) {
+		r.Status = StatusExpired
+		r.secret = nil
+		m.log("", "approval-expired", r, nil)
+	}
+}
+
+func (m *Manager) log(actor, action string, r *Request, detail map[string]string) {
+	d := map[string]string{"request": r.ID, "operation": r.Operation, "approvals": fmt.Sprint(len(r.Approvals))}
+	for k, v := range r.Params {
+		d[k] = v
+	}
+	for k, v := range detail {
+		d[k] = v
+	}
+	m.audit.Log(audit.Event{Actor: actor, Action: action, Key: r.Target, Detail: d})
+}
+
+// verify checks an RSA PKCS#1 v1.5 or ECDSA (ASN.1) signature of the SHA-256 of the
+// payload, or an Ed25519 signature of the payload.
+func verify(pub crypto.PublicKey, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		var sig struct{ R, S *big.Int }
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) > 0 || sig.R == nil || sig.S == nil {
+			return false
+		}
+		return ecdsa.Verify(k, digest[:], sig.R, sig.S)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	}
+	return false
+}
+
+func approverToken(conf *configs.Config, name string) bool {
+	for _, t := range conf.Auth.Tokens {
+		if t.Name != name {
+			continue
+		}
+		for _, r := range t.Roles {
+			if r == auth.RoleApprover {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func known(op string) bool {
+	for _, o := range operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret returns the secret value of the name, only set in the request given to the executor.
+func (r *Request) Secret(name string) string {
+	return r.secret[name]
+}
+
+// copy leaves the secret values out.
+func (r *Request) copy() *Request {
+	c := *r
+	c.secret = nil
+	c.Approvals = append([]Approval{}, r.Approvals...)
+	if r.Params != nil {
+		c.Params = make(map[string]string, len(r.Params))
+		for k, v := range r.Params {
+			c.Params[k] = v
+		}
+	}
+	return &c
+}
+
+func (r *Request) withSecret() *Request {
+	c := r.copy()
+	c.secret = r.secret
+	return c
+}
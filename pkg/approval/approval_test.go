@@ -0,0 +1,267 @@
+package approval
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"hsm/configs"
+	"hsm/pkg/auth"
+)
+
+func TestLoadDevConfig(t *testing.T) {
+	conf := configs.LoadConfig("../../configs", "dev")
+	if conf == nil {
+		t.Fatal("failed to load dev config")
+	}
+
+	m, err := NewManager(conf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range operations {
+		if !m.Guarded(op) || !Guards(conf, op) {
+			t.Errorf("%s is not guarded", op)
+		}
+	}
+
+	// the dev keys of misc/approval sign for the approvers of the config
+	for _, name := range []string{"officer-a", "officer-b"} {
+		b, err := ioutil.ReadFile("../../misc/approval/" + name + ".pem")
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(b)
+		priv, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verify(m.approvers[name], []byte("payload"), sign(t, priv, []byte("payload"))) {
+			t.Errorf("%s: dev key does not match the config", name)
+		}
+	}
+}
+
+type signer struct {
+	name string
+	key  crypto.Signer
+}
+
+func testManager(t *testing.T, threshold int) (*Manager, []signer) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signers := []signer{{"officer-a", rsaKey}, {"officer-b", ecKey}, {"officer-c", edKey}}
+	conf := &configs.Config{Approval: configs.Approval{Threshold: threshold, Operations: []string{OpDeleteKey, OpChangePIN}}}
+	for _, s := range signers {
+		der, err := x509.MarshalPKIXPublicKey(s.key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf.Approval.Approvers = append(conf.Approval.Approvers, configs.Approver{
+			Name:      s.name,
+			PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		})
+		conf.Auth.Tokens = append(conf.Auth.Tokens, configs.APIToken{Name: s.name, Roles: []string{auth.RoleApprover}})
+	}
+
+	m, err := NewManager(conf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, signers
+}
+
+func sign(t *testing.T, key crypto.Signer, payload []byte) []byte {
+	var sig []byte
+	var err error
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		sig, err = key.Sign(rand.Reader, payload, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(payload)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestQuorum(t *testing.T) {
+	m, signers := testManager(t, 2)
+
+	var executed []string
+	m.Handle(OpDeleteKey, func(r *Request) error {
+		executed = append(executed, r.Target)
+		return nil
+	})
+
+	r, err := m.Submit(OpDeleteKey, "data-key", map[string]string{"id": "01"}, "officer-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the requester cannot approve, a signature of another request does not count
+	if _, err := m.Approve(r.ID, "officer-a", sign(t, signers[0].key, r.Payload())); !errors.Is(err, ErrRefused) {
+		t.Errorf("self approval: got %v", err)
+	}
+	if _, err := m.Approve(r.ID, "officer-b", sign(t, signers[1].key, []byte("other"))); !errors.Is(err, ErrRefused) {
+		t.Errorf("bad signature: got %v", err)
+	}
+
+	r, err = m.Approve(r.ID, "officer-b", sign(t, signers[1].key, r.Payload()))
+	if err != nil || r.Status != StatusPending || len(executed) != 0 {
+		t.Fatalf("first approval: got %+v, %v", r, err)
+	}
+	if _, err := m.Approve(r.ID, "officer-b", sign(t, signers[1].key, r.Payload())); !errors.Is(err, ErrRefused) {
+		t.Errorf("second approval of the same approver: got %v", err)
+	}
+
+	r, err = m.Approve(r.ID, "officer-c", sign(t, signers[2].key, r.Payload()))
+	if err != nil || r.Status != StatusExecuted || len(executed) != 1 || executed[0] != "data-key" {
+		t.Fatalf("quorum: got %+v, %v, executed %v", r, err, executed)
+	}
+	if _, err := m.Approve(r.ID, "officer-a", sign(t, signers[0].key, r.Payload())); !errors.Is(err, ErrClosed) {
+		t.Errorf("approval after execution: got %v", err)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	m, signers := testManager(t, 2)
+
+	var pin string
+	m.Handle(OpChangePIN, func(r *Request) error {
+		pin = r.Secret("newPin")
+		return nil
+	})
+
+	r, err := m.SubmitSecret(OpChangePIN, "hsm", map[string]string{"userType": "user"}, map[string]string{"newPin": "s3cret-PIN"}, "token-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := m.List()[0]
+	if r.Secret("newPin") != "" || listed.Secret("newPin") != "" || bytes.Contains(r.Payload(), []byte("s3cret-PIN")) {
+		t.Error("secret visible outside the executor")
+	}
+
+	for _, s := range signers[1:] {
+		if _, err := m.Approve(r.ID, s.name, sign(t, s.key, r.Payload())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pin != "s3cret-PIN" {
+		t.Errorf("executor got pin %q", pin)
+	}
+	if m.requests[r.ID].secret != nil {
+		t.Error("secret kept after execution")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	m, signers := testManager(t, 2)
+	m.Handle(OpDeleteKey, func(r *Request) error { return errors.New("not expected") })
+
+	r, err := m.Submit(OpDeleteKey, "data-key", nil, "key-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.requests[r.ID].ExpiresAt = time.Now().Add(-time.Second)
+
+	if _, err := m.Approve(r.ID, "officer-a", sign(t, signers[0].key, r.Payload())); !errors.Is(err, ErrClosed) {
+		t.Errorf("approval of an expired request: got %v", err)
+	}
+	if r, _ := m.Get(r.ID); r.Status != StatusExpired {
+		t.Errorf("got status %s, want %s", r.Status, StatusExpired)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	m, signers := testManager(t, 2)
+	m.Handle(OpDeleteKey, func(r *Request) error { return errors.New("not expected") })
+
+	gone, err := m.Submit(OpDeleteKey, "data-key", map[string]string{"id": "01"}, "key-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := m.Submit(OpDeleteKey, "data-key", map[string]string{"id": "02"}, "key-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := m.Cancel(OpDeleteKey, "data-key", func(r *Request) bool { return r.Params["id"] == "01" }, "key-admin", "key destroyed")
+	if n != 1 {
+		t.Fatalf("got %d canceled, want 1", n)
+	}
+	if r, _ := m.Get(gone.ID); r.Status != StatusCanceled || r.Error != "key destroyed" {
+		t.Errorf("got %+v, want %s", r, StatusCanceled)
+	}
+	if _, err := m.Approve(gone.ID, "officer-a", sign(t, signers[0].key, gone.Payload())); !errors.Is(err, ErrClosed) {
+		t.Errorf("approval of a canceled request: got %v", err)
+	}
+	if r, _ := m.Get(other.ID); r.Status != StatusPending {
+		t.Errorf("got status %s, want %s", r.Status, StatusPending)
+	}
+}
+
+func TestNewManager(t *testing.T) {
+	cases := map[string]configs.Approval{
+		"single approver":   {Threshold: 1, Operations: []string{OpDeleteKey}},
+		"unknown operation": {Threshold: 2, Operations: []string{"format-token"}},
+		"invalid key":       {Approvers: []configs.Approver{{Name: "officer-a", PublicKey: "key"}}},
+	}
+	for name, c := range cases {
+		if _, err := NewManager(&configs.Config{Approval: c}, nil); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+
+	// an approver approves with the API token of its name, which needs the approver role
+	conf := &configs.Config{}
+	conf.Approval.Approvers = []configs.Approver{{Name: "officer-a", PublicKey: testPublicKey(t)}}
+	for name, tokens := range map[string][]configs.APIToken{
+		"no token":   nil,
+		"other role": {{Name: "officer-a", Roles: []string{auth.RoleKeyAdmin}}},
+	} {
+		conf.Auth.Tokens = tokens
+		if _, err := NewManager(conf, nil); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	conf.Auth.Tokens = []configs.APIToken{{Name: "officer-a", Roles: []string{auth.RoleApprover}}}
+	if _, err := NewManager(conf, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func testPublicKey(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
@@ -0,0 +1,60 @@
+// Package audit appends security relevant events to a JSON lines file.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event is one line of the audit log.
+type Event struct {
+	Time   time.Time         `json:"time"`
+	Actor  string            `json:"actor"`
+	Action string            `json:"action"`
+	Key    string            `json:"key,omitempty"`
+	Detail map[string]string `json:"detail,omitempty"`
+}
+
+// Logger writes events to the file, a logger without path only logs them to the standard logger.
+type Logger struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogger(path string) *Logger {
+	return &Logger{path: path}
+}
+
+// Log appends the event, the time is set when it is empty.
+func (l *Logger) Log(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %v", err)
+	}
+	if l == nil || l.path == "" {
+		log.Printf("audit: %s", b)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
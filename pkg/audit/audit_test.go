@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := NewLogger(filepath.Join(dir, "audit.log"))
+	for _, action := range []string{"activate", "deactivate"} {
+		if err := l.Log(Event{Actor: "key-admin", Action: action, Key: "n2k-master-key"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []Event
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		e := Event{}
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || events[1].Action != "deactivate" || events[0].Time.IsZero() {
+		t.Errorf("got events %+v", events)
+	}
+}
@@ -0,0 +1,127 @@
+// Package auth authorizes gRPC calls with bearer API tokens mapped to roles in the config.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"hsm/configs"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// roles granted to API tokens
+const (
+	RoleKeyAdmin   = "key-admin"
+	RoleKeyReader  = "key-reader"
+	RoleTokenAdmin = "token-admin"
+	RoleData       = "data-user"     // encryption, decryption and re-encryption under the key aliases
+	RoleMAC        = "mac-user"      // MAC generation and verification
+	RolePIN        = "pin-user"      // PIN verification, offsets and PVVs
+	RoleCustodian  = "key-custodian" // key ceremonies, on top of the custodian passphrases
+	RoleApprover   = "approver"      // approvals of dual control requests, with the key of the approver
+)
+
+type (
+	callerKey struct{}
+	tenantKey struct{}
+)
+
+// Authorizer checks that the caller token has one of the roles required by the method, the
+// keys are those of its tenant or the global ones for a token without tenant. Methods
+// without rules, and rules without roles, are refused to every caller.
+type Authorizer struct {
+	tokens []configs.APIToken
+	rules  map[string][]string
+}
+
+// New creates an authorizer, rules maps full gRPC method names to the accepted roles.
+func New(tokens []configs.APIToken, rules map[string][]string) *Authorizer {
+	return &Authorizer{tokens: tokens, rules: rules}
+}
+
+// UnaryInterceptor rejects unauthorized calls before they reach the handler and stores
+// the caller name and tenant in the context.
+func (a *Authorizer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	token, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole(token, a.rules[info.FullMethod]) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not allowed to call this method", token.Name)
+	}
+
+	ctx = context.WithValue(ctx, callerKey{}, token.Name)
+	return handler(context.WithValue(ctx, tenantKey{}, token.Tenant), req)
+}
+
+// Authorize returns the name of the token in the incoming metadata if it has one of the roles.
+// The HTTP gateway forwards the Authorization header as metadata.
+func (a *Authorizer) Authorize(ctx context.Context, roles []string) (string, error) {
+	token, err := a.authenticate(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !hasRole(token, roles) {
+		return "", status.Errorf(codes.PermissionDenied, "%s is not allowed to call this method", token.Name)
+	}
+	return token.Name, nil
+}
+
+func (a *Authorizer) authenticate(ctx context.Context) (*configs.APIToken, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	token := a.token(strings.TrimPrefix(values[0], "Bearer "))
+	if token == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	return token, nil
+}
+
+func hasRole(token *configs.APIToken, roles []string) bool {
+	for _, have := range token.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// token finds the configured token by its hash, every entry is compared in constant time.
+func (a *Authorizer) token(bearer string) *configs.APIToken {
+	sum := sha256.Sum256([]byte(bearer))
+	hash := []byte(hex.EncodeToString(sum[:]))
+
+	var found *configs.APIToken
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(a.tokens[i].TokenHash))) == 1 {
+			found = &a.tokens[i]
+		}
+	}
+	return found
+}
+
+// Caller returns the name of the authorized token, empty outside of the interceptor.
+func Caller(ctx context.Context) string {
+	name, _ := ctx.Value(callerKey{}).(string)
+	return name
+}
+
+// Tenant returns the tenant of the authorized token, empty for tokens without tenant and
+// outside of the interceptor.
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
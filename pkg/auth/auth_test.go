@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"hsm/configs"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	method       = "/crypto.KeyManagement/CreateKey"
+	cryptoMethod = "/crypto.Crypto/Encrypt"
+	emptyMethod  = "/crypto.Crypto/GetKeyCheckValue"
+	noRule       = "/crypto.KeyCeremony/StartCeremony"
+)
+
+// rules of the tests, the rule of emptyMethod has no roles
+var rules = map[string][]string{method: {RoleKeyAdmin}, cryptoMethod: {RoleData}, emptyMethod: {}}
+
+// sha256 of "admin-token", "reader-token", "cards-token" and "app-token"
+var tokens = []configs.APIToken{
+	{Name: "admin", TokenHash: "10a4c7c9fc5206d6f36dc6944a81bb6f4a3cb0e25014ae3b12e6c3e52712292a", Roles: []string{RoleKeyAdmin}},
+	{Name: "reader", TokenHash: "ba5005a40cf5212e4ac0190104cc127edab013294bb71279a975b27a80982d45", Roles: []string{RoleKeyReader}},
+	{Name: "cards", TokenHash: "f0f57901a377e5248fc0756e32066f719214d2cb7c1663963a095f475b51d3c0", Roles: []string{RoleKeyAdmin, RoleData}, Tenant: "cards"},
+	{Name: "app", TokenHash: "7f14c33dfe13ac4af4884e14da5760f9b930205aa8055478c3e74296470d71af", Roles: []string{RoleData}},
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	a := New(tokens, rules)
+
+	call := func(fullMethod, bearer string) (string, error) {
+		ctx := context.Background()
+		if bearer != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+bearer))
+		}
+		res, err := a.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return Caller(ctx), nil
+			})
+		if err != nil {
+			return "", err
+		}
+		return res.(string), nil
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		bearer string
+		caller string
+		code   codes.Code
+	}{
+		{"admin", method, "admin-token", "admin", codes.OK},
+		{"reader", method, "reader-token", "", codes.PermissionDenied},
+		{"unknown token", method, "other-token", "", codes.Unauthenticated},
+		{"no token", method, "", "", codes.Unauthenticated},
+		{"data role", cryptoMethod, "app-token", "app", codes.OK},
+		{"crypto without data role", cryptoMethod, "reader-token", "", codes.PermissionDenied},
+		{"crypto without token", cryptoMethod, "", "", codes.Unauthenticated},
+		{"crypto with unknown token", cryptoMethod, "other-token", "", codes.Unauthenticated},
+		{"rule without roles", emptyMethod, "admin-token", "", codes.PermissionDenied},
+		{"no rule", noRule, "admin-token", "", codes.PermissionDenied},
+		{"no rule without token", noRule, "", "", codes.Unauthenticated},
+	}
+	for _, c := range cases {
+		caller, err := call(c.method, c.bearer)
+		if status.Code(err) != c.code {
+			t.Errorf("%s: got code %v, want %v", c.name, status.Code(err), c.code)
+		}
+		if caller != c.caller {
+			t.Errorf("%s: got caller %q, want %q", c.name, caller, c.caller)
+		}
+	}
+}
+
+func TestTenant(t *testing.T) {
+	a := New(tokens, rules)
+
+	call := func(fullMethod, bearer string) (string, error) {
+		ctx := context.Background()
+		if bearer != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+bearer))
+		}
+		res, err := a.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return Tenant(ctx), nil
+			})
+		if err != nil {
+			return "", err
+		}
+		return res.(string), nil
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		bearer string
+		tenant string
+		code   codes.Code
+	}{
+		{"tenant token", method, "cards-token", "cards", codes.OK},
+		{"token without tenant", method, "admin-token", "", codes.OK},
+		{"crypto with tenant token", cryptoMethod, "cards-token", "cards", codes.OK},
+		{"crypto without tenant", cryptoMethod, "app-token", "", codes.OK},
+		{"no rule with tenant token", noRule, "cards-token", "", codes.PermissionDenied},
+	}
+	for _, c := range cases {
+		tenant, err := call(c.method, c.bearer)
+		if status.Code(err) != c.code {
+			t.Errorf("%s: got code %v, want %v", c.name, status.Code(err), c.code)
+		}
+		if tenant != c.tenant {
+			t.Errorf("%s: got tenant %q, want %q", c.name, tenant, c.tenant)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+// Package backup exports the extractable token keys wrapped under a backup key-encryption
+// key and restores them into a token. The KEK itself is only kept as M-of-N Shamir shares
+// held by the custodians.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hsm/configs"
+	"hsm/pkg/byok"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+	"hsm/pkg/metadata"
+	"hsm/pkg/policy"
+	"hsm/pkg/shamir"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const kekLength = 32
+
+// check methods of a backed up key
+const (
+	CheckKCV    = "kcv"    // key check value of a DES/3DES or AES key
+	CheckRewrap = "rewrap" // SHA-256 of the wrapped key, it is wrapped again after restore
+)
+
+type (
+	// Bundle is the file holding the wrapped keys, it is useless without the shares.
+	Bundle struct {
+		ID        string    `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		Threshold int       `json:"threshold"`
+		Shares    int       `json:"shares"`
+		KEKCheck  string    `json:"kek_check"`
+		Keys      []Key     `json:"keys"`
+		// Skipped are the labels of the keys that cannot be extracted, a restore of the
+		// bundle does not bring them back.
+		Skipped []string `json:"skipped,omitempty"`
+		// Records are the service records and the metadata of the keys, sealed under the KEK.
+		Records string `json:"records,omitempty"`
+
+		// Metadata of the restored keys, for the caller to put back into its store.
+		Metadata []*metadata.Entry `json:"-"`
+	}
+
+	// Key is a wrapped private or secret key with the attributes to restore it.
+	Key struct {
+		Class   uint            `json:"class"`
+		KeyType uint            `json:"key_type"`
+		Label   string          `json:"label"`
+		ID      string          `json:"id,omitempty"`
+		Flags   map[string]bool `json:"flags"`
+		Wrapped string          `json:"wrapped"`
+		Check   string          `json:"check"`
+		Method  string          `json:"check_method"`
+		Public  *PublicKey      `json:"public,omitempty"`
+	}
+
+	// PublicKey is the clear RSA or EC public key of a backed up private key.
+	PublicKey struct {
+		Modulus  string          `json:"modulus,omitempty"`
+		Exponent string          `json:"exponent,omitempty"`
+		ECParams string          `json:"ec_params,omitempty"`
+		ECPoint  string          `json:"ec_point,omitempty"`
+		Flags    map[string]bool `json:"flags"`
+	}
+
+	// Record is a data object the service keeps about a backed up key: its lifecycle state,
+	// policy, provenance or key ring. Value is the JSON value of the object.
+	Record struct {
+		Application string `json:"application"`
+		Label       string `json:"label"`
+		Value       string `json:"value"`
+	}
+
+	sealedRecords struct {
+		Records  []Record          `json:"records"`
+		Metadata []*metadata.Entry `json:"metadata,omitempty"`
+	}
+
+	// Share is the file given to one custodian.
+	Share struct {
+		Bundle    string `json:"bundle"`
+		Threshold int    `json:"threshold"`
+		Value     string `json:"share"`
+	}
+
+	// Result tells what happened to one key of the token or of the bundle.
+	Result struct {
+		Label  string `json:"label"`
+		Class  string `json:"class"`
+		Status string `json:"status"`
+	}
+)
+
+// attributes copied from the original object to the restored one
+var flagAttributes = map[string]uint{
+	"encrypt":     pkcs11.CKA_ENCRYPT,
+	"decrypt":     pkcs11.CKA_DECRYPT,
+	"sign":        pkcs11.CKA_SIGN,
+	"verify":      pkcs11.CKA_VERIFY,
+	"wrap":        pkcs11.CKA_WRAP,
+	"unwrap":      pkcs11.CKA_UNWRAP,
+	"derive":      pkcs11.CKA_DERIVE,
+	"sensitive":   pkcs11.CKA_SENSITIVE,
+	"extractable": pkcs11.CKA_EXTRACTABLE,
+}
+
+var classes = []uint{pkcs11.CKO_SECRET_KEY, pkcs11.CKO_PRIVATE_KEY}
+
+// applications of the service records of the keys, the ring records are per label and the
+// others per label and id
+var recordApplications = []string{lifecycle.Application, policy.Application, byok.Application, hsm_api.RingApplication}
+
+// Create wraps every extractable secret and private key of the token under a fresh KEK
+// and writes the bundle to the backup directory, with the service records and the entries
+// of meta that belong to the keys. Each share of the KEK is written to the directory of its
+// custodian or, with no custodians configured, printed once to out. The keys that cannot
+// be extracted are listed in Skipped.
+func Create(conf *configs.Config, ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, shares, threshold int, meta []*metadata.Entry, out io.Writer) (*Bundle, []Result, error) {
+	if err := CheckDestinations(conf, shares, out); err != nil {
+		return nil, nil, err
+	}
+
+	kek := hsm_api.GenIV(kekLength)
+	if kek == nil {
+		return nil, nil, fmt.Errorf("failed to generate kek")
+	}
+	defer zero(kek)
+
+	parts, err := shamir.Split(kek, shares, threshold)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to split kek: %v", err)
+	}
+
+	kekCheck, err := hsm_api.SoftKeyCheckValue(kek, hsm_api.KeyTypeAES256, hsm_api.KCVMethodCMAC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tk, err := hsm_api.ImportTransportKey(ctx, ss, kek, pkcs11.CKA_WRAP)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hsm_api.RemoveKey(ctx, ss, tk)
+
+	b := &Bundle{
+		ID:        time.Now().UTC().Format("20060102T150405Z"),
+		CreatedAt: time.Now().UTC(),
+		Threshold: threshold,
+		Shares:    shares,
+		KEKCheck:  hex.EncodeToString(kekCheck),
+	}
+
+	var results []Result
+	for _, class := range classes {
+		objs, err := hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, obj := range objs {
+			k, status, err := wrap(ctx, ss, tk, obj, class)
+			if err != nil {
+				return nil, nil, err
+			}
+			results = append(results, Result{Label: k.Label, Class: hsm_api.ClassNames[class], Status: status})
+			if status == "exported" {
+				b.Keys = append(b.Keys, *k)
+			} else {
+				b.Skipped = append(b.Skipped, k.Label)
+			}
+		}
+	}
+
+	sealed := sealedRecords{}
+	if sealed.Records, err = records(ctx, ss, b.Keys); err != nil {
+		return nil, nil, err
+	}
+	ids := map[string]bool{}
+	for _, k := range b.Keys {
+		ids[k.ID] = true
+	}
+	for _, e := range meta {
+		if ids[e.ID] {
+			sealed.Metadata = append(sealed.Metadata, e)
+		}
+	}
+	if b.Records, err = seal(kek, b.ID, sealed); err != nil {
+		return nil, nil, err
+	}
+
+	if err := write(conf, b, parts, out); err != nil {
+		return nil, nil, err
+	}
+	return b, results, nil
+}
+
+// wrap exports one key, non-extractable keys are reported and left out.
+func wrap(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, tk, obj pkcs11.ObjectHandle, class uint) (*Key, string, error) {
+	attrs, err := hsm_api.GetAttributes(ctx, ss, obj, pkcs11.CKA_KEY_TYPE, pkcs11.CKA_LABEL, pkcs11.CKA_ID)
+	if err != nil {
+		return nil, "", err
+	}
+	k := &Key{
+		Class:   class,
+		KeyType: hsm_api.AttributeUint(attrs[pkcs11.CKA_KEY_TYPE]),
+		Label:   string(attrs[pkcs11.CKA_LABEL]),
+		ID:      hex.EncodeToString(attrs[pkcs11.CKA_ID]),
+	}
+
+	k.Flags, err = flags(ctx, ss, obj)
+	if err != nil {
+		return nil, "", err
+	}
+	if !k.Flags["extractable"] {
+		return k, "not extractable", nil
+	}
+
+	wrapped, err := hsm_api.WrapKey(ctx, ss, tk, obj)
+	if err != nil {
+		return nil, "", fmt.Errorf("key %s: %v", k.Label, err)
+	}
+	k.Wrapped = hex.EncodeToString(wrapped)
+
+	// the kcv needs an encryption key, the others are checked by wrapping them again
+	var kcv []byte
+	if class == pkcs11.CKO_SECRET_KEY {
+		kcv, _ = hsm_api.KeyCheckValue(ctx, ss, obj, "")
+	}
+	if kcv != nil {
+		k.Check, k.Method = hex.EncodeToString(kcv), CheckKCV
+	} else {
+		sum := sha256.Sum256(wrapped)
+		k.Check, k.Method = hex.EncodeToString(sum[:]), CheckRewrap
+	}
+
+	if class == pkcs11.CKO_PRIVATE_KEY && (k.KeyType == pkcs11.CKK_RSA || k.KeyType == pkcs11.CKK_EC) {
+		if k.Public, err = publicKey(ctx, ss, k); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return k, "exported", nil
+}
+
+// publicKey copies the public key of the same label and id as the private key, if any.
+func publicKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, k *Key) (*PublicKey, error) {
+	id, _ := hex.DecodeString(k.ID)
+	objs, err := hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, k.Label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil || len(objs) == 0 {
+		return nil, err
+	}
+
+	f, err := flags(ctx, ss, objs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if k.KeyType == pkcs11.CKK_EC {
+		attrs, err := hsm_api.GetAttributes(ctx, ss, objs[0], pkcs11.CKA_EC_PARAMS, pkcs11.CKA_EC_POINT)
+		if err != nil {
+			return nil, err
+		}
+		return &PublicKey{
+			ECParams: hex.EncodeToString(attrs[pkcs11.CKA_EC_PARAMS]),
+			ECPoint:  hex.EncodeToString(attrs[pkcs11.CKA_EC_POINT]),
+			Flags:    f,
+		}, nil
+	}
+
+	attrs, err := hsm_api.GetAttributes(ctx, ss, objs[0], pkcs11.CKA_MODULUS, pkcs11.CKA_PUBLIC_EXPONENT)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{
+		Modulus:  hex.EncodeToString(attrs[pkcs11.CKA_MODULUS]),
+		Exponent: hex.EncodeToString(attrs[pkcs11.CKA_PUBLIC_EXPONENT]),
+		Flags:    f,
+	}, nil
+}
+
+// records collects the service records of the keys.
+func records(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, keys []Key) ([]Record, error) {
+	labels, ids := map[string]bool{}, map[string]bool{}
+	for _, k := range keys {
+		labels[k.Label] = true
+		ids[recordKey(k.Label, k.ID)] = true
+	}
+
+	records := []Record{}
+	for _, app := range recordApplications {
+		objs, err := hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, app),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			attrs, err := hsm_api.GetAttributes(ctx, ss, obj, pkcs11.CKA_LABEL, pkcs11.CKA_VALUE)
+			if err != nil {
+				return nil, err
+			}
+			r := Record{Application: app, Label: string(attrs[pkcs11.CKA_LABEL]), Value: string(attrs[pkcs11.CKA_VALUE])}
+			if app == hsm_api.RingApplication {
+				if labels[r.Label] {
+					records = append(records, r)
+				}
+				continue
+			}
+			id, err := r.keyID()
+			if err != nil {
+				return nil, err
+			}
+			if ids[recordKey(r.Label, id)] {
+				records = append(records, r)
+			}
+		}
+	}
+	return records, nil
+}
+
+// keyID returns the hex id of the key of a per key record.
+func (r Record) keyID() (string, error) {
+	v := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal([]byte(r.Value), &v); err != nil {
+		return "", fmt.Errorf("invalid %s record of %s: %v", r.Application, r.Label, err)
+	}
+	return v.ID, nil
+}
+
+func recordKey(label, id string) string {
+	return label + "\x00" + id
+}
+
+// seal encrypts the records with AES-256-GCM under the KEK, the bundle id is the additional
+// data; the result is hex, the nonce first.
+func seal(kek []byte, bundle string, v sealedRecords) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal records: %v", err)
+	}
+	defer zero(data)
+
+	aead, err := recordCipher(kek)
+	if err != nil {
+		return "", err
+	}
+	nonce := hsm_api.GenIV(aead.NonceSize())
+	if nonce == nil {
+		return "", fmt.Errorf("failed to generate nonce")
+	}
+	return hex.EncodeToString(aead.Seal(nonce, nonce, data, []byte(bundle))), nil
+}
+
+// unseal decrypts the records of the bundle, see seal.
+func unseal(kek []byte, b *Bundle) (*sealedRecords, error) {
+	v := &sealedRecords{}
+	if b.Records == "" {
+		return v, nil
+	}
+	sealed, err := hex.DecodeString(b.Records)
+	if err != nil {
+		return nil, fmt.Errorf("invalid records: %v", err)
+	}
+
+	aead, err := recordCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid records: too short")
+	}
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(b.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open records: %v", err)
+	}
+	defer zero(data)
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to parse records: %v", err)
+	}
+	return v, nil
+}
+
+func recordCipher(kek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record cipher: %v", err)
+	}
+	return aead, nil
+}
+
+func flags(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) (map[string]bool, error) {
+	types := make([]uint, 0, len(flagAttributes))
+	for _, t := range flagAttributes {
+		types = append(types, t)
+	}
+
+	attrs, err := hsm_api.GetAttributes(ctx, ss, obj, types...)
+	if err != nil {
+		return nil, err
+	}
+
+	f := map[string]bool{}
+	for name, t := range flagAttributes {
+		if v, ok := attrs[t]; ok {
+			f[name] = hsm_api.AttributeBool(v)
+		}
+	}
+	return f, nil
+}
+
+// CheckDestinations refuses a backup whose shares would land next to the bundle: every
+// share needs a custodian directory outside the backup directory, or out to print it.
+func CheckDestinations(conf *configs.Config, shares int, out io.Writer) error {
+	custodians := conf.Backup.Custodians
+	if len(custodians) == 0 {
+		if out == nil {
+			return fmt.Errorf("backup.custodians are needed to hand out the kek shares")
+		}
+		return nil
+	}
+	if len(custodians) != shares {
+		return fmt.Errorf("%d kek shares for %d backup custodians", shares, len(custodians))
+	}
+
+	dir, err := filepath.Abs(conf.Backup.Dir)
+	if err != nil {
+		return fmt.Errorf("invalid backup directory: %v", err)
+	}
+	seen := map[string]string{}
+	for _, c := range custodians {
+		if c.Name == "" || c.Path == "" {
+			return fmt.Errorf("backup custodian without name or path")
+		}
+		path, err := filepath.Abs(c.Path)
+		if err != nil {
+			return fmt.Errorf("invalid path of custodian %s: %v", c.Name, err)
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("share of custodian %s would be stored in the backup directory", c.Name)
+		}
+		if other, ok := seen[path]; ok {
+			return fmt.Errorf("custodians %s and %s share %s", other, c.Name, c.Path)
+		}
+		seen[path] = c.Name
+
+		// a medium that is not mounted leaves an empty mount point on the local disk
+		if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+			return fmt.Errorf("share directory %s of custodian %s is not available", c.Path, c.Name)
+		}
+	}
+	return nil
+}
+
+// write saves the bundle to the backup directory and hands out the shares, see Create.
+// The shares are never stored in the backup directory.
+func write(conf *configs.Config, b *Bundle, parts [][]byte, out io.Writer) error {
+	defer func() {
+		for _, part := range parts {
+			zero(part)
+		}
+	}()
+
+	dir := conf.Backup.Dir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	if err := writeJSON(filepath.Join(dir, b.ID+".bundle.json"), b); err != nil {
+		return err
+	}
+
+	for i, part := range parts {
+		s := Share{Bundle: b.ID, Threshold: b.Threshold, Value: hex.EncodeToString(part)}
+		if len(conf.Backup.Custodians) == 0 {
+			if err := printShare(out, i+1, len(parts), s); err != nil {
+				return err
+			}
+			continue
+		}
+		c := conf.Backup.Custodians[i]
+		if err := writeJSON(filepath.Join(c.Path, fmt.Sprintf("%s.share-%s.json", b.ID, c.Name)), s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printShare shows one share in the format of a share file, for its custodian to copy.
+func printShare(out io.Writer, n, total int, s Share) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal share: %v", err)
+	}
+	if _, err := fmt.Fprintf(out, "share %d of %d, save it as %s.share-%d.json:\n%s\n", n, total, s.Bundle, n, data); err != nil {
+		return fmt.Errorf("failed to print share: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", filepath.Base(path), err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+	"hsm/pkg/metadata"
+	"hsm/pkg/shamir"
+)
+
+func TestCombine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kek := hsm_api.GenIV(kekLength)
+	check, _ := hsm_api.SoftKeyCheckValue(kek, hsm_api.KeyTypeAES256, hsm_api.KCVMethodCMAC)
+	b := &Bundle{ID: "test", Threshold: 2, Shares: 3, KEKCheck: hex.EncodeToString(check)}
+
+	parts, err := shamir.Split(kek, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &configs.Config{Backup: configs.Backup{Dir: filepath.Join(dir, "bundles")}}
+	for i := 1; i <= 3; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("custodian-%d", i))
+		if err := os.Mkdir(path, 0700); err != nil {
+			t.Fatal(err)
+		}
+		conf.Backup.Custodians = append(conf.Backup.Custodians, configs.ShareCustodian{Name: fmt.Sprint(i), Path: path})
+	}
+	if err := write(conf, b, parts, nil); err != nil {
+		t.Fatal(err)
+	}
+	share := func(i int) string {
+		return filepath.Join(dir, fmt.Sprintf("custodian-%d", i), fmt.Sprintf("test.share-%d.json", i))
+	}
+
+	// only the bundle is next to the bundle
+	files, err := ioutil.ReadDir(conf.Backup.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "test.bundle.json" {
+		t.Errorf("unexpected files in the backup directory: %v", files)
+	}
+
+	got, err := combine(b, []string{share(3), share(1)})
+	if err != nil {
+		t.Error(err)
+	}
+	if hex.EncodeToString(got) != hex.EncodeToString(kek) {
+		t.Error("kek mismatch")
+	}
+
+	if _, err := combine(b, []string{share(2)}); err == nil {
+		t.Error("expected error below the threshold")
+	}
+
+	// a share of another backup does not match the kek check
+	other := &Bundle{ID: "other", Threshold: 2}
+	otherParts, _ := shamir.Split(hsm_api.GenIV(kekLength), 3, 2)
+	if err := write(conf, other, otherParts, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := combine(b, []string{share(1), filepath.Join(dir, "custodian-2", "other.share-2.json")}); err == nil {
+		t.Error("expected error for share of another backup")
+	}
+}
+
+func TestPrintShares(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &configs.Config{Backup: configs.Backup{Dir: dir}}
+	parts, err := shamir.Split(hsm_api.GenIV(kekLength), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := write(conf, &Bundle{ID: "test", Threshold: 2, Shares: 3}, parts, out); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(out.String(), `"share":`); n != 3 {
+		t.Errorf("%d shares printed, expected 3", n)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("expected the bundle alone in the backup directory, got %d files", len(files))
+	}
+}
+
+func TestCheckDestinations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bundles := filepath.Join(dir, "bundles")
+	for _, d := range []string{"a", "b", filepath.Join("bundles", "c")} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	custodian := func(name, path string) configs.ShareCustodian {
+		return configs.ShareCustodian{Name: name, Path: filepath.Join(dir, path)}
+	}
+
+	tests := []struct {
+		name       string
+		custodians []configs.ShareCustodian
+		out        bool
+		ok         bool
+	}{
+		{"custodian directories", []configs.ShareCustodian{custodian("a", "a"), custodian("b", "b")}, false, true},
+		{"printed", nil, true, true},
+		{"nowhere to go", nil, false, false},
+		{"fewer custodians than shares", []configs.ShareCustodian{custodian("a", "a")}, false, false},
+		{"inside the backup directory", []configs.ShareCustodian{custodian("a", "a"), custodian("c", filepath.Join("bundles", "c"))}, false, false},
+		{"the backup directory", []configs.ShareCustodian{custodian("a", "a"), custodian("b", "bundles")}, false, false},
+		{"same directory", []configs.ShareCustodian{custodian("a", "a"), custodian("b", "a")}, false, false},
+		{"not mounted", []configs.ShareCustodian{custodian("a", "a"), custodian("b", "missing")}, false, false},
+	}
+	for _, tt := range tests {
+		conf := &configs.Config{Backup: configs.Backup{Dir: bundles, Custodians: tt.custodians}}
+		var out io.Writer
+		if tt.out {
+			out = &bytes.Buffer{}
+		}
+		err := CheckDestinations(conf, 2, out)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
+
+func TestSealRecords(t *testing.T) {
+	kek := hsm_api.GenIV(kekLength)
+	records := sealedRecords{
+		Records: []Record{
+			{Application: lifecycle.Application, Label: "data-key", Value: `{"label":"data-key","id":"01","state":"COMPROMISED"}`},
+			{Application: hsm_api.RingApplication, Label: "data-key", Value: `{"label":"data-key","next":2}`},
+		},
+		Metadata: []*metadata.Entry{{ID: "01", Label: "data-key", Owner: "cards"}},
+	}
+
+	b := &Bundle{ID: "test"}
+	var err error
+	if b.Records, err = seal(kek, b.ID, records); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.Records, "COMPROMISED") {
+		t.Error("records are not sealed")
+	}
+
+	got, err := unseal(kek, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Records) != 2 || got.Records[0] != records.Records[0] || len(got.Metadata) != 1 || got.Metadata[0].Owner != "cards" {
+		t.Errorf("got %+v", got)
+	}
+	if id, err := got.Records[0].keyID(); err != nil || id != "01" {
+		t.Errorf("got key id %q, %v", id, err)
+	}
+
+	if _, err := unseal(hsm_api.GenIV(kekLength), b); err == nil {
+		t.Error("expected error for another kek")
+	}
+	if _, err := unseal(kek, &Bundle{ID: "other", Records: b.Records}); err == nil {
+		t.Error("expected error for the records of another bundle")
+	}
+}
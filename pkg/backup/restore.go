@@ -0,0 +1,322 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/shamir"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// Restore rebuilds the KEK from the custodian shares and unwraps the keys of the bundle
+// into the token. Keys already present with the same label and id are skipped, every
+// restored key is verified against its check value and removed when it does not match.
+// A restored key gets its service records back, so a compromised or deactivated key stays
+// so, and a key ring record missing from the token is restored with its version numbers.
+// The metadata of the restored keys is left in Metadata of the bundle.
+func Restore(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, bundlePath string, sharePaths []string) (*Bundle, []Result, error) {
+	b := &Bundle{}
+	if err := readJSON(bundlePath, b); err != nil {
+		return nil, nil, err
+	}
+
+	kek, err := combine(b, sharePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zero(kek)
+
+	tk, err := hsm_api.ImportTransportKey(ctx, ss, kek, pkcs11.CKA_WRAP, pkcs11.CKA_UNWRAP)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hsm_api.RemoveKey(ctx, ss, tk)
+
+	sealed, err := unseal(kek, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	perKey := map[string][]Record{}
+	var rings []Record
+	for _, r := range sealed.Records {
+		if r.Application == hsm_api.RingApplication {
+			rings = append(rings, r)
+			continue
+		}
+		id, err := r.keyID()
+		if err != nil {
+			return nil, nil, err
+		}
+		perKey[recordKey(r.Label, id)] = append(perKey[recordKey(r.Label, id)], r)
+	}
+
+	var results []Result
+	failed := 0
+	restored := map[string]bool{}
+	for _, k := range b.Keys {
+		status, err := restore(ctx, ss, tk, k, perKey[recordKey(k.Label, k.ID)])
+		if err != nil {
+			status = err.Error()
+			failed++
+		}
+		if status == "restored" {
+			restored[k.ID] = true
+		}
+		results = append(results, Result{Label: k.Label, Class: hsm_api.ClassNames[k.Class], Status: status})
+	}
+
+	for _, r := range rings {
+		status, err := restoreRing(ctx, ss, r)
+		if err != nil {
+			status = err.Error()
+			failed++
+		}
+		results = append(results, Result{Label: r.Label, Class: "key ring", Status: status})
+	}
+	for _, e := range sealed.Metadata {
+		if restored[e.ID] {
+			b.Metadata = append(b.Metadata, e)
+		}
+	}
+
+	if failed > 0 {
+		return b, results, fmt.Errorf("%d of %d keys not restored", failed, len(b.Keys))
+	}
+	return b, results, nil
+}
+
+// combine reads the shares of the bundle and checks the KEK they give.
+func combine(b *Bundle, sharePaths []string) ([]byte, error) {
+	if len(sharePaths) < b.Threshold {
+		return nil, fmt.Errorf("%d shares given, %d needed", len(sharePaths), b.Threshold)
+	}
+
+	parts := make([][]byte, 0, len(sharePaths))
+	defer func() {
+		for _, part := range parts {
+			zero(part)
+		}
+	}()
+	for _, path := range sharePaths {
+		s := Share{}
+		if err := readJSON(path, &s); err != nil {
+			return nil, err
+		}
+		if s.Bundle != b.ID {
+			return nil, fmt.Errorf("share %s belongs to backup %s", path, s.Bundle)
+		}
+		part, err := hex.DecodeString(s.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share %s: %v", path, err)
+		}
+		parts = append(parts, part)
+	}
+
+	kek, err := shamir.Combine(parts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine shares: %v", err)
+	}
+
+	check, err := hsm_api.SoftKeyCheckValue(kek, hsm_api.KeyTypeAES256, hsm_api.KCVMethodCMAC)
+	if err != nil || hex.EncodeToString(check) != b.KEKCheck {
+		zero(kek)
+		return nil, fmt.Errorf("shares do not rebuild the backup kek")
+	}
+	return kek, nil
+}
+
+func restore(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, tk pkcs11.ObjectHandle, k Key, records []Record) (string, error) {
+	id, err := hex.DecodeString(k.ID)
+	if err != nil {
+		return "", fmt.Errorf("invalid id: %v", err)
+	}
+	wrapped, err := hex.DecodeString(k.Wrapped)
+	if err != nil {
+		return "", fmt.Errorf("invalid wrapped key: %v", err)
+	}
+
+	found, err := hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, k.Class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, k.Label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(found) > 0 {
+		return "exists", nil
+	}
+
+	obj, err := hsm_api.UnwrapKey(ctx, ss, tk, wrapped, template(k.Class, k.KeyType, k.Label, id, k.Flags))
+	if err != nil {
+		return "", err
+	}
+
+	if err := verify(ctx, ss, tk, obj, k); err != nil {
+		hsm_api.RemoveKey(ctx, ss, obj)
+		return "", err
+	}
+
+	// a key is not back without its records, a compromised key would come back active
+	for _, r := range records {
+		if err := restoreRecord(ctx, ss, r, k.ID); err != nil {
+			hsm_api.RemoveKey(ctx, ss, obj)
+			return "", err
+		}
+	}
+
+	if k.Public != nil {
+		if err := restorePublic(ctx, ss, k, id); err != nil {
+			return "", err
+		}
+	}
+	return "restored", nil
+}
+
+// restoreRecord replaces the record of the application left in the token for the key, such
+// as the one of a key that was destroyed since the backup.
+func restoreRecord(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, r Record, id string) error {
+	objs, err := recordObjects(ctx, ss, r)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(ctx, ss, obj, pkcs11.CKA_VALUE)
+		if err != nil {
+			return err
+		}
+		old := Record{Application: r.Application, Label: r.Label, Value: string(attrs[pkcs11.CKA_VALUE])}
+		if oldID, err := old.keyID(); err == nil && oldID != id {
+			continue
+		}
+		if err := ctx.DestroyObject(ss, obj); err != nil {
+			return fmt.Errorf("failed to remove %s record of %s: %v", r.Application, r.Label, err)
+		}
+	}
+	return createRecord(ctx, ss, r)
+}
+
+// restoreRing restores a key ring record the token does not have, the one of the token
+// knows the versions created since the backup and is kept.
+func restoreRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, r Record) (string, error) {
+	objs, err := recordObjects(ctx, ss, r)
+	if err != nil {
+		return "", err
+	}
+	if len(objs) > 0 {
+		return "exists", nil
+	}
+	if err := createRecord(ctx, ss, r); err != nil {
+		return "", err
+	}
+	return "restored", nil
+}
+
+func recordObjects(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, r Record) ([]pkcs11.ObjectHandle, error) {
+	return hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, r.Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, r.Label),
+	})
+}
+
+func createRecord(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, r Record) error {
+	_, err := ctx.CreateObject(ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, r.Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, r.Label),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, []byte(r.Value)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s record of %s: %v", r.Application, r.Label, err)
+	}
+	return nil
+}
+
+func verify(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, tk, obj pkcs11.ObjectHandle, k Key) error {
+	expected, err := hex.DecodeString(k.Check)
+	if err != nil {
+		return fmt.Errorf("invalid check value: %v", err)
+	}
+
+	switch k.Method {
+	case CheckKCV:
+		ok, err := hsm_api.VerifyKeyCheckValue(ctx, ss, obj, "", expected)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("kcv mismatch")
+		}
+	case CheckRewrap:
+		wrapped, err := hsm_api.WrapKey(ctx, ss, tk, obj)
+		if err != nil {
+			return err
+		}
+		if sum := sha256.Sum256(wrapped); !bytes.Equal(sum[:], expected) {
+			return fmt.Errorf("check value mismatch")
+		}
+	default:
+		return fmt.Errorf("unsupported check method: %s", k.Method)
+	}
+	return nil
+}
+
+func restorePublic(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, k Key, id []byte) error {
+	t := template(pkcs11.CKO_PUBLIC_KEY, k.KeyType, k.Label, id, k.Public.Flags)
+	if k.KeyType == pkcs11.CKK_EC {
+		params, err := hex.DecodeString(k.Public.ECParams)
+		if err != nil {
+			return fmt.Errorf("invalid public key ec params: %v", err)
+		}
+		point, err := hex.DecodeString(k.Public.ECPoint)
+		if err != nil {
+			return fmt.Errorf("invalid public key ec point: %v", err)
+		}
+		t = append(t,
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, point),
+		)
+	} else {
+		modulus, err := hex.DecodeString(k.Public.Modulus)
+		if err != nil {
+			return fmt.Errorf("invalid public key modulus: %v", err)
+		}
+		exponent, err := hex.DecodeString(k.Public.Exponent)
+		if err != nil {
+			return fmt.Errorf("invalid public key exponent: %v", err)
+		}
+		t = append(t,
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, modulus),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, exponent),
+		)
+	}
+	if _, err := ctx.CreateObject(ss, t); err != nil {
+		return fmt.Errorf("failed to create public key: %v", err)
+	}
+	return nil
+}
+
+// template gives the token object attributes recorded at backup time.
+func template(class, keyType uint, label string, id []byte, flags map[string]bool) []*pkcs11.Attribute {
+	t := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+	}
+	for name, v := range flags {
+		if a, ok := flagAttributes[name]; ok {
+			t = append(t, pkcs11.NewAttribute(a, v))
+		}
+	}
+	return t
+}
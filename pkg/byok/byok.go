@@ -0,0 +1,277 @@
+// Package byok imports key material supplied by a tenant, wrapped under an HSM generated
+// RSA key handed out with a single use import token.
+package byok
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hsm/configs"
+	"hsm/pkg/audit"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	defaultTokenTTL = 24 * time.Hour
+
+	// CKA_APPLICATION of the provenance data objects
+	Application = "hsm-provenance"
+
+	// WrappingKeyPrefix starts the labels of the wrapping key pairs of the import tokens.
+	WrappingKeyPrefix = "byok-"
+
+	// OriginExternal marks key material generated outside the HSM.
+	OriginExternal = "EXTERNAL"
+)
+
+// ErrInvalidToken is returned for unknown, expired, used or foreign import tokens.
+var ErrInvalidToken = errors.New("invalid or expired import token")
+
+// Parameters are handed to the tenant to wrap its key material.
+type Parameters struct {
+	Token       string
+	Algorithm   string
+	PublicKey   *rsa.PublicKey
+	Fingerprint string
+	ExpiresAt   time.Time
+}
+
+// Provenance records where an imported key comes from, stored next to the key.
+type Provenance struct {
+	Label       string    `json:"label"`
+	ID          string    `json:"id"`
+	Tenant      string    `json:"tenant"`
+	Origin      string    `json:"origin"`
+	Algorithm   string    `json:"algorithm"`
+	WrappingKey string    `json:"wrappingKey"`
+	ImportedAt  time.Time `json:"importedAt"`
+}
+
+type pending struct {
+	tenant      string
+	algorithm   string
+	priv, pub   pkcs11.ObjectHandle
+	fingerprint string
+	expires     time.Time
+}
+
+// Manager keeps the wrapping keys of the outstanding import tokens as session objects,
+// they are destroyed once used or expired and do not survive a restart.
+type Manager struct {
+	ctx   *pkcs11.Ctx
+	ss    pkcs11.SessionHandle
+	ttl   time.Duration
+	audit *audit.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pending
+}
+
+func NewManager(conf *configs.Config, ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, audit *audit.Logger) *Manager {
+	ttl := conf.BYOK.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	return &Manager{
+		ctx:     ctx,
+		ss:      ss,
+		ttl:     ttl,
+		audit:   audit,
+		pending: map[string]*pending{},
+	}
+}
+
+// Parameters generates a wrapping key pair and an import token bound to the tenant.
+func (m *Manager) Parameters(tenant, algorithm string) (*Parameters, error) {
+	switch algorithm {
+	case "":
+		algorithm = hsm_api.WrapRSAAESKeyWrapSHA256
+	case hsm_api.WrapRSAOAEPSHA256, hsm_api.WrapRSAAESKeyWrapSHA256:
+	default:
+		return nil, fmt.Errorf("unsupported wrapping algorithm: %s", algorithm)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate import token: %v", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	m.expire(now)
+
+	priv, pub, err := hsm_api.GenerateWrappingKeyPair(m.ctx, m.ss, WrappingKeyPrefix+token[:8])
+	if err != nil {
+		return nil, err
+	}
+	key, err := hsm_api.RSAPublicKey(m.ctx, m.ss, pub)
+	if err != nil {
+		m.destroy(&pending{priv: priv, pub: pub})
+		return nil, err
+	}
+	fingerprint, err := hsm_api.PublicKeyID(key)
+	if err != nil {
+		m.destroy(&pending{priv: priv, pub: pub})
+		return nil, err
+	}
+
+	p := &pending{
+		tenant:      tenant,
+		algorithm:   algorithm,
+		priv:        priv,
+		pub:         pub,
+		fingerprint: fingerprint,
+		expires:     now.Add(m.ttl),
+	}
+	m.pending[token] = p
+
+	m.audit.Log(audit.Event{
+		Actor:  tenant,
+		Action: "import-parameters",
+		Detail: map[string]string{"algorithm": algorithm, "wrappingKey": fingerprint, "expiresAt": p.expires.Format(time.RFC3339)},
+	})
+
+	return &Parameters{
+		Token:       token,
+		Algorithm:   algorithm,
+		PublicKey:   key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   p.expires,
+	}, nil
+}
+
+// Import unwraps the key material with the wrapping key of the token into a token key of
+// the template and records its provenance. The token is spent even when the import fails.
+func (m *Manager) Import(token, tenant, label string, id []byte, t *hsm_api.KeyTemplate, wrapped []byte) (pkcs11.ObjectHandle, error) {
+	if label == "" {
+		return 0, fmt.Errorf("missing key label")
+	}
+
+	m.mu.Lock()
+	p, err := m.take(token, tenant, time.Now().UTC())
+	m.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	defer m.destroy(p)
+
+	key, err := hsm_api.UnwrapImportedKey(m.ctx, m.ss, p.priv, p.algorithm, wrapped, label, id, t)
+	if err != nil {
+		return 0, err
+	}
+
+	prov := &Provenance{
+		Label:       label,
+		ID:          hex.EncodeToString(id),
+		Tenant:      tenant,
+		Origin:      OriginExternal,
+		Algorithm:   p.algorithm,
+		WrappingKey: p.fingerprint,
+		ImportedAt:  time.Now().UTC(),
+	}
+	if err := m.save(prov); err != nil {
+		hsm_api.RemoveKey(m.ctx, m.ss, key)
+		return 0, err
+	}
+
+	m.audit.Log(audit.Event{
+		Actor:  tenant,
+		Action: "import-key-material",
+		Key:    label,
+		Detail: map[string]string{"id": prov.ID, "template": t.Name, "algorithm": p.algorithm, "wrappingKey": p.fingerprint},
+	})
+
+	return key, nil
+}
+
+// Provenance returns the import record of the key, nil for keys generated in the HSM.
+func (m *Manager) Provenance(label string, id []byte) (*Provenance, error) {
+	objs, err := hsm_api.FindObjects(m.ctx, m.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	want := hex.EncodeToString(id)
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(m.ctx, m.ss, obj, pkcs11.CKA_VALUE)
+		if err != nil {
+			return nil, err
+		}
+		p := &Provenance{}
+		if err := json.Unmarshal(attrs[pkcs11.CKA_VALUE], p); err != nil {
+			return nil, fmt.Errorf("invalid provenance record of %s: %v", label, err)
+		}
+		if p.ID == want {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// take removes the token from the outstanding ones, it must belong to the tenant and
+// not be expired. Called with the lock held.
+func (m *Manager) take(token, tenant string, now time.Time) (*pending, error) {
+	m.expire(now)
+
+	p, ok := m.pending[token]
+	if !ok || p.tenant != tenant {
+		return nil, ErrInvalidToken
+	}
+	delete(m.pending, token)
+	return p, nil
+}
+
+// expire destroys the wrapping keys of the expired tokens. Called with the lock held.
+func (m *Manager) expire(now time.Time) {
+	for token, p := range m.pending {
+		if !now.Before(p.expires) {
+			delete(m.pending, token)
+			m.destroy(p)
+		}
+	}
+}
+
+func (m *Manager) destroy(p *pending) {
+	if m.ctx == nil {
+		return
+	}
+	for _, obj := range []pkcs11.ObjectHandle{p.priv, p.pub} {
+		if obj != 0 {
+			hsm_api.RemoveKey(m.ctx, m.ss, obj)
+		}
+	}
+}
+
+func (m *Manager) save(p *Provenance) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal provenance record: %v", err)
+	}
+
+	_, err = m.ctx.CreateObject(m.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, p.Label),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save provenance record: %v", err)
+	}
+	return nil
+}
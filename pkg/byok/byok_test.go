@@ -0,0 +1,31 @@
+package byok
+
+import (
+	"testing"
+	"time"
+
+	"hsm/configs"
+)
+
+func TestTake(t *testing.T) {
+	m := NewManager(&configs.Config{}, nil, 0, nil)
+	now := time.Now().UTC()
+	m.pending["used"] = &pending{tenant: "tenant-a", expires: now.Add(time.Hour)}
+	m.pending["expired"] = &pending{tenant: "tenant-a", expires: now}
+
+	if _, err := m.take("used", "tenant-b", now); err != ErrInvalidToken {
+		t.Errorf("foreign token: got %v", err)
+	}
+	if _, err := m.take("used", "tenant-a", now); err != nil {
+		t.Error(err)
+	}
+	if _, err := m.take("used", "tenant-a", now); err != ErrInvalidToken {
+		t.Errorf("spent token: got %v", err)
+	}
+	if _, err := m.take("expired", "tenant-a", now); err != ErrInvalidToken {
+		t.Errorf("expired token: got %v", err)
+	}
+	if len(m.pending) != 0 {
+		t.Errorf("pending tokens left: %d", len(m.pending))
+	}
+}
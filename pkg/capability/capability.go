@@ -0,0 +1,173 @@
+// Package capability lists the mechanisms the configuration needs from the token: the
+// generation and allowed mechanisms of the key templates, the MAC and PIN operations of
+// their DES keys, the algorithms of the key aliases and of the n2k key, the metadata
+// store, the ceremony transcript signature, key import and backup. The server checks them
+// against the mechanisms of its token and refuses to start when one is missing.
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/keyalias"
+	"hsm/pkg/policy"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// Need is one of Mechanisms with the Flags capabilities and keys of Bits, 0 for any size.
+type Need struct {
+	Mechanisms []uint
+	Flags      uint
+	Bits       int
+	For        string
+}
+
+// Needs lists the mechanisms needed by the configuration and its parsed templates and
+// aliases.
+func Needs(conf *configs.Config, templates map[string]*hsm_api.KeyTemplate, aliases map[string]*keyalias.Alias) []Need {
+	var needs []Need
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := templates[name]
+		what := "key template " + name
+		if mech, flags, ok := t.GenerateMechanism(); ok {
+			needs = append(needs, Need{[]uint{mech}, flags, t.Bits, what})
+		}
+		for _, mech := range t.AllowedMechanisms {
+			needs = append(needs, Need{[]uint{mech}, 0, t.Bits, what})
+		}
+		needs = append(needs, desNeeds(t, what)...)
+	}
+
+	types := make([]string, 0, len(aliases))
+	for typ := range aliases {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		a := aliases[typ]
+		var flags uint
+		if a.Encrypt {
+			flags |= pkcs11.CKF_ENCRYPT
+		}
+		if a.Decrypt {
+			flags |= pkcs11.CKF_DECRYPT
+		}
+		what := "key alias " + typ
+		switch a.Algorithm {
+		case "GCM":
+			needs = append(needs, Need{[]uint{pkcs11.CKM_AES_GCM}, flags, 0, what})
+		case "CBC":
+			// the mechanism follows the type of the key
+			needs = append(needs, Need{[]uint{pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_DES3_CBC_PAD}, flags, 0, what})
+		}
+	}
+
+	if conf.HSM.N2kLabel != "" {
+		// the default Encrypt and Decrypt path, the mechanism follows the type of the key
+		needs = append(needs, Need{[]uint{pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_DES3_CBC_PAD}, pkcs11.CKF_ENCRYPT | pkcs11.CKF_DECRYPT, 0, "n2k key"})
+	}
+	if conf.Metadata.KeyLabel != "" {
+		needs = append(needs,
+			Need{[]uint{pkcs11.CKM_AES_KEY_GEN}, pkcs11.CKF_GENERATE, 256, "metadata store"},
+			Need{[]uint{pkcs11.CKM_AES_GCM}, pkcs11.CKF_ENCRYPT | pkcs11.CKF_DECRYPT, 256, "metadata store"},
+		)
+	}
+	if conf.Ceremony.SigningKey != "" {
+		needs = append(needs,
+			Need{[]uint{pkcs11.CKM_SHA256_RSA_PKCS}, pkcs11.CKF_SIGN | pkcs11.CKF_VERIFY, 0, "ceremony transcripts"},
+			Need{[]uint{pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN}, pkcs11.CKF_GENERATE_KEY_PAIR, hsm_api.WrappingKeyBits, "ceremony transport"},
+			Need{[]uint{pkcs11.CKM_RSA_PKCS_OAEP}, pkcs11.CKF_DECRYPT, hsm_api.WrappingKeyBits, "ceremony transport"},
+		)
+	}
+	if conf.BYOK.TokenTTL > 0 {
+		needs = append(needs,
+			Need{[]uint{pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN}, pkcs11.CKF_GENERATE_KEY_PAIR, hsm_api.WrappingKeyBits, "key import"},
+			Need{[]uint{pkcs11.CKM_RSA_PKCS_OAEP}, pkcs11.CKF_UNWRAP, hsm_api.WrappingKeyBits, "key import"},
+			Need{[]uint{hsm_api.WrapMechanism}, pkcs11.CKF_UNWRAP, 0, "key import"},
+		)
+	}
+	if conf.Backup.Dir != "" {
+		needs = append(needs, Need{[]uint{hsm_api.WrapMechanism}, pkcs11.CKF_WRAP | pkcs11.CKF_UNWRAP, 256, "backup"})
+	}
+	return needs
+}
+
+// desNeeds lists the mechanisms of the MAC and PIN operations with the DES keys of the
+// template: DES3 ECB for the PIN keys, which serve nothing else, and for the retail MAC,
+// DES3 CBC for the CBC-MAC, and for the retail MAC keys single DES CBC with their K1 key,
+// which is unwrapped into the token with the DES2 key.
+func desNeeds(t *hsm_api.KeyTemplate, what string) []Need {
+	if t.Class != pkcs11.CKO_SECRET_KEY || (t.KeyType != pkcs11.CKK_DES2 && t.KeyType != pkcs11.CKK_DES3) {
+		return nil
+	}
+	switch t.Purpose {
+	case policy.PurposePINEncryption:
+		return []Need{{[]uint{pkcs11.CKM_DES3_ECB}, pkcs11.CKF_DECRYPT, 0, what + " (pin)"}}
+	case policy.PurposePINVerification:
+		return []Need{{[]uint{pkcs11.CKM_DES3_ECB}, pkcs11.CKF_ENCRYPT, 0, what + " (pin)"}}
+	}
+	needs := []Need{
+		{[]uint{pkcs11.CKM_DES3_ECB}, pkcs11.CKF_ENCRYPT, 0, what + " (mac)"},
+		{[]uint{pkcs11.CKM_DES3_CBC}, pkcs11.CKF_ENCRYPT, 0, what + " (mac)"},
+	}
+	if t.RetailMac {
+		needs = append(needs,
+			Need{[]uint{pkcs11.CKM_DES_CBC}, pkcs11.CKF_ENCRYPT, 0, what + " (mac algorithm 3)"},
+			Need{[]uint{hsm_api.WrapMechanism}, pkcs11.CKF_UNWRAP, 256, what + " (mac algorithm 3)"},
+		)
+	}
+	return needs
+}
+
+// Check returns an error listing every need the mechanisms of the token do not meet.
+func Check(mechs []*hsm_api.MechanismInfo, needs []Need) error {
+	byType := map[uint]*hsm_api.MechanismInfo{}
+	for _, m := range mechs {
+		byType[m.Type] = m
+	}
+
+	var missing []string
+	for _, n := range needs {
+		if !meets(byType, n) {
+			missing = append(missing, n.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the token does not support: %s", strings.Join(missing, "; "))
+	}
+	return nil
+}
+
+func meets(mechs map[uint]*hsm_api.MechanismInfo, n Need) bool {
+	for _, mech := range n.Mechanisms {
+		if m, ok := mechs[mech]; ok && m.Supports(n.Flags, n.Bits) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n Need) String() string {
+	names := make([]string, len(n.Mechanisms))
+	for i, mech := range n.Mechanisms {
+		names[i] = hsm_api.MechanismName(mech)
+	}
+	s := strings.Join(names, " or ")
+	if flags := (&hsm_api.MechanismInfo{Flags: n.Flags}).FlagNames(); len(flags) > 0 {
+		s += " " + strings.Join(flags, ",")
+	}
+	if n.Bits > 0 {
+		s += fmt.Sprintf(" %d bits", n.Bits)
+	}
+	return s + " for " + n.For
+}
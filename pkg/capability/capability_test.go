@@ -0,0 +1,109 @@
+package capability
+
+import (
+	"strings"
+	"testing"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/keyalias"
+	"hsm/pkg/keytemplate"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestNeedsDevConfig(t *testing.T) {
+	conf := configs.LoadConfig("../../configs", "dev")
+	if conf == nil {
+		t.Fatal("failed to load dev config")
+	}
+	templates, err := keytemplate.Load(conf.KeyTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := keyalias.Load(conf.KeyAliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	needed := map[string]bool{}
+	for _, n := range Needs(conf, templates, aliases) {
+		needed[n.String()] = true
+	}
+	for _, want := range []string{
+		"CKM_RSA_PKCS_KEY_PAIR_GEN GENERATE_KEY_PAIR 3072 bits for key template rsa-signing",
+		"CKM_AES_GCM ENCRYPT,DECRYPT for key alias CARD_DATA",
+		"CKM_AES_CBC_PAD or CKM_DES3_CBC_PAD DECRYPT for key alias LEGACY_N2K",
+		"CKM_AES_KEY_GEN GENERATE 256 bits for metadata store",
+		"CKM_AES_CBC_PAD or CKM_DES3_CBC_PAD ENCRYPT,DECRYPT for n2k key",
+		"CKM_DES3_ECB ENCRYPT for key template pin-verification (pin)",
+		"CKM_DES3_ECB DECRYPT for key template pin-encryption (pin)",
+		"CKM_DES3_ECB ENCRYPT for key template mac (mac)",
+		"CKM_DES3_CBC ENCRYPT for key template mac (mac)",
+		"CKM_RSA_PKCS_KEY_PAIR_GEN GENERATE_KEY_PAIR 3072 bits for key import",
+		"CKM_RSA_PKCS_OAEP UNWRAP 3072 bits for key import",
+		"CKM_AES_KEY_WRAP_PAD WRAP,UNWRAP 256 bits for backup",
+	} {
+		if !needed[want] {
+			t.Errorf("missing need %q", want)
+		}
+	}
+	if needed["CKM_DES3_CBC ENCRYPT for key template pin-verification (mac)"] {
+		t.Error("pin-verification keys do not serve the mac operations")
+	}
+	if !needed["CKM_DES_CBC ENCRYPT for key template mac (mac algorithm 3)"] {
+		t.Error("mac needs CKM_DES_CBC for its K1 key")
+	}
+}
+
+func TestDESNeeds(t *testing.T) {
+	tmpl := &hsm_api.KeyTemplate{
+		Name:      "mac",
+		Class:     pkcs11.CKO_SECRET_KEY,
+		KeyType:   pkcs11.CKK_DES2,
+		Usage:     []uint{pkcs11.CKA_ENCRYPT},
+		RetailMac: true,
+	}
+	var des bool
+	for _, n := range desNeeds(tmpl, "key template mac") {
+		if n.Mechanisms[0] == pkcs11.CKM_EXTRACT_KEY_FROM_KEY {
+			t.Error("the retail mac does not derive")
+		}
+		des = des || n.Mechanisms[0] == pkcs11.CKM_DES_CBC
+	}
+	if !des {
+		t.Error("a retail mac key needs CKM_DES_CBC for its K1 key")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	mechs := []*hsm_api.MechanismInfo{
+		{Type: pkcs11.CKM_AES_KEY_GEN, MinKeySize: 16, MaxKeySize: 32, Flags: pkcs11.CKF_GENERATE},
+		{Type: pkcs11.CKM_AES_GCM, MinKeySize: 16, MaxKeySize: 32, Flags: pkcs11.CKF_ENCRYPT | pkcs11.CKF_DECRYPT},
+		{Type: pkcs11.CKM_DES3_CBC_PAD, Flags: pkcs11.CKF_ENCRYPT | pkcs11.CKF_DECRYPT},
+		{Type: pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, MinKeySize: 512, MaxKeySize: 2048, Flags: pkcs11.CKF_GENERATE_KEY_PAIR},
+	}
+
+	ok := []Need{
+		{[]uint{pkcs11.CKM_AES_KEY_GEN}, pkcs11.CKF_GENERATE, 256, "aes"},
+		{[]uint{pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_DES3_CBC_PAD}, pkcs11.CKF_DECRYPT, 0, "cbc"},
+	}
+	if err := Check(mechs, ok); err != nil {
+		t.Error(err)
+	}
+
+	err := Check(mechs, []Need{
+		{[]uint{pkcs11.CKM_AES_KEY_GEN}, pkcs11.CKF_GENERATE, 512, "aes"},
+		{[]uint{pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN}, pkcs11.CKF_GENERATE_KEY_PAIR, 3072, "rsa"},
+		{[]uint{pkcs11.CKM_AES_GCM}, pkcs11.CKF_SIGN, 0, "gcm"},
+		{[]uint{pkcs11.CKM_ECDSA}, 0, 0, "ec"},
+	})
+	if err == nil {
+		t.Fatal("expected error for unsupported mechanisms")
+	}
+	for _, what := range []string{"for aes", "for rsa", "for gcm", "for ec"} {
+		if !strings.Contains(err.Error(), what) {
+			t.Errorf("error %q does not name %s", err, what)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package ceremony
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/policy"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ErrClearCombine is returned when the token cannot combine the components and the manager
+// was not allowed to combine them in memory, see AllowClearCombine.
+var ErrClearCombine = errors.New("the token lacks CKM_XOR_BASE_AND_DATA, only the ceremony command with -clear-combine combines the components in memory")
+
+// TransportKeyPrefix starts the labels of the transport key pairs of the ceremonies.
+const TransportKeyPrefix = "ceremony-transport-"
+
+const (
+	defaultComponents = 2
+	maxComponents     = 3
+	ceremonyTimeout   = time.Hour
+)
+
+type (
+	// Manager runs master key loading ceremonies: every component is entered by a different
+	// authenticated custodian and combined inside the token, the result is stored under the
+	// key label once its check value is verified.
+	Manager struct {
+		conf     *configs.Config
+		ctx      *pkcs11.Ctx
+		ss       pkcs11.SessionHandle
+		mu       sync.Mutex
+		sessions map[string]*session
+
+		clearCombine bool
+	}
+
+	session struct {
+		transcript Transcript
+		components int
+		expected   []byte
+		xor        bool
+		base       pkcs11.ObjectHandle // XOR of the components entered so far
+		acc        []byte              // same, when the token cannot XOR derive
+		count      int
+
+		// RSA key pair the components are encrypted to when they come over the network
+		transport, transportPub pkcs11.ObjectHandle
+	}
+
+	Transcript struct {
+		ID          string      `json:"id"`
+		KeyLabel    string      `json:"key_label"`
+		KeyID       string      `json:"key_id,omitempty"`
+		KeyType     string      `json:"key_type"`
+		Purpose     string      `json:"purpose,omitempty"`
+		KCV         string      `json:"kcv,omitempty"`
+		Combination string      `json:"combination"`
+		StartedBy   string      `json:"started_by"`
+		StartedAt   time.Time   `json:"started_at"`
+		CompletedBy string      `json:"completed_by,omitempty"`
+		CompletedAt time.Time   `json:"completed_at,omitempty"`
+		Components  []Component `json:"components"`
+		Signature   string      `json:"signature,omitempty"`
+	}
+
+	Component struct {
+		Custodian   string    `json:"custodian"`
+		KCV         string    `json:"kcv"`
+		SubmittedAt time.Time `json:"submitted_at"`
+	}
+)
+
+func NewManager(conf *configs.Config, ctx *pkcs11.Ctx, ss pkcs11.SessionHandle) *Manager {
+	return &Manager{
+		conf:     conf,
+		ctx:      ctx,
+		ss:       ss,
+		sessions: map[string]*session{},
+	}
+}
+
+// AllowClearCombine lets the ceremonies of a token without CKM_XOR_BASE_AND_DATA combine
+// the components in the memory of the process: the clear key exists there until it is
+// unwrapped into the token. It is meant for development tokens such as SoftHSM2.
+func (m *Manager) AllowClearCombine() {
+	m.clearCombine = true
+}
+
+// Start opens a ceremony for the key, an empty label means the configured n2k label and
+// an empty type AES256. purpose makes the key a PIN key, see policy.PurposePINEncryption.
+// expectedKCV is the check value the combined key must have.
+func (m *Manager) Start(custodian, secret, keyLabel, keyType, purpose string, expectedKCV []byte) (*Transcript, error) {
+	if err := m.authenticate(custodian, secret); err != nil {
+		return nil, err
+	}
+
+	if keyLabel == "" {
+		keyLabel = m.conf.HSM.N2kLabel
+	}
+	if keyType == "" {
+		keyType = hsm_api.KeyTypeAES256
+	}
+	if hsm_api.SecretKeyLength(keyType) == 0 || keyType == hsm_api.KeyTypeGenericSecret {
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	if !policy.ValidPurpose(purpose) {
+		return nil, fmt.Errorf("unknown key purpose: %s", purpose)
+	}
+	if rotated(m.conf, keyLabel) {
+		return nil, fmt.Errorf("key %s has a rotation policy, a rotation would replace the ceremony key", keyLabel)
+	}
+	if purpose != "" && keyType != hsm_api.KeyTypeDES2 && keyType != hsm_api.KeyTypeDES3 {
+		return nil, fmt.Errorf("purpose %s needs a DES2 or DES3 key", purpose)
+	}
+
+	components := m.conf.Ceremony.Components
+	if components == 0 {
+		components = defaultComponents
+	}
+	if components < 2 || components > maxComponents {
+		return nil, fmt.Errorf("invalid number of components: %d", components)
+	}
+	if len(m.conf.Ceremony.Custodians) < components {
+		return nil, fmt.Errorf("not enough custodians for %d components", components)
+	}
+
+	// never replace an existing key and make sure the transcript can be signed
+	if err := hsm_api.CheckNewKey(m.ctx, m.ss, keyLabel, nil); err != nil {
+		return nil, err
+	}
+	if _, err := hsm_api.FindKeys(m.ctx, m.ss, pkcs11.CKO_PRIVATE_KEY, m.conf.Ceremony.SigningKey); err != nil {
+		return nil, fmt.Errorf("failed to find ceremony signing key: %v", err)
+	}
+
+	xor, err := hsm_api.SupportsMechanism(m.ctx, m.ss, pkcs11.CKM_XOR_BASE_AND_DATA)
+	if err != nil {
+		return nil, err
+	}
+	if !xor && !m.clearCombine {
+		return nil, ErrClearCombine
+	}
+
+	s := &session{
+		transcript: Transcript{
+			ID:          hex.EncodeToString(hsm_api.GenIV(16)),
+			KeyLabel:    keyLabel,
+			KeyType:     keyType,
+			Purpose:     purpose,
+			Combination: "unwrap",
+			StartedBy:   custodian,
+			StartedAt:   time.Now().UTC(),
+		},
+		components: components,
+		expected:   expectedKCV,
+		xor:        xor,
+	}
+	if xor {
+		s.transcript.Combination = "xor-derive"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+	m.sessions[s.transcript.ID] = s
+
+	t := s.transcript
+	return &t, nil
+}
+
+// Submit enters one clear component, its check value is verified before it is combined.
+// It returns the number of components still missing.
+func (m *Manager) Submit(id, custodian, secret string, component, componentKCV []byte) (int, error) {
+	if err := m.authenticate(custodian, secret); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.session(id)
+	if err != nil {
+		return 0, err
+	}
+	return m.submit(s, custodian, component, componentKCV)
+}
+
+// TransportKey returns the RSA key the components of the ceremony are encrypted to when
+// they are not typed on the terminal of the service, see SubmitEncrypted. The key pair
+// lives in the token until the ceremony ends.
+func (m *Manager) TransportKey(id string) (*rsa.PublicKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.session(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.transport == 0 {
+		priv, pub, err := hsm_api.GenerateTransportKeyPair(m.ctx, m.ss, TransportKeyPrefix+id[:8])
+		if err != nil {
+			return nil, err
+		}
+		s.transport, s.transportPub = priv, pub
+	}
+	return hsm_api.RSAPublicKey(m.ctx, m.ss, s.transportPub)
+}
+
+// SubmitEncrypted enters one component encrypted with RSA-OAEP SHA-256 to the transport key
+// of the ceremony, it is decrypted by the token and handled like Submit.
+func (m *Manager) SubmitEncrypted(id, custodian, secret string, encrypted, componentKCV []byte) (int, error) {
+	if err := m.authenticate(custodian, secret); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.session(id)
+	if err != nil {
+		return 0, err
+	}
+	if s.transport == 0 {
+		return 0, fmt.Errorf("ceremony %s has no transport key", id)
+	}
+	component, err := hsm_api.DecryptOAEP(m.ctx, m.ss, s.transport, encrypted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt component: %v", err)
+	}
+	defer func() {
+		for i := range component {
+			component[i] = 0
+		}
+	}()
+	return m.submit(s, custodian, component, componentKCV)
+}
+
+// submit checks and combines a component, the caller holds the lock.
+func (m *Manager) submit(s *session, custodian string, component, componentKCV []byte) (int, error) {
+	if s.count == s.components {
+		return 0, fmt.Errorf("all components were already entered")
+	}
+	for _, c := range s.transcript.Components {
+		if c.Custodian == custodian {
+			return 0, fmt.Errorf("custodian %s already entered a component", custodian)
+		}
+	}
+
+	kcv, err := hsm_api.SoftKeyCheckValue(component, s.transcript.KeyType, "")
+	if err != nil {
+		return 0, err
+	}
+	if subtle.ConstantTimeCompare(kcv, componentKCV) != 1 {
+		return 0, fmt.Errorf("component check value mismatch")
+	}
+
+	if err := m.combine(s, component); err != nil {
+		return 0, err
+	}
+
+	s.count++
+	s.transcript.Components = append(s.transcript.Components, Component{
+		Custodian:   custodian,
+		KCV:         strings.ToUpper(hex.EncodeToString(kcv)),
+		SubmittedAt: time.Now().UTC(),
+	})
+
+	return s.components - s.count, nil
+}
+
+// combine XORs the component into the session key, or into the memory accumulator when
+// the token lacks CKM_XOR_BASE_AND_DATA and AllowClearCombine was called. The
+// accumulator holds the clear key once the last component is in, it is wiped when the
+// ceremony ends.
+func (m *Manager) combine(s *session, component []byte) error {
+	if !s.xor {
+		if s.acc == nil {
+			s.acc = make([]byte, len(component))
+		}
+		for i := range s.acc {
+			s.acc[i] ^= component[i]
+		}
+		return nil
+	}
+
+	if s.count == 0 {
+		base, err := hsm_api.ImportKeyComponent(m.ctx, m.ss, component)
+		if err != nil {
+			return err
+		}
+		s.base = base
+		return nil
+	}
+
+	// the last component is combined in Finalize with the key template
+	if s.count == s.components-1 {
+		s.acc = append([]byte{}, component...)
+		return nil
+	}
+
+	base, err := hsm_api.XorKeyComponent(m.ctx, m.ss, s.base, component, nil)
+	if err != nil {
+		return err
+	}
+	hsm_api.RemoveKey(m.ctx, m.ss, s.base)
+	s.base = base
+	return nil
+}
+
+// Finalize stores the combined key, verifies its check value and writes the signed transcript.
+func (m *Manager) Finalize(id, custodian, secret string) (*Transcript, error) {
+	if err := m.authenticate(custodian, secret); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.session(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.count != s.components {
+		return nil, fmt.Errorf("%d components are still missing", s.components-s.count)
+	}
+	defer m.close(s)
+
+	t := &s.transcript
+	template, err := hsm_api.SecretKeyTemplate(t.KeyLabel, t.KeyType, s.xor)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := hsm_api.NewKeyID()
+	if err != nil {
+		return nil, err
+	}
+	template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, keyID))
+
+	var key pkcs11.ObjectHandle
+	if s.xor {
+		key, err = hsm_api.XorKeyComponent(m.ctx, m.ss, s.base, s.acc, template)
+	} else {
+		key, err = hsm_api.UnwrapSecretKey(m.ctx, m.ss, s.acc, template)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kcv, err := hsm_api.KeyCheckValue(m.ctx, m.ss, key, "")
+	if err != nil {
+		hsm_api.RemoveKey(m.ctx, m.ss, key)
+		return nil, err
+	}
+	if len(s.expected) > 0 && subtle.ConstantTimeCompare(kcv, s.expected) != 1 {
+		hsm_api.RemoveKey(m.ctx, m.ss, key)
+		return nil, fmt.Errorf("key check value mismatch: got %X", kcv)
+	}
+
+	if t.Purpose != "" {
+		if err := policy.NewEnforcer(m.ctx, m.ss, nil).Register(key, nil, t.Purpose); err != nil {
+			hsm_api.RemoveKey(m.ctx, m.ss, key)
+			return nil, err
+		}
+	}
+
+	t.KCV = strings.ToUpper(hex.EncodeToString(kcv))
+	t.KeyID = hex.EncodeToString(keyID)
+	t.CompletedBy = custodian
+	t.CompletedAt = time.Now().UTC()
+
+	if err := m.writeTranscript(t); err != nil {
+		hsm_api.RemoveKey(m.ctx, m.ss, key)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Abort drops an unfinished ceremony and the partial key.
+func (m *Manager) Abort(id, custodian, secret string) error {
+	if err := m.authenticate(custodian, secret); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.session(id)
+	if err != nil {
+		return err
+	}
+	m.close(s)
+	return nil
+}
+
+// writeTranscript signs the transcript with the ceremony signing key (SHA256 RSA PKCS#1)
+// and writes it as <transcript_dir>/<id>.json.
+func (m *Manager) writeTranscript(t *Transcript) error {
+	signingKey, err := hsm_api.FindKeys(m.ctx, m.ss, pkcs11.CKO_PRIVATE_KEY, m.conf.Ceremony.SigningKey)
+	if err != nil {
+		return fmt.Errorf("failed to find ceremony signing key: %v", err)
+	}
+
+	t.Signature = ""
+	unsigned, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transcript: %v", err)
+	}
+
+	signature, err := hsm_api.Sign(m.ctx, m.ss, signingKey[0], pkcs11.CKM_SHA256_RSA_PKCS, unsigned)
+	if err != nil {
+		return err
+	}
+	t.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal transcript: %v", err)
+	}
+
+	dir := transcriptDir(m.conf)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create transcript dir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, t.ID+".json"), b, 0600); err != nil {
+		return fmt.Errorf("failed to write transcript: %v", err)
+	}
+
+	return nil
+}
+
+// CheckRotation refuses a rotation policy on the n2k key or on a key a ceremony loaded, as
+// the transcripts tell: a rotation would replace the key the custodians hold the components
+// of with one nobody can rebuild.
+func CheckRotation(conf *configs.Config) error {
+	labels := map[string]bool{conf.HSM.N2kLabel: true}
+	files, err := filepath.Glob(filepath.Join(transcriptDir(conf), "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list transcripts: %v", err)
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to read transcript: %v", err)
+		}
+		var t Transcript
+		if err := json.Unmarshal(b, &t); err != nil {
+			return fmt.Errorf("failed to parse transcript %s: %v", f, err)
+		}
+		labels[t.KeyLabel] = true
+	}
+
+	for _, p := range conf.Rotation.Policies {
+		if labels[p.Label] {
+			return fmt.Errorf("rotation policy on ceremony key %s", p.Label)
+		}
+	}
+	return nil
+}
+
+func rotated(conf *configs.Config, label string) bool {
+	for _, p := range conf.Rotation.Policies {
+		if p.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+func transcriptDir(conf *configs.Config) string {
+	if conf.Ceremony.TranscriptDir == "" {
+		return "."
+	}
+	return conf.Ceremony.TranscriptDir
+}
+
+// VerifyTranscript checks the signature of a transcript with the ceremony signing key.
+func (m *Manager) VerifyTranscript(t Transcript) error {
+	signature, err := base64.StdEncoding.DecodeString(t.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %v", err)
+	}
+
+	pub, err := hsm_api.FindKeys(m.ctx, m.ss, pkcs11.CKO_PUBLIC_KEY, m.conf.Ceremony.SigningKey)
+	if err != nil {
+		return fmt.Errorf("failed to find ceremony verification key: %v", err)
+	}
+
+	t.Signature = ""
+	unsigned, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transcript: %v", err)
+	}
+
+	return hsm_api.Verify(m.ctx, m.ss, pub[0], pkcs11.CKM_SHA256_RSA_PKCS, unsigned, signature)
+}
+
+func (m *Manager) authenticate(custodian, secret string) error {
+	sum := sha256.Sum256([]byte(secret))
+	for _, c := range m.conf.Ceremony.Custodians {
+		if c.Name != custodian {
+			continue
+		}
+		expected, err := hex.DecodeString(c.SecretHash)
+		if err == nil && subtle.ConstantTimeCompare(expected, sum[:]) == 1 {
+			return nil
+		}
+		break
+	}
+	return fmt.Errorf("custodian authentication failed")
+}
+
+// session returns a running ceremony, the caller holds the lock.
+func (m *Manager) session(id string) (*session, error) {
+	m.expire()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("ceremony %s not found", id)
+	}
+	return s, nil
+}
+
+// expire closes the ceremonies left open for too long, the caller holds the lock.
+func (m *Manager) expire() {
+	for _, s := range m.sessions {
+		if time.Since(s.transcript.StartedAt) > ceremonyTimeout {
+			m.close(s)
+		}
+	}
+}
+
+// close wipes the partial key material of the ceremony, the caller holds the lock.
+func (m *Manager) close(s *session) {
+	if s.xor && s.count > 0 {
+		hsm_api.RemoveKey(m.ctx, m.ss, s.base)
+	}
+	if s.transport != 0 {
+		hsm_api.RemoveKey(m.ctx, m.ss, s.transport)
+		hsm_api.RemoveKey(m.ctx, m.ss, s.transportPub)
+	}
+	for i := range s.acc {
+		s.acc[i] = 0
+	}
+	delete(m.sessions, s.transcript.ID)
+}
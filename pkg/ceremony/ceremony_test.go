@@ -0,0 +1,77 @@
+package ceremony
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hsm/configs"
+)
+
+func testConfig() *configs.Config {
+	return &configs.Config{
+		Ceremony: configs.Ceremony{
+			Custodians: []configs.Custodian{
+				// sha256 of "custodian-a" and "custodian-b"
+				{Name: "custodian-a", SecretHash: "039cc1e2d407a35c11823bf5b55978d9a50744f636bac81841e484713cadebf3"},
+				{Name: "custodian-b", SecretHash: "aead443fc868422e386ae2a7501bebb43c4cc878854638d2a63343d253b324a1"},
+			},
+		},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	m := NewManager(testConfig(), nil, 0)
+
+	if err := m.authenticate("custodian-a", "custodian-a"); err != nil {
+		t.Error(err)
+	}
+	if err := m.authenticate("custodian-a", "custodian-b"); err == nil {
+		t.Error("expected error for the passphrase of another custodian")
+	}
+	if err := m.authenticate("custodian-c", "custodian-c"); err == nil {
+		t.Error("expected error for unknown custodian")
+	}
+}
+
+func TestUnknownCeremony(t *testing.T) {
+	m := NewManager(testConfig(), nil, 0)
+
+	if _, err := m.Submit("missing", "custodian-b", "custodian-b", make([]byte, 32), nil); err == nil {
+		t.Error("expected error for unknown ceremony")
+	}
+	if _, err := m.Start("custodian-b", "wrong", "", "", "", nil); err == nil {
+		t.Error("expected error for failed authentication")
+	}
+}
+
+func TestCheckRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "t1.json"), []byte(`{"id":"t1","key_label":"zone-pin-key"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := testConfig()
+	conf.HSM.N2kLabel = "n2k-master-key"
+	conf.Ceremony.TranscriptDir = dir
+	conf.Rotation.Policies = []configs.RotationPolicy{{Label: "data-key", Period: time.Hour}}
+	if err := CheckRotation(conf); err != nil {
+		t.Error(err)
+	}
+
+	for _, label := range []string{"n2k-master-key", "zone-pin-key"} {
+		conf.Rotation.Policies = []configs.RotationPolicy{{Label: label, Period: time.Hour}}
+		if err := CheckRotation(conf); err == nil {
+			t.Errorf("%s: expected error for a ceremony key", label)
+		}
+		if _, err := NewManager(conf, nil, 0).Start("custodian-a", "custodian-a", label, "", "", nil); err == nil {
+			t.Errorf("%s: expected error for a ceremony on a rotated key", label)
+		}
+	}
+}
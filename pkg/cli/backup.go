@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"hsm/configs"
+	"hsm/pkg/approval"
+	"hsm/pkg/backup"
+	"hsm/pkg/metadata"
+)
+
+// backupCmd wraps the extractable keys of the token, writes the bundle to the backup
+// directory and the shares of its KEK to the custodian directories, or prints them.
+func backupCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("backup")
+	shares := fs.Int("n", conf.Backup.Shares, "number of kek shares")
+	threshold := fs.Int("m", conf.Backup.Threshold, "shares needed to restore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if approval.Guards(conf, approval.OpExportKeys) {
+		return fmt.Errorf("key export is under dual control, request the backup from the service: POST /api/v1/backups")
+	}
+
+	ctx, ss, closeSession, err := openSession(conf)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	store, err := metadata.NewStore(conf, ctx, ss)
+	if err != nil {
+		return err
+	}
+
+	b, results, err := backup.Create(conf, ctx, ss, *shares, *threshold, store.List(metadata.Filter{}), os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	printResults(results)
+	printSkipped(b)
+	fmt.Printf("backup %s: %d keys, kek split in %d shares, %d needed, bundle written to %s\n",
+		b.ID, len(b.Keys), b.Shares, b.Threshold, conf.Backup.Dir)
+	if len(conf.Backup.Custodians) == 0 {
+		fmt.Println("the shares above are not stored, each custodian keeps a copy of its own share")
+	} else {
+		for _, c := range conf.Backup.Custodians {
+			fmt.Printf("  share of %s written to %s\n", c.Name, c.Path)
+		}
+	}
+
+	return nil
+}
+
+// restoreCmd unwraps a backup bundle into the token: restore <bundle> <share>...
+func restoreCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("restore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: restore <bundle file> <share file>...")
+	}
+
+	ctx, ss, closeSession, err := openSession(conf)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	b, results, err := backup.Restore(ctx, ss, fs.Arg(0), fs.Args()[1:])
+	printResults(results)
+	if err != nil {
+		return err
+	}
+	printSkipped(b)
+
+	if len(b.Metadata) > 0 {
+		store, err := metadata.NewStore(conf, ctx, ss)
+		if err != nil {
+			return err
+		}
+		for _, e := range b.Metadata {
+			if _, err := store.Put(e); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("metadata of %d keys restored, restart the service to load it\n", len(b.Metadata))
+	}
+	fmt.Printf("backup %s restored, every key verified\n", b.ID)
+
+	return nil
+}
+
+// printSkipped warns about the keys the backup does not hold.
+func printSkipped(b *backup.Bundle) {
+	if len(b.Skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %d keys cannot be extracted and are NOT in backup %s: %s\n",
+		len(b.Skipped), b.ID, strings.Join(b.Skipped, ", "))
+}
+
+func printResults(results []backup.Result) {
+	for _, r := range results {
+		fmt.Printf("  %-8s %-32s %s\n", r.Class, r.Label, r.Status)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"hsm/configs"
+	"hsm/pkg/ceremony"
+)
+
+// ceremonyCmd runs a key loading ceremony on this terminal, custodians take turns to
+// authenticate and type their component.
+func ceremonyCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("ceremony")
+	label := fs.String("label", conf.HSM.N2kLabel, "label of the key to load")
+	keyType := fs.String("type", "AES256", "key type: AES128, AES192, AES256, DES2 or DES3")
+	purpose := fs.String("purpose", "", "purpose of a PIN key: pin-encryption or pin-verification")
+	kcv := fs.String("kcv", "", "expected check value of the key (hex)")
+	clearCombine := fs.Bool("clear-combine", false, "combine the components in memory when the token cannot XOR derive, development tokens only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	expected, err := hex.DecodeString(*kcv)
+	if err != nil {
+		return fmt.Errorf("invalid kcv: %v", err)
+	}
+
+	ctx, ss, closeSession, err := openSession(conf)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	m := ceremony.NewManager(conf, ctx, ss)
+	if *clearCombine {
+		m.AllowClearCombine()
+	}
+	p := newPrompter()
+
+	fmt.Println("custodian opening the ceremony")
+	opener, openerSecret, err := askCustodian(p)
+	if err != nil {
+		return err
+	}
+
+	t, err := m.Start(opener, openerSecret, *label, *keyType, *purpose, expected)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ceremony %s started for key %s\n", t.ID, t.KeyLabel)
+
+	custodian, secret := opener, openerSecret
+	for remaining := -1; remaining != 0; {
+		fmt.Println("next custodian")
+		name, passphrase, err := askCustodian(p)
+		if err != nil {
+			m.Abort(t.ID, opener, openerSecret)
+			return err
+		}
+
+		component, componentKCV, err := askComponent(p)
+		if err == nil {
+			remaining, err = m.Submit(t.ID, name, passphrase, component, componentKCV)
+		}
+		if err != nil {
+			fmt.Printf("component rejected: %v\n", err)
+			continue
+		}
+		custodian, secret = name, passphrase
+		fmt.Printf("component accepted, %d remaining\n", remaining)
+	}
+
+	t, err = m.Finalize(t.ID, custodian, secret)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("key %s loaded, kcv %s, transcript %s.json signed\n", t.KeyLabel, t.KCV, t.ID)
+
+	return nil
+}
+
+func askCustodian(p *prompter) (string, string, error) {
+	name, err := p.ask("custodian name")
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := p.askSecret("custodian passphrase")
+	if err != nil {
+		return "", "", err
+	}
+	return name, secret, nil
+}
+
+func askComponent(p *prompter) ([]byte, []byte, error) {
+	c, err := p.askSecret("component (hex)")
+	if err != nil {
+		return nil, nil, err
+	}
+	component, err := hex.DecodeString(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid component: %v", err)
+	}
+
+	k, err := p.ask("component kcv (hex)")
+	if err != nil {
+		return nil, nil, err
+	}
+	kcv, err := hex.DecodeString(k)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid component kcv: %v", err)
+	}
+
+	return component, kcv, nil
+}
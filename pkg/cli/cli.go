@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+type command struct {
+	usage string
+	run   func(conf *configs.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"ceremony":   {"load a key from custodian components", ceremonyCmd},
+	"backup":     {"back up the extractable keys under a shared kek", backupCmd},
+	"restore":    {"restore a backup from custodian kek shares", restoreCmd},
+	"import":     {"import an RSA or EC private key from a PEM/DER file", importCmd},
+	"inventory":  {"list every object of the token and the risky ones", inventoryCmd},
+	"init-token": {"initialize a token with its label and SO PIN", initTokenCmd},
+	"init-pin":   {"set the user PIN of a token after an SO login", initPINCmd},
+	"set-pin":    {"change the user or SO PIN of a token", setPINCmd},
+}
+
+// Run executes the command line tool named by args[0] against the configured HSM.
+func Run(conf *configs.Config, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+	return cmd.run(conf, args[1:])
+}
+
+func usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := strings.Builder{}
+	b.WriteString("commands:\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %-12s %s\n", name, commands[name].usage))
+	}
+	return b.String()
+}
+
+// openSession opens a logged in session on the configured slot, close releases it.
+func openSession(conf *configs.Config) (*pkcs11.Ctx, pkcs11.SessionHandle, func(), error) {
+	ctx, err := hsm_api.GetContext(conf.ModulePath)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	ss, err := hsm_api.GetSession(ctx, conf.HSM.SlotID, conf.HSM.Pin)
+	if err != nil {
+		hsm_api.FinishContext(ctx)
+		return nil, 0, nil, err
+	}
+
+	return ctx, ss, func() {
+		hsm_api.FinishSession(ctx, ss)
+		hsm_api.FinishContext(ctx)
+	}, nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// prompter reads answers line by line from stdin.
+type prompter struct {
+	r *bufio.Reader
+}
+
+func newPrompter() *prompter {
+	return &prompter{r: bufio.NewReader(os.Stdin)}
+}
+
+func (p *prompter) ask(question string) (string, error) {
+	fmt.Printf("%s: ", question)
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %v", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askSecret reads a passphrase, a PIN or a clear component without echo. Input that is
+// not a terminal, such as a pipe, is read as is.
+func (p *prompter) askSecret(question string) (string, error) {
+	restore, hidden, err := noEcho(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("failed to hide input: %v", err)
+	}
+	if hidden {
+		defer func() {
+			restore()
+			fmt.Println()
+		}()
+	}
+	return p.ask(question)
+}
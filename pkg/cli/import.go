@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/keytemplate"
+)
+
+// importCmd stores an RSA or EC private key file in the token: import -label <label> <file>
+func importCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("import")
+	label := fs.String("label", "", "label of the imported key")
+	id := fs.String("id", "", "id of the imported key (hex)")
+	templateName := fs.String("template", "", "key template of the configuration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *label == "" {
+		return fmt.Errorf("usage: import -label <label> [-id <hex>] [-template <name>] <private key file>")
+	}
+
+	var template *hsm_api.KeyTemplate
+	if *templateName != "" {
+		templates, err := keytemplate.Load(conf.KeyTemplates)
+		if err != nil {
+			return err
+		}
+		if template = templates[*templateName]; template == nil {
+			return fmt.Errorf("unknown key template: %s", *templateName)
+		}
+	}
+
+	keyID, err := hex.DecodeString(*id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %v", err)
+	}
+	key, err := hsm_api.ParsePrivateKey(data)
+	if err != nil {
+		return err
+	}
+
+	ctx, ss, closeSession, err := openSession(conf)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	priv, _, err := hsm_api.ImportPrivateKey(ctx, ss, *label, keyID, key, template)
+	if err != nil {
+		return err
+	}
+
+	info, err := hsm_api.DescribeKey(ctx, ss, priv)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %s %d bits key %s, extractable: %v\n", info.KeyType, info.Bits, info.Label, info.Extractable)
+
+	return nil
+}
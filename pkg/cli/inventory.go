@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"os"
+
+	"hsm/configs"
+	"hsm/pkg/inventory"
+)
+
+// inventoryCmd prints every object of the configured slot with the findings to review.
+func inventoryCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("inventory")
+	format := fs.String("format", inventory.FormatTable, "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, ss, closeSession, err := openSession(conf)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	r, err := inventory.Take(ctx, ss, conf.HSM.SlotID)
+	if err != nil {
+		return err
+	}
+	return inventory.Write(os.Stdout, r, *format)
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// noEcho turns off the echo of the terminal and returns the function restoring it, ok is
+// false when fd is not a terminal.
+func noEcho(fd int) (restore func(), ok bool, err error) {
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err == unix.ENOTTY || err == unix.EINVAL {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	hidden := *state
+	hidden.Lflag &^= unix.ECHO
+	hidden.Lflag |= unix.ICANON | unix.ISIG
+	hidden.Iflag |= unix.ICRNL
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &hidden); err != nil {
+		return nil, false, err
+	}
+	return func() { unix.IoctlSetTermios(fd, unix.TCSETS, state) }, true, nil
+}
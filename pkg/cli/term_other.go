@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+// noEcho cannot hide the input outside Linux, secrets are read as typed and echoed.
+func noEcho(fd int) (restore func(), ok bool, err error) {
+	return nil, false, nil
+}
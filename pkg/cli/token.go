@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+
+	"hsm/configs"
+	"hsm/pkg/approval"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/tokenadmin"
+)
+
+// initTokenCmd initializes a token: init-token -label <label> [-slot <id>], the SO PIN is
+// prompted.
+func initTokenCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("init-token")
+	label := fs.String("label", "", "label of the token")
+	slot := fs.Uint("slot", 0, "slot of the token, the first uninitialized one by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *label == "" {
+		return fmt.Errorf("usage: init-token -label <label> [-slot <id>]")
+	}
+
+	soPIN, err := newPrompter().askSecret("SO PIN")
+	if err != nil {
+		return err
+	}
+
+	return withAdmin(conf, func(a *tokenadmin.Admin) error {
+		id, err := a.InitToken(*slot, *label, soPIN)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("token %s initialized in slot %d\n", *label, id)
+		return nil
+	})
+}
+
+// initPINCmd sets the user PIN of a token after an SO login: init-pin -label <label>
+func initPINCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("init-pin")
+	label := fs.String("label", "", "label of the token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *label == "" {
+		return fmt.Errorf("usage: init-pin -label <label>")
+	}
+	if err := pinChangeAllowed(conf); err != nil {
+		return err
+	}
+
+	p := newPrompter()
+	soPIN, err := p.askSecret("SO PIN")
+	if err != nil {
+		return err
+	}
+	pin, err := p.askSecret("user PIN")
+	if err != nil {
+		return err
+	}
+
+	return withAdmin(conf, func(a *tokenadmin.Admin) error {
+		return a.InitPIN(*label, soPIN, pin)
+	})
+}
+
+// setPINCmd changes the user or SO PIN of a token: set-pin -label <label> [-so]. A new
+// user PIN of the configured token is saved to its PIN file.
+func setPINCmd(conf *configs.Config, args []string) error {
+	fs := newFlagSet("set-pin")
+	label := fs.String("label", "", "label of the token")
+	so := fs.Bool("so", false, "change the SO PIN")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *label == "" {
+		return fmt.Errorf("usage: set-pin -label <label> [-so]")
+	}
+	if err := pinChangeAllowed(conf); err != nil {
+		return err
+	}
+
+	userType := tokenadmin.UserTypeUser
+	if *so {
+		userType = tokenadmin.UserTypeSO
+	}
+
+	p := newPrompter()
+	oldPIN, err := p.askSecret("current PIN")
+	if err != nil {
+		return err
+	}
+	newPIN, err := p.askSecret("new PIN")
+	if err != nil {
+		return err
+	}
+
+	return withAdmin(conf, func(a *tokenadmin.Admin) error {
+		return a.SetPIN(*label, userType, oldPIN, newPIN)
+	})
+}
+
+// pinChangeAllowed refuses PIN changes under dual control, the approvals are collected by
+// the service.
+func pinChangeAllowed(conf *configs.Config) error {
+	if approval.Guards(conf, approval.OpChangePIN) {
+		return fmt.Errorf("pin changes are under dual control, request them from the service: /api/v1/tokens/<label>/pin")
+	}
+	return nil
+}
+
+// withAdmin runs f with the token administration of the module, without a session.
+func withAdmin(conf *configs.Config, f func(a *tokenadmin.Admin) error) error {
+	ctx, err := hsm_api.GetContext(conf.ModulePath)
+	if err != nil {
+		return err
+	}
+	defer hsm_api.FinishContext(ctx)
+
+	return f(tokenadmin.New(ctx, &conf.HSM, 0))
+}
@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"context"
+
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/keyalias"
+	"hsm/pkg/tenant"
+
+	"github.com/gemalto/pkcs11"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// encryptionKey returns the key ring and the algorithm of an Encrypt request, an empty
+// type is the n2k key with the algorithm of the request.
+func (s Server) encryptionKey(ctx context.Context, typ, algorithm string) (string, string, error) {
+	if typ == "" {
+		ring, err := s.label(ctx, s.conf.HSM.N2kLabel)
+		return ring, algorithm, err
+	}
+
+	a, err := s.keyAlias(typ)
+	if err != nil {
+		return "", "", err
+	}
+	if algorithm, err = a.EncryptAlgorithm(algorithm); err != nil {
+		return "", "", err
+	}
+	ring, err := s.aliasRing(ctx, a)
+	return ring, algorithm, err
+}
+
+// decryptionKey returns the key ring of a Decrypt request for a cipher text of the algorithm.
+func (s Server) decryptionKey(ctx context.Context, typ, algorithm string) (string, error) {
+	if typ == "" {
+		return s.label(ctx, s.conf.HSM.N2kLabel)
+	}
+
+	a, err := s.keyAlias(typ)
+	if err != nil {
+		return "", err
+	}
+	if err := a.CheckDecrypt(algorithm); err != nil {
+		return "", err
+	}
+	return s.aliasRing(ctx, a)
+}
+
+func (s Server) keyAlias(typ string) (*keyalias.Alias, error) {
+	a, ok := s.aliases[typ]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown key type: %s", typ)
+	}
+	return a, nil
+}
+
+// aliasRing resolves the key ring of the alias in the namespace of the caller, an alias by
+// id names the ring of the secret key with the id.
+func (s Server) aliasRing(ctx context.Context, a *keyalias.Alias) (string, error) {
+	if a.Label != "" {
+		return s.label(ctx, a.Label)
+	}
+
+	objs, err := hsm_api.FindObjects(s.ctx, s.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, a.ID),
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(s.ctx, s.ss, obj, pkcs11.CKA_LABEL)
+		if err != nil {
+			return "", err
+		}
+		if label := string(attrs[pkcs11.CKA_LABEL]); tenant.Owns(auth.Tenant(ctx), label) && !s.internal(label) {
+			return label, nil
+		}
+	}
+	return "", status.Errorf(codes.NotFound, "not found key of type %s", a.Type)
+}
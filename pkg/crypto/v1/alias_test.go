@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hsm/configs"
+	"hsm/pkg/keyalias"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEncryptionKey(t *testing.T) {
+	conf := &configs.Config{HSM: configs.HSM{N2kLabel: "n2k-master-key"}}
+	aliases, err := keyalias.Load([]configs.KeyAlias{
+		{Type: "CARD_DATA", Label: "card-data-key", Algorithm: "GCM"},
+		{Type: "LEGACY", Label: "legacy-key", Policy: keyalias.PolicyDecryptOnly},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Server{conf: conf, aliases: aliases}
+	ctx := context.Background()
+
+	ring, alg, err := s.encryptionKey(ctx, "", "CBC")
+	if err != nil || ring != "n2k-master-key" || alg != "CBC" {
+		t.Errorf("empty type: got %s %s %v", ring, alg, err)
+	}
+	ring, alg, err = s.encryptionKey(ctx, "CARD_DATA", "")
+	if err != nil || ring != "card-data-key" || alg != "GCM" {
+		t.Errorf("CARD_DATA: got %s %s %v", ring, alg, err)
+	}
+	if _, _, err := s.encryptionKey(ctx, "N2K_UNKNOWN", ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown type: got %v", err)
+	}
+	_, _, err = s.encryptionKey(ctx, "LEGACY", "")
+	if !errors.Is(err, keyalias.ErrNotAllowed) {
+		t.Errorf("decrypt-only: got %v", err)
+	}
+	if code := status.Code(keyError("failed to encrypt", err)); code != codes.PermissionDenied {
+		t.Errorf("decrypt-only: got code %v", code)
+	}
+
+	if ring, err := s.decryptionKey(ctx, "LEGACY", "CBC"); err != nil || ring != "legacy-key" {
+		t.Errorf("LEGACY: got %s %v", ring, err)
+	}
+	if _, err := s.decryptionKey(ctx, "CARD_DATA", "CBC"); !errors.Is(err, keyalias.ErrNotAllowed) {
+		t.Errorf("CARD_DATA with CBC: got %v", err)
+	}
+}
+
+func TestReEncryptAliases(t *testing.T) {
+	conf := &configs.Config{HSM: configs.HSM{N2kLabel: "n2k-master-key"}}
+	aliases, err := keyalias.Load([]configs.KeyAlias{
+		{Type: "CARD_DATA", Label: "card-data-key", Algorithm: "GCM"},
+		{Type: "LEGACY", Label: "legacy-key", Policy: keyalias.PolicyDecryptOnly},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Server{conf: conf, aliases: aliases}
+	ctx := context.Background()
+	cbc := envelope{algorithm: algorithmCBC, data: []byte("iv and cipher")}.seal()
+
+	// the aliases are checked before any key is used
+	if _, err := s.reEncrypt(ctx, "", "LEGACY", "", cbc); !errors.Is(err, keyalias.ErrNotAllowed) {
+		t.Errorf("into decrypt-only: got %v", err)
+	}
+	if _, err := s.reEncrypt(ctx, "CARD_DATA", "", "", cbc); !errors.Is(err, keyalias.ErrNotAllowed) {
+		t.Errorf("CBC from CARD_DATA: got %v", err)
+	}
+	if _, err := s.reEncrypt(ctx, "N2K_UNKNOWN", "", "", cbc); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown type: got %v", err)
+	}
+
+	_, err = s.ReEncryptBatch(ctx, &ReEncryptBatchRequest{CipherTexts: make([]string, maxReEncryptBatch+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("oversized batch: got %v", err)
+	}
+}
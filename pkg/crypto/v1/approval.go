@@ -0,0 +1,129 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"hsm/pkg/approval"
+	"hsm/pkg/auth"
+	"hsm/pkg/tenant"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListApprovals lists the approval requests on the keys of the namespace of the caller.
+func (s Server) ListApprovals(ctx context.Context, req *ListApprovalsRequest) (*ListApprovalsResponse, error) {
+	caller := auth.Tenant(ctx)
+	res := &ListApprovalsResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}
+	for _, r := range s.approvals.List() {
+		if tenant.Owns(caller, r.Target) {
+			res.Requests = append(res.Requests, s.approvalRequest(r))
+		}
+	}
+	return res, nil
+}
+
+func (s Server) GetApproval(ctx context.Context, req *GetApprovalRequest) (*ApprovalResponse, error) {
+	r, err := s.approval(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Request:      s.approvalRequest(r),
+	}, nil
+}
+
+// Approve adds a signed approval of the caller, the operation runs when it completes the
+// quorum and its failure is reported in the status of the request. The approver is the
+// token of the call, a request naming another approver is refused.
+func (s Server) Approve(ctx context.Context, req *ApproveRequest) (*ApprovalResponse, error) {
+	if _, err := s.approval(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	approver := auth.Caller(ctx)
+	if req.Approver != "" && req.Approver != approver {
+		return nil, status.Errorf(codes.PermissionDenied, "%s cannot approve for %s", approver, req.Approver)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to decode signature: %v", err)
+	}
+
+	r, err := s.approvals.Approve(req.Id, approver, sig)
+	if err != nil {
+		return nil, approvalError(err)
+	}
+	return &ApprovalResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Request:      s.approvalRequest(r),
+	}, nil
+}
+
+// approval returns the request when its target is in the namespace of the caller.
+func (s Server) approval(ctx context.Context, id string) (*approval.Request, error) {
+	r, err := s.approvals.Get(id)
+	if err == nil && !tenant.Owns(auth.Tenant(ctx), r.Target) {
+		err = approval.ErrNotFound
+	}
+	if err != nil {
+		return nil, approvalError(err)
+	}
+	return r, nil
+}
+
+// deleteApproved is the executor of approved key deletions.
+func (s Server) deleteApproved(r *approval.Request) error {
+	id, err := hex.DecodeString(r.Params["id"])
+	if err != nil {
+		return fmt.Errorf("failed to decode request id: %v", err)
+	}
+	_, err = s.deleteKeys(r.Target, r.Params["keyClass"], id, r.Requester)
+	return err
+}
+
+func (s Server) approvalRequest(r *approval.Request) *ApprovalRequest {
+	res := &ApprovalRequest{
+		Id:        r.ID,
+		Operation: r.Operation,
+		Target:    tenant.Local(tenant.Of(r.Target), r.Target),
+		Params:    r.Params,
+		Requester: r.Requester,
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: r.ExpiresAt.Format(time.RFC3339),
+		Threshold: int32(s.approvals.Threshold()),
+		Status:    r.Status,
+		Error:     r.Error,
+		Payload:   string(r.Payload()),
+	}
+	for _, a := range r.Approvals {
+		res.Approvals = append(res.Approvals, &Approval{
+			Approver:   a.Approver,
+			ApprovedAt: a.ApprovedAt.Format(time.RFC3339),
+		})
+	}
+	return res
+}
+
+func approvalError(err error) error {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, approval.ErrRefused):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, approval.ErrClosed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
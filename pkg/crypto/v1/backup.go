@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"hsm/pkg/approval"
+	"hsm/pkg/audit"
+	"hsm/pkg/auth"
+	"hsm/pkg/backup"
+	"hsm/pkg/metadata"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// target of the approval requests of a backup, the backup covers the whole token
+const backupTarget = "backup"
+
+// ExportBackup backs up the extractable keys of the service token, the bundle goes to the
+// backup directory and the shares of its KEK to the custodian directories of the config.
+func (s Server) ExportBackup(ctx context.Context, req *ExportBackupRequest) (*ExportBackupResponse, error) {
+	if err := tokenCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	shares, threshold := int(req.Shares), int(req.Threshold)
+	if shares == 0 {
+		shares = s.conf.Backup.Shares
+	}
+	if threshold == 0 {
+		threshold = s.conf.Backup.Threshold
+	}
+	// the service has no one to print the shares to
+	if err := backup.CheckDestinations(s.conf, shares, nil); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to export backup: %v", err)
+	}
+
+	if s.approvals.Guarded(approval.OpExportKeys) {
+		r, err := s.approvals.Submit(approval.OpExportKeys, backupTarget, map[string]string{
+			"shares":    strconv.Itoa(shares),
+			"threshold": strconv.Itoa(threshold),
+		}, auth.Caller(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &ExportBackupResponse{
+			ErrorCode:    "0000",
+			ErrorMessage: "success",
+			Approval:     s.approvalRequest(r),
+		}, nil
+	}
+
+	b, err := s.exportBackup(shares, threshold, auth.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportBackupResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Id:           b.ID,
+		Keys:         int32(len(b.Keys)),
+		Skipped:      b.Skipped,
+	}, nil
+}
+
+func (s Server) exportBackup(shares, threshold int, actor string) (*backup.Bundle, error) {
+	b, _, err := backup.Create(s.conf, s.ctx, s.ss, shares, threshold, s.metadata.List(metadata.Filter{}), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export backup: %v", err)
+	}
+	s.audit.Log(audit.Event{
+		Actor:  actor,
+		Action: "backup-export",
+		Key:    b.ID,
+		Detail: map[string]string{
+			"keys":      strconv.Itoa(len(b.Keys)),
+			"skipped":   strings.Join(b.Skipped, ","),
+			"shares":    strconv.Itoa(shares),
+			"threshold": strconv.Itoa(threshold),
+		},
+	})
+	return b, nil
+}
+
+// exportApproved is the executor of approved backups.
+func (s Server) exportApproved(r *approval.Request) error {
+	shares, err := strconv.Atoi(r.Params["shares"])
+	if err != nil {
+		return fmt.Errorf("invalid shares: %v", err)
+	}
+	threshold, err := strconv.Atoi(r.Params["threshold"])
+	if err != nil {
+		return fmt.Errorf("invalid threshold: %v", err)
+	}
+	_, err = s.exportBackup(shares, threshold, r.Requester)
+	return err
+}
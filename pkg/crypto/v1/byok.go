@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"hsm/pkg/auth"
+	"hsm/pkg/byok"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetImportParameters hands out a wrapping public key and an import token bound to the caller.
+func (s Server) GetImportParameters(ctx context.Context, req *GetImportParametersRequest) (*GetImportParametersResponse, error) {
+	p, err := s.byok.Parameters(auth.Caller(ctx), req.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import parameters: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode wrapping key: %v", err)
+	}
+
+	return &GetImportParametersResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		ImportToken:  p.Token,
+		Algorithm:    p.Algorithm,
+		PublicKey:    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Fingerprint:  p.Fingerprint,
+		ExpiresAt:    p.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// ImportKeyMaterial unwraps the caller's key material with the wrapping key of its import token.
+func (s Server) ImportKeyMaterial(ctx context.Context, req *ImportKeyMaterialRequest) (*ImportKeyMaterialResponse, error) {
+	id, err := newKeyID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(req.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request wrappedKey: %v", err)
+	}
+
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.keyTemplate(req.Template)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		if t, err = hsm_api.DefaultKeyTemplate(req.KeyType, false); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := s.byok.Import(req.ImportToken, auth.Caller(ctx), label, id, t, wrapped)
+	if errors.Is(err, byok.ErrInvalidToken) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, keyError("failed to import key material", err)
+	}
+	if err := s.registerPolicy(t, key); err != nil {
+		return nil, err
+	}
+
+	info, err := hsm_api.DescribeKey(s.ctx, s.ss, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe key: %v", err)
+	}
+
+	keyInfo := s.keyInfo(info)
+	// the key is in, a failed check value leaves it empty
+	if keyInfo.Kcv, err = s.checkValue(info); err != nil {
+		log.Print(err)
+	}
+	return &ImportKeyMaterialResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Key:          keyInfo,
+		Origin:       byok.OriginExternal,
+		Kcv:          keyInfo.Kcv,
+	}, nil
+}
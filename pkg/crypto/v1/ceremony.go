@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+
+	"hsm/pkg/auth"
+)
+
+// the custodians also authenticate with their passphrases
+var ceremonyRoles = map[string][]string{
+	"/crypto.KeyCeremony/StartCeremony":      {auth.RoleCustodian},
+	"/crypto.KeyCeremony/SubmitKeyComponent": {auth.RoleCustodian},
+	"/crypto.KeyCeremony/FinalizeCeremony":   {auth.RoleCustodian},
+	"/crypto.KeyCeremony/AbortCeremony":      {auth.RoleCustodian},
+}
+
+func (s Server) StartCeremony(ctx context.Context, req *StartCeremonyRequest) (*StartCeremonyResponse, error) {
+	expected, err := hex.DecodeString(req.ExpectedKcv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request expectedKcv: %v", err)
+	}
+
+	t, err := s.ceremony.Start(req.Custodian, req.Secret, req.KeyLabel, req.KeyType, req.Purpose, expected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start ceremony: %v", err)
+	}
+
+	pub, err := s.ceremony.TransportKey(t.ID)
+	if err != nil {
+		s.ceremony.Abort(t.ID, req.Custodian, req.Secret)
+		return nil, fmt.Errorf("failed to start ceremony: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		s.ceremony.Abort(t.ID, req.Custodian, req.Secret)
+		return nil, fmt.Errorf("failed to encode transport key: %v", err)
+	}
+	log.Printf("ceremony %s for key %s started by %s", t.ID, t.KeyLabel, t.StartedBy)
+
+	return &StartCeremonyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		CeremonyId:   t.ID,
+		KeyLabel:     t.KeyLabel,
+		TransportKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+func (s Server) SubmitKeyComponent(ctx context.Context, req *SubmitKeyComponentRequest) (*SubmitKeyComponentResponse, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(req.EncryptedComponent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request encryptedComponent: %v", err)
+	}
+	kcv, err := hex.DecodeString(req.ComponentKcv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request componentKcv: %v", err)
+	}
+
+	remaining, err := s.ceremony.SubmitEncrypted(req.CeremonyId, req.Custodian, req.Secret, encrypted, kcv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit key component: %v", err)
+	}
+	log.Printf("ceremony %s: component entered by %s, %d remaining", req.CeremonyId, req.Custodian, remaining)
+
+	return &SubmitKeyComponentResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Remaining:    int32(remaining),
+	}, nil
+}
+
+func (s Server) FinalizeCeremony(ctx context.Context, req *FinalizeCeremonyRequest) (*FinalizeCeremonyResponse, error) {
+	t, err := s.ceremony.Finalize(req.CeremonyId, req.Custodian, req.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize ceremony: %v", err)
+	}
+	log.Printf("ceremony %s completed by %s, key %s kcv %s", t.ID, t.CompletedBy, t.KeyLabel, t.KCV)
+
+	transcript, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transcript: %v", err)
+	}
+
+	return &FinalizeCeremonyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Kcv:          t.KCV,
+		Transcript:   string(transcript),
+	}, nil
+}
+
+func (s Server) AbortCeremony(ctx context.Context, req *AbortCeremonyRequest) (*AbortCeremonyResponse, error) {
+	if err := s.ceremony.Abort(req.CeremonyId, req.Custodian, req.Secret); err != nil {
+		return nil, fmt.Errorf("failed to abort ceremony: %v", err)
+	}
+	log.Printf("ceremony %s aborted by %s", req.CeremonyId, req.Custodian)
+
+	return &AbortCeremonyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}, nil
+}
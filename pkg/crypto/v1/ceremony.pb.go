@@ -0,0 +1,1065 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.13.0
+// source: ceremony.proto
+
+package crypto
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// every call is authenticated with the custodian name and passphrase, on top of the bearer
+// token of the key-custodian role.
+// check values are hex: ECB for DES keys, CMAC for AES keys.
+// purpose is empty, pin-encryption (zpk) or pin-verification (pvk).
+// a token without CKM_XOR_BASE_AND_DATA cannot run ceremonies over the API.
+type StartCeremonyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Custodian   string `protobuf:"bytes,1,opt,name=custodian,proto3" json:"custodian,omitempty"`
+	Secret      string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	KeyLabel    string `protobuf:"bytes,3,opt,name=keyLabel,proto3" json:"keyLabel,omitempty"`
+	KeyType     string `protobuf:"bytes,4,opt,name=keyType,proto3" json:"keyType,omitempty"`
+	ExpectedKcv string `protobuf:"bytes,5,opt,name=expectedKcv,proto3" json:"expectedKcv,omitempty"`
+	Purpose     string `protobuf:"bytes,6,opt,name=purpose,proto3" json:"purpose,omitempty"`
+}
+
+func (x *StartCeremonyRequest) Reset() {
+	*x = StartCeremonyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartCeremonyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartCeremonyRequest) ProtoMessage() {}
+
+func (x *StartCeremonyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartCeremonyRequest.ProtoReflect.Descriptor instead.
+func (*StartCeremonyRequest) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartCeremonyRequest) GetCustodian() string {
+	if x != nil {
+		return x.Custodian
+	}
+	return ""
+}
+
+func (x *StartCeremonyRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *StartCeremonyRequest) GetKeyLabel() string {
+	if x != nil {
+		return x.KeyLabel
+	}
+	return ""
+}
+
+func (x *StartCeremonyRequest) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *StartCeremonyRequest) GetExpectedKcv() string {
+	if x != nil {
+		return x.ExpectedKcv
+	}
+	return ""
+}
+
+func (x *StartCeremonyRequest) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+// transportKey is the PEM RSA key the components are encrypted to.
+type StartCeremonyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ErrorCode    string `protobuf:"bytes,1,opt,name=errorCode,proto3" json:"errorCode,omitempty"`
+	ErrorMessage string `protobuf:"bytes,2,opt,name=errorMessage,proto3" json:"errorMessage,omitempty"`
+	CeremonyId   string `protobuf:"bytes,3,opt,name=ceremonyId,proto3" json:"ceremonyId,omitempty"`
+	KeyLabel     string `protobuf:"bytes,4,opt,name=keyLabel,proto3" json:"keyLabel,omitempty"`
+	TransportKey string `protobuf:"bytes,5,opt,name=transportKey,proto3" json:"transportKey,omitempty"`
+}
+
+func (x *StartCeremonyResponse) Reset() {
+	*x = StartCeremonyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartCeremonyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartCeremonyResponse) ProtoMessage() {}
+
+func (x *StartCeremonyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartCeremonyResponse.ProtoReflect.Descriptor instead.
+func (*StartCeremonyResponse) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartCeremonyResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *StartCeremonyResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *StartCeremonyResponse) GetCeremonyId() string {
+	if x != nil {
+		return x.CeremonyId
+	}
+	return ""
+}
+
+func (x *StartCeremonyResponse) GetKeyLabel() string {
+	if x != nil {
+		return x.KeyLabel
+	}
+	return ""
+}
+
+func (x *StartCeremonyResponse) GetTransportKey() string {
+	if x != nil {
+		return x.TransportKey
+	}
+	return ""
+}
+
+// encryptedComponent is the component under RSA-OAEP SHA-256 with the transport key, in
+// base64; clear components are only typed on the terminal of the ceremony command.
+type SubmitKeyComponentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CeremonyId         string `protobuf:"bytes,1,opt,name=ceremonyId,proto3" json:"ceremonyId,omitempty"`
+	Custodian          string `protobuf:"bytes,2,opt,name=custodian,proto3" json:"custodian,omitempty"`
+	Secret             string `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	ComponentKcv       string `protobuf:"bytes,5,opt,name=componentKcv,proto3" json:"componentKcv,omitempty"`
+	EncryptedComponent string `protobuf:"bytes,6,opt,name=encryptedComponent,proto3" json:"encryptedComponent,omitempty"`
+}
+
+func (x *SubmitKeyComponentRequest) Reset() {
+	*x = SubmitKeyComponentRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitKeyComponentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitKeyComponentRequest) ProtoMessage() {}
+
+func (x *SubmitKeyComponentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitKeyComponentRequest.ProtoReflect.Descriptor instead.
+func (*SubmitKeyComponentRequest) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubmitKeyComponentRequest) GetCeremonyId() string {
+	if x != nil {
+		return x.CeremonyId
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentRequest) GetCustodian() string {
+	if x != nil {
+		return x.Custodian
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentRequest) GetComponentKcv() string {
+	if x != nil {
+		return x.ComponentKcv
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentRequest) GetEncryptedComponent() string {
+	if x != nil {
+		return x.EncryptedComponent
+	}
+	return ""
+}
+
+type SubmitKeyComponentResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ErrorCode    string `protobuf:"bytes,1,opt,name=errorCode,proto3" json:"errorCode,omitempty"`
+	ErrorMessage string `protobuf:"bytes,2,opt,name=errorMessage,proto3" json:"errorMessage,omitempty"`
+	Remaining    int32  `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+}
+
+func (x *SubmitKeyComponentResponse) Reset() {
+	*x = SubmitKeyComponentResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitKeyComponentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitKeyComponentResponse) ProtoMessage() {}
+
+func (x *SubmitKeyComponentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitKeyComponentResponse.ProtoReflect.Descriptor instead.
+func (*SubmitKeyComponentResponse) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SubmitKeyComponentResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *SubmitKeyComponentResponse) GetRemaining() int32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+type FinalizeCeremonyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CeremonyId string `protobuf:"bytes,1,opt,name=ceremonyId,proto3" json:"ceremonyId,omitempty"`
+	Custodian  string `protobuf:"bytes,2,opt,name=custodian,proto3" json:"custodian,omitempty"`
+	Secret     string `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+}
+
+func (x *FinalizeCeremonyRequest) Reset() {
+	*x = FinalizeCeremonyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalizeCeremonyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalizeCeremonyRequest) ProtoMessage() {}
+
+func (x *FinalizeCeremonyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalizeCeremonyRequest.ProtoReflect.Descriptor instead.
+func (*FinalizeCeremonyRequest) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FinalizeCeremonyRequest) GetCeremonyId() string {
+	if x != nil {
+		return x.CeremonyId
+	}
+	return ""
+}
+
+func (x *FinalizeCeremonyRequest) GetCustodian() string {
+	if x != nil {
+		return x.Custodian
+	}
+	return ""
+}
+
+func (x *FinalizeCeremonyRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+// transcript is the signed JSON transcript.
+type FinalizeCeremonyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ErrorCode    string `protobuf:"bytes,1,opt,name=errorCode,proto3" json:"errorCode,omitempty"`
+	ErrorMessage string `protobuf:"bytes,2,opt,name=errorMessage,proto3" json:"errorMessage,omitempty"`
+	Kcv          string `protobuf:"bytes,3,opt,name=kcv,proto3" json:"kcv,omitempty"`
+	Transcript   string `protobuf:"bytes,4,opt,name=transcript,proto3" json:"transcript,omitempty"`
+}
+
+func (x *FinalizeCeremonyResponse) Reset() {
+	*x = FinalizeCeremonyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalizeCeremonyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalizeCeremonyResponse) ProtoMessage() {}
+
+func (x *FinalizeCeremonyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalizeCeremonyResponse.ProtoReflect.Descriptor instead.
+func (*FinalizeCeremonyResponse) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FinalizeCeremonyResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *FinalizeCeremonyResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *FinalizeCeremonyResponse) GetKcv() string {
+	if x != nil {
+		return x.Kcv
+	}
+	return ""
+}
+
+func (x *FinalizeCeremonyResponse) GetTranscript() string {
+	if x != nil {
+		return x.Transcript
+	}
+	return ""
+}
+
+type AbortCeremonyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CeremonyId string `protobuf:"bytes,1,opt,name=ceremonyId,proto3" json:"ceremonyId,omitempty"`
+	Custodian  string `protobuf:"bytes,2,opt,name=custodian,proto3" json:"custodian,omitempty"`
+	Secret     string `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+}
+
+func (x *AbortCeremonyRequest) Reset() {
+	*x = AbortCeremonyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AbortCeremonyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortCeremonyRequest) ProtoMessage() {}
+
+func (x *AbortCeremonyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortCeremonyRequest.ProtoReflect.Descriptor instead.
+func (*AbortCeremonyRequest) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AbortCeremonyRequest) GetCeremonyId() string {
+	if x != nil {
+		return x.CeremonyId
+	}
+	return ""
+}
+
+func (x *AbortCeremonyRequest) GetCustodian() string {
+	if x != nil {
+		return x.Custodian
+	}
+	return ""
+}
+
+func (x *AbortCeremonyRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type AbortCeremonyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ErrorCode    string `protobuf:"bytes,1,opt,name=errorCode,proto3" json:"errorCode,omitempty"`
+	ErrorMessage string `protobuf:"bytes,2,opt,name=errorMessage,proto3" json:"errorMessage,omitempty"`
+}
+
+func (x *AbortCeremonyResponse) Reset() {
+	*x = AbortCeremonyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ceremony_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AbortCeremonyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortCeremonyResponse) ProtoMessage() {}
+
+func (x *AbortCeremonyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ceremony_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortCeremonyResponse.ProtoReflect.Descriptor instead.
+func (*AbortCeremonyResponse) Descriptor() ([]byte, []int) {
+	return file_ceremony_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AbortCeremonyResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *AbortCeremonyResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+var File_ceremony_proto protoreflect.FileDescriptor
+
+var file_ceremony_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x06, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xbe, 0x01, 0x0a, 0x14, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1c, 0x0a, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69, 0x61, 0x6e, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x65,
+	0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x4b, 0x63, 0x76, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x4b, 0x63, 0x76, 0x12, 0x18, 0x0a,
+	0x07, 0x70, 0x75, 0x72, 0x70, 0x6f, 0x73, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x70, 0x75, 0x72, 0x70, 0x6f, 0x73, 0x65, 0x22, 0xb9, 0x01, 0x0a, 0x15, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12,
+	0x22, 0x0a, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x49,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e,
+	0x79, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12,
+	0x22, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x4b, 0x65, 0x79, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x4b, 0x65, 0x79, 0x22, 0xd6, 0x01, 0x0a, 0x19, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4b, 0x65,
+	0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x49,
+	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69, 0x61, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69, 0x61, 0x6e, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x4b, 0x63, 0x76, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4b, 0x63, 0x76, 0x12, 0x2e, 0x0a, 0x12, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x65, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4a, 0x04, 0x08, 0x04, 0x10,
+	0x05, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x22, 0x7c, 0x0a, 0x1a,
+	0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4b, 0x65, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09,
+	0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x22, 0x6f, 0x0a, 0x17, 0x46, 0x69,
+	0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e,
+	0x79, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d,
+	0x6f, 0x6e, 0x79, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69,
+	0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64,
+	0x69, 0x61, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x22, 0x8e, 0x01, 0x0a, 0x18,
+	0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x43, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x63,
+	0x76, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x63, 0x76, 0x12, 0x1e, 0x0a, 0x0a,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x22, 0x6c, 0x0a, 0x14,
+	0x41, 0x62, 0x6f, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f,
+	0x6e, 0x79, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69, 0x61,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x64, 0x69,
+	0x61, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x22, 0x59, 0x0a, 0x15, 0x41, 0x62,
+	0x6f, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64,
+	0x65, 0x12, 0x22, 0x0a, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x32, 0xf1, 0x03, 0x0a, 0x0b, 0x4b, 0x65, 0x79, 0x43, 0x65, 0x72,
+	0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x12, 0x6f, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x65,
+	0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x12, 0x1c, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e,
+	0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x2f, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x82, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69,
+	0x74, 0x4b, 0x65, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x2e,
+	0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4b, 0x65, 0x79,
+	0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x22, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x4b, 0x65, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x25, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1f, 0x22, 0x1a, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x2f, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x7b, 0x0a, 0x10, 0x46,
+	0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x12,
+	0x1f, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a,
+	0x65, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x20, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69,
+	0x7a, 0x65, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x24, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1e, 0x22, 0x19, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x2f, 0x66, 0x69, 0x6e,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x6f, 0x0a, 0x0d, 0x41, 0x62, 0x6f, 0x72,
+	0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x12, 0x1c, 0x2e, 0x63, 0x72, 0x79, 0x70,
+	0x74, 0x6f, 0x2e, 0x41, 0x62, 0x6f, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f,
+	0x2e, 0x41, 0x62, 0x6f, 0x72, 0x74, 0x43, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x72, 0x65, 0x6d, 0x6f, 0x6e, 0x79,
+	0x2f, 0x61, 0x62, 0x6f, 0x72, 0x74, 0x3a, 0x01, 0x2a, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x3b, 0x63,
+	0x72, 0x79, 0x70, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_ceremony_proto_rawDescOnce sync.Once
+	file_ceremony_proto_rawDescData = file_ceremony_proto_rawDesc
+)
+
+func file_ceremony_proto_rawDescGZIP() []byte {
+	file_ceremony_proto_rawDescOnce.Do(func() {
+		file_ceremony_proto_rawDescData = protoimpl.X.CompressGZIP(file_ceremony_proto_rawDescData)
+	})
+	return file_ceremony_proto_rawDescData
+}
+
+var file_ceremony_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_ceremony_proto_goTypes = []interface{}{
+	(*StartCeremonyRequest)(nil),       // 0: crypto.StartCeremonyRequest
+	(*StartCeremonyResponse)(nil),      // 1: crypto.StartCeremonyResponse
+	(*SubmitKeyComponentRequest)(nil),  // 2: crypto.SubmitKeyComponentRequest
+	(*SubmitKeyComponentResponse)(nil), // 3: crypto.SubmitKeyComponentResponse
+	(*FinalizeCeremonyRequest)(nil),    // 4: crypto.FinalizeCeremonyRequest
+	(*FinalizeCeremonyResponse)(nil),   // 5: crypto.FinalizeCeremonyResponse
+	(*AbortCeremonyRequest)(nil),       // 6: crypto.AbortCeremonyRequest
+	(*AbortCeremonyResponse)(nil),      // 7: crypto.AbortCeremonyResponse
+}
+var file_ceremony_proto_depIdxs = []int32{
+	0, // 0: crypto.KeyCeremony.StartCeremony:input_type -> crypto.StartCeremonyRequest
+	2, // 1: crypto.KeyCeremony.SubmitKeyComponent:input_type -> crypto.SubmitKeyComponentRequest
+	4, // 2: crypto.KeyCeremony.FinalizeCeremony:input_type -> crypto.FinalizeCeremonyRequest
+	6, // 3: crypto.KeyCeremony.AbortCeremony:input_type -> crypto.AbortCeremonyRequest
+	1, // 4: crypto.KeyCeremony.StartCeremony:output_type -> crypto.StartCeremonyResponse
+	3, // 5: crypto.KeyCeremony.SubmitKeyComponent:output_type -> crypto.SubmitKeyComponentResponse
+	5, // 6: crypto.KeyCeremony.FinalizeCeremony:output_type -> crypto.FinalizeCeremonyResponse
+	7, // 7: crypto.KeyCeremony.AbortCeremony:output_type -> crypto.AbortCeremonyResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_ceremony_proto_init() }
+func file_ceremony_proto_init() {
+	if File_ceremony_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_ceremony_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartCeremonyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartCeremonyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitKeyComponentRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitKeyComponentResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FinalizeCeremonyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FinalizeCeremonyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AbortCeremonyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ceremony_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AbortCeremonyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ceremony_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ceremony_proto_goTypes,
+		DependencyIndexes: file_ceremony_proto_depIdxs,
+		MessageInfos:      file_ceremony_proto_msgTypes,
+	}.Build()
+	File_ceremony_proto = out.File
+	file_ceremony_proto_rawDesc = nil
+	file_ceremony_proto_goTypes = nil
+	file_ceremony_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// KeyCeremonyClient is the client API for KeyCeremony service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type KeyCeremonyClient interface {
+	StartCeremony(ctx context.Context, in *StartCeremonyRequest, opts ...grpc.CallOption) (*StartCeremonyResponse, error)
+	SubmitKeyComponent(ctx context.Context, in *SubmitKeyComponentRequest, opts ...grpc.CallOption) (*SubmitKeyComponentResponse, error)
+	FinalizeCeremony(ctx context.Context, in *FinalizeCeremonyRequest, opts ...grpc.CallOption) (*FinalizeCeremonyResponse, error)
+	AbortCeremony(ctx context.Context, in *AbortCeremonyRequest, opts ...grpc.CallOption) (*AbortCeremonyResponse, error)
+}
+
+type keyCeremonyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyCeremonyClient(cc grpc.ClientConnInterface) KeyCeremonyClient {
+	return &keyCeremonyClient{cc}
+}
+
+func (c *keyCeremonyClient) StartCeremony(ctx context.Context, in *StartCeremonyRequest, opts ...grpc.CallOption) (*StartCeremonyResponse, error) {
+	out := new(StartCeremonyResponse)
+	err := c.cc.Invoke(ctx, "/crypto.KeyCeremony/StartCeremony", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyCeremonyClient) SubmitKeyComponent(ctx context.Context, in *SubmitKeyComponentRequest, opts ...grpc.CallOption) (*SubmitKeyComponentResponse, error) {
+	out := new(SubmitKeyComponentResponse)
+	err := c.cc.Invoke(ctx, "/crypto.KeyCeremony/SubmitKeyComponent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyCeremonyClient) FinalizeCeremony(ctx context.Context, in *FinalizeCeremonyRequest, opts ...grpc.CallOption) (*FinalizeCeremonyResponse, error) {
+	out := new(FinalizeCeremonyResponse)
+	err := c.cc.Invoke(ctx, "/crypto.KeyCeremony/FinalizeCeremony", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyCeremonyClient) AbortCeremony(ctx context.Context, in *AbortCeremonyRequest, opts ...grpc.CallOption) (*AbortCeremonyResponse, error) {
+	out := new(AbortCeremonyResponse)
+	err := c.cc.Invoke(ctx, "/crypto.KeyCeremony/AbortCeremony", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeyCeremonyServer is the server API for KeyCeremony service.
+type KeyCeremonyServer interface {
+	StartCeremony(context.Context, *StartCeremonyRequest) (*StartCeremonyResponse, error)
+	SubmitKeyComponent(context.Context, *SubmitKeyComponentRequest) (*SubmitKeyComponentResponse, error)
+	FinalizeCeremony(context.Context, *FinalizeCeremonyRequest) (*FinalizeCeremonyResponse, error)
+	AbortCeremony(context.Context, *AbortCeremonyRequest) (*AbortCeremonyResponse, error)
+}
+
+// UnimplementedKeyCeremonyServer can be embedded to have forward compatible implementations.
+type UnimplementedKeyCeremonyServer struct {
+}
+
+func (*UnimplementedKeyCeremonyServer) StartCeremony(context.Context, *StartCeremonyRequest) (*StartCeremonyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartCeremony not implemented")
+}
+func (*UnimplementedKeyCeremonyServer) SubmitKeyComponent(context.Context, *SubmitKeyComponentRequest) (*SubmitKeyComponentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitKeyComponent not implemented")
+}
+func (*UnimplementedKeyCeremonyServer) FinalizeCeremony(context.Context, *FinalizeCeremonyRequest) (*FinalizeCeremonyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinalizeCeremony not implemented")
+}
+func (*UnimplementedKeyCeremonyServer) AbortCeremony(context.Context, *AbortCeremonyRequest) (*AbortCeremonyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortCeremony not implemented")
+}
+
+func RegisterKeyCeremonyServer(s *grpc.Server, srv KeyCeremonyServer) {
+	s.RegisterService(&_KeyCeremony_serviceDesc, srv)
+}
+
+func _KeyCeremony_StartCeremony_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartCeremonyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyCeremonyServer).StartCeremony(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crypto.KeyCeremony/StartCeremony",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyCeremonyServer).StartCeremony(ctx, req.(*StartCeremonyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyCeremony_SubmitKeyComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitKeyComponentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyCeremonyServer).SubmitKeyComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crypto.KeyCeremony/SubmitKeyComponent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyCeremonyServer).SubmitKeyComponent(ctx, req.(*SubmitKeyComponentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyCeremony_FinalizeCeremony_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FinalizeCeremonyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyCeremonyServer).FinalizeCeremony(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crypto.KeyCeremony/FinalizeCeremony",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyCeremonyServer).FinalizeCeremony(ctx, req.(*FinalizeCeremonyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyCeremony_AbortCeremony_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortCeremonyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyCeremonyServer).AbortCeremony(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crypto.KeyCeremony/AbortCeremony",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyCeremonyServer).AbortCeremony(ctx, req.(*AbortCeremonyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _KeyCeremony_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "crypto.KeyCeremony",
+	HandlerType: (*KeyCeremonyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartCeremony",
+			Handler:    _KeyCeremony_StartCeremony_Handler,
+		},
+		{
+			MethodName: "SubmitKeyComponent",
+			Handler:    _KeyCeremony_SubmitKeyComponent_Handler,
+		},
+		{
+			MethodName: "FinalizeCeremony",
+			Handler:    _KeyCeremony_FinalizeCeremony_Handler,
+		},
+		{
+			MethodName: "AbortCeremony",
+			Handler:    _KeyCeremony_AbortCeremony_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ceremony.proto",
+}
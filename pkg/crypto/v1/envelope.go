@@ -0,0 +1,53 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cipher text algorithms
+const (
+	algorithmCBC = "CBC" // AES or 3DES CBC with PKCS#7 padding, iv || cipher
+	algorithmGCM = "GCM" // AES-GCM, iv || cipher || tag
+)
+
+// envelope is a cipher text with the key ring version and algorithm it was encrypted with.
+type envelope struct {
+	version   int
+	algorithm string
+	data      []byte
+}
+
+// seal formats the envelope as v<version>:<base64> for CBC and v<version>:gcm:<base64> for GCM.
+func (e envelope) seal() string {
+	data := base64.StdEncoding.EncodeToString(e.data)
+	if e.algorithm == algorithmGCM {
+		return fmt.Sprintf("v%d:gcm:%s", e.version, data)
+	}
+	return fmt.Sprintf("v%d:%s", e.version, data)
+}
+
+// openEnvelope parses a cipher text. Plain base64 was encrypted with CBC before key rings
+// existed, under version 0.
+func openEnvelope(cipherText string) (envelope, error) {
+	e := envelope{algorithm: algorithmCBC}
+	if i := strings.IndexByte(cipherText, ':'); i >= 0 {
+		v, err := strconv.Atoi(strings.TrimPrefix(cipherText[:i], "v"))
+		if err != nil || !strings.HasPrefix(cipherText, "v") || v < 0 {
+			return e, fmt.Errorf("invalid key version: %s", cipherText[:i])
+		}
+		e.version, cipherText = v, cipherText[i+1:]
+	}
+	if strings.HasPrefix(cipherText, "gcm:") {
+		e.algorithm, cipherText = algorithmGCM, strings.TrimPrefix(cipherText, "gcm:")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return e, err
+	}
+	e.data = data
+	return e, nil
+}
@@ -0,0 +1,35 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	data := []byte("iv and cipher")
+
+	for _, algorithm := range []string{algorithmCBC, algorithmGCM} {
+		e, err := openEnvelope(envelope{version: 3, algorithm: algorithm, data: data}.seal())
+		if err != nil {
+			t.Error(err)
+		}
+		if e.version != 3 || e.algorithm != algorithm || !bytes.Equal(e.data, data) {
+			t.Errorf("%s: got %+v", algorithm, e)
+		}
+	}
+
+	// cipher text from before key rings
+	e, err := openEnvelope("aXYgYW5kIGNpcGhlcg==")
+	if err != nil {
+		t.Error(err)
+	}
+	if e.version != 0 || e.algorithm != algorithmCBC || !bytes.Equal(e.data, data) {
+		t.Errorf("legacy: got %+v", e)
+	}
+
+	for _, invalid := range []string{"x3:aXY=", "v-1:aXY=", "v:aXY=", "v1:not base64", "v1:ctr:aXY="} {
+		if _, err := openEnvelope(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package crypto
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func (s Server) GetKeyCheckValue(ctx context.Context, req *GetKeyCheckValueRequest) (*GetKeyCheckValueResponse, error) {
+	obj, err := s.key(ctx, pkcs11.CKO_SECRET_KEY, req.KeyLabel, req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	kcv, err := hsm_api.KeyCheckValue(s.ctx, s.ss, obj, strings.ToUpper(req.Method))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key check value: %v", err)
+	}
+
+	res := &GetKeyCheckValueResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Kcv:          strings.ToUpper(hex.EncodeToString(kcv)),
+	}
+	if req.ExpectedKcv != "" {
+		res.Verified = subtle.ConstantTimeCompare([]byte(res.Kcv), []byte(strings.ToUpper(req.ExpectedKcv))) == 1
+	}
+
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+)
+
+func (s Server) RotateKey(ctx context.Context, req *RotateKeyRequest) (*RotateKeyResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.rotateKey(label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate key: %v", err)
+	}
+	log.Printf("key ring %s rotated to version %d by %s", label, v.Version, auth.Caller(ctx))
+
+	return &RotateKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Primary:      keyVersion(v),
+	}, nil
+}
+
+// rotateKey rotates the key ring, the new version is held to the allowed mechanisms and the
+// purpose of the primary, from the token or the policy records. Every rotation goes through it.
+func (s Server) rotateKey(label string) (*hsm_api.KeyVersion, error) {
+	primary, err := hsm_api.PrimaryKeyVersion(s.ctx, s.ss, label)
+	if err != nil {
+		return nil, err
+	}
+	mechs, err := s.policy.Mechanisms(primary.Handle)
+	if err != nil {
+		return nil, err
+	}
+	purpose, err := s.policy.Purpose(primary.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := hsm_api.RotateKey(s.ctx, s.ss, label, mechs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.Register(v.Handle, mechs, purpose); err != nil {
+		hsm_api.RemoveKey(s.ctx, s.ss, v.Handle)
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s Server) GetRotationSchedule(ctx context.Context, req *GetRotationScheduleRequest) (*GetRotationScheduleResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := s.rotation.Schedule(label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rotation schedule: %v", err)
+	}
+
+	res := &GetRotationScheduleResponse{
+		ErrorCode:      "0000",
+		ErrorMessage:   "success",
+		PrimaryVersion: int32(sched.Primary),
+		Since:          sched.Since.Format(time.RFC3339),
+		Period:         sched.Policy.Period.String(),
+		Operations:     int64(sched.Operations),
+		MaxOperations:  int64(sched.Policy.MaxOperations),
+	}
+	if !sched.Next.IsZero() {
+		res.NextRotation = sched.Next.Format(time.RFC3339)
+	}
+	return res, nil
+}
+
+func (s Server) ListKeyVersions(ctx context.Context, req *ListKeyVersionsRequest) (*ListKeyVersionsResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := hsm_api.KeyVersions(s.ctx, s.ss, label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list key versions: %v", err)
+	}
+
+	res := &ListKeyVersionsResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}
+	for _, v := range versions {
+		res.Versions = append(res.Versions, keyVersion(v))
+	}
+	return res, nil
+}
+
+func (s Server) DisableKeyVersion(ctx context.Context, req *SetKeyVersionStateRequest) (*SetKeyVersionStateResponse, error) {
+	return s.setKeyVersionEnabled(ctx, req, false)
+}
+
+func (s Server) EnableKeyVersion(ctx context.Context, req *SetKeyVersionStateRequest) (*SetKeyVersionStateResponse, error) {
+	return s.setKeyVersionEnabled(ctx, req, true)
+}
+
+func (s Server) setKeyVersionEnabled(ctx context.Context, req *SetKeyVersionStateRequest, enabled bool) (*SetKeyVersionStateResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := hsm_api.SetKeyVersionEnabled(s.ctx, s.ss, label, int(req.Version), enabled); err != nil {
+		return nil, fmt.Errorf("failed to change key version: %v", err)
+	}
+	log.Printf("version %d of key ring %s enabled: %v, by %s", req.Version, label, enabled, auth.Caller(ctx))
+
+	return &SetKeyVersionStateResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}, nil
+}
+
+func keyVersion(v *hsm_api.KeyVersion) *KeyVersion {
+	return &KeyVersion{
+		Version: int32(v.Version),
+		Primary: v.Primary,
+		Enabled: v.Enabled,
+	}
+}
@@ -0,0 +1,449 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"hsm/pkg/approval"
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+	"hsm/pkg/tenant"
+
+	"github.com/gemalto/pkcs11"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// roles accepted by the key management methods
+var keyManagementRoles = map[string][]string{
+	"/crypto.KeyManagement/CreateKey":   {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/ImportKey":   {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/ListKeys":    {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/DescribeKey": {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/DeleteKey":   {auth.RoleKeyAdmin},
+
+	"/crypto.KeyManagement/GetPublicKey": {auth.RoleKeyAdmin, auth.RoleKeyReader},
+
+	"/crypto.KeyManagement/GetKeyMetadata":       {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/SetKeyMetadata":       {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/ListKeyMetadata":      {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/ReconcileKeyMetadata": {auth.RoleKeyAdmin, auth.RoleKeyReader},
+
+	"/crypto.KeyManagement/FindObjects":  {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/GetInventory": {auth.RoleKeyAdmin, auth.RoleKeyReader},
+
+	"/crypto.KeyManagement/SetKeyState":         {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/GetKeyState":         {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/RotateKey":           {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/ListKeyVersions":     {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/GetRotationSchedule": {auth.RoleKeyAdmin, auth.RoleKeyReader},
+	"/crypto.KeyManagement/DisableKeyVersion":   {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/EnableKeyVersion":    {auth.RoleKeyAdmin},
+
+	"/crypto.KeyManagement/GetImportParameters": {auth.RoleKeyAdmin},
+	"/crypto.KeyManagement/ImportKeyMaterial":   {auth.RoleKeyAdmin},
+
+	"/crypto.KeyManagement/ListApprovals": {auth.RoleKeyAdmin, auth.RoleKeyReader, auth.RoleApprover},
+	"/crypto.KeyManagement/GetApproval":   {auth.RoleKeyAdmin, auth.RoleKeyReader, auth.RoleApprover},
+	"/crypto.KeyManagement/Approve":       {auth.RoleApprover},
+}
+
+func (s Server) CreateKey(ctx context.Context, req *CreateKeyRequest) (*CreateKeyResponse, error) {
+	id, err := newKeyID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.keyTemplate(req.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []pkcs11.ObjectHandle
+	if t != nil {
+		objs, err = hsm_api.GenerateFromTemplate(s.ctx, s.ss, t, label, id)
+	} else {
+		objs, err = hsm_api.GenerateKey(s.ctx, s.ss, label, id, req.KeyType, req.Extractable)
+	}
+	if errors.Is(err, hsm_api.ErrDuplicateKey) && req.Rotate {
+		return s.createVersion(ctx, label)
+	}
+	if err != nil {
+		return nil, keyError("failed to create key", err)
+	}
+	if err := s.registerPolicy(t, objs...); err != nil {
+		return nil, err
+	}
+	if t != nil {
+		log.Printf("key %s of template %s created by %s", label, t.Name, auth.Caller(ctx))
+	} else {
+		log.Printf("key %s of type %s created by %s", label, req.KeyType, auth.Caller(ctx))
+	}
+
+	if req.PreActive {
+		if _, err := s.lifecycle.Register(label, id, lifecycle.PreActive, auth.Caller(ctx)); err != nil {
+			return nil, fmt.Errorf("failed to register key: %v", err)
+		}
+	}
+	if req.Metadata != nil {
+		if _, err := s.putMetadata(label, id, req.Metadata, auth.Caller(ctx)); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]*KeyInfo, 0, len(objs))
+	for _, obj := range objs {
+		info, err := hsm_api.DescribeKey(s.ctx, s.ss, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe key: %v", err)
+		}
+		keys = append(keys, s.keyInfo(info))
+	}
+
+	return &CreateKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Keys:         keys,
+	}, nil
+}
+
+func (s Server) ImportKey(ctx context.Context, req *ImportKeyRequest) (*ImportKeyResponse, error) {
+	id, err := newKeyID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(req.PrivateKey)
+	if !strings.Contains(req.PrivateKey, "-----BEGIN") {
+		if data, err = base64.StdEncoding.DecodeString(req.PrivateKey); err != nil {
+			return nil, fmt.Errorf("failed to decode request privateKey: %v", err)
+		}
+	}
+
+	key, err := hsm_api.ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.keyTemplate(req.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, pub, err := hsm_api.ImportPrivateKey(s.ctx, s.ss, label, id, key, t)
+	if err != nil {
+		return nil, keyError("failed to import key", err)
+	}
+	if err := s.registerPolicy(t, priv, pub); err != nil {
+		return nil, err
+	}
+	log.Printf("key %s imported by %s", label, auth.Caller(ctx))
+
+	keys := make([]*KeyInfo, 0, 2)
+	for _, obj := range []pkcs11.ObjectHandle{priv, pub} {
+		info, err := hsm_api.DescribeKey(s.ctx, s.ss, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe key: %v", err)
+		}
+		keys = append(keys, s.keyInfo(info))
+	}
+
+	return &ImportKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Keys:         keys,
+	}, nil
+}
+
+// ListKeys only lists the keys of the namespace of the caller.
+func (s Server) ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error) {
+	label := req.Label
+	if label != "" {
+		var err error
+		if label, err = s.label(ctx, label); err != nil {
+			return nil, err
+		}
+	}
+
+	infos, err := hsm_api.ListKeys(s.ctx, s.ss, req.KeyClass, label, req.KeyType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %v", err)
+	}
+
+	return &ListKeysResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Keys:         s.keyInfos(tenant.Keys(auth.Tenant(ctx), infos), nil),
+	}, nil
+}
+
+func (s Server) DescribeKey(ctx context.Context, req *DescribeKeyRequest) (*DescribeKeyResponse, error) {
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	label, err := s.keyLabel(ctx, req.Label, id)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := hsm_api.ListKeys(s.ctx, s.ss, req.KeyClass, label, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe key: %v", err)
+	}
+
+	keys := []*KeyInfo{}
+	for _, info := range infos {
+		if len(id) > 0 && !bytes.Equal(info.ID, id) {
+			continue
+		}
+		k := s.keyInfo(info)
+		if k.Kcv, err = s.checkValue(info); err != nil {
+			return nil, fmt.Errorf("failed to describe key: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("failed to describe key: not found key")
+	}
+
+	return &DescribeKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Keys:         keys,
+	}, nil
+}
+
+func (s Server) DeleteKey(ctx context.Context, req *DeleteKeyRequest) (*DeleteKeyResponse, error) {
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	label, err := s.keyLabel(ctx, req.Label, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.approvals.Guarded(approval.OpDeleteKey) {
+		r, err := s.approvals.Submit(approval.OpDeleteKey, label, map[string]string{
+			"keyClass": req.KeyClass,
+			"id":       req.Id,
+		}, auth.Caller(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &DeleteKeyResponse{
+			ErrorCode:    "0000",
+			ErrorMessage: "success",
+			Approval:     s.approvalRequest(r),
+		}, nil
+	}
+
+	deleted, err := s.deleteKeys(label, req.KeyClass, id, auth.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeleteKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Deleted:      int32(deleted),
+	}, nil
+}
+
+// deleteKeys deletes the keys of the label, and of the id when set, with their metadata.
+func (s Server) deleteKeys(label, class string, id []byte, actor string) (int, error) {
+	infos, err := hsm_api.ListKeys(s.ctx, s.ss, class, label, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete key: %v", err)
+	}
+
+	deleted, err := hsm_api.DeleteKeys(s.ctx, s.ss, class, label, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete key: %v", err)
+	}
+	log.Printf("key %s deleted by %s, %d objects", label, actor, deleted)
+
+	var ids [][]byte
+	for _, info := range infos {
+		if len(id) == 0 || bytes.Equal(info.ID, id) {
+			ids = append(ids, info.ID)
+		}
+	}
+	if err := s.dropMetadata(ids); err != nil {
+		log.Printf("failed to drop metadata of key %s: %v", label, err)
+	}
+	return deleted, nil
+}
+
+// keyLabel resolves the label of a request naming keys by label, by id or both. The keys
+// of an id alone must be in the namespace of the caller.
+func (s Server) keyLabel(ctx context.Context, label string, id []byte) (string, error) {
+	if label != "" || len(id) == 0 {
+		return s.label(ctx, label)
+	}
+
+	objs, err := hsm_api.FindObjects(s.ctx, s.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find key: %v", err)
+	}
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(s.ctx, s.ss, obj, pkcs11.CKA_LABEL)
+		if err != nil {
+			return "", fmt.Errorf("failed to find key: %v", err)
+		}
+		if l := string(attrs[pkcs11.CKA_LABEL]); tenant.Owns(auth.Tenant(ctx), l) && !s.internal(l) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("failed to find key: not found key")
+}
+
+// createVersion rotates the key ring of a label in use for a create request that asked for it.
+func (s Server) createVersion(ctx context.Context, label string) (*CreateKeyResponse, error) {
+	v, err := s.rotateKey(label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key version: %v", err)
+	}
+	log.Printf("key ring %s rotated to version %d by %s on create", label, v.Version, auth.Caller(ctx))
+
+	info, err := hsm_api.DescribeKey(s.ctx, s.ss, v.Handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe key: %v", err)
+	}
+
+	return &CreateKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Keys:         []*KeyInfo{s.keyInfo(info)},
+	}, nil
+}
+
+// newKeyID decodes the id of a new key, a UUID in hex with or without dashes, or returns
+// a new UUID when the request has none.
+func newKeyID(reqID string) ([]byte, error) {
+	id, err := hex.DecodeString(strings.Replace(reqID, "-", "", -1))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to decode request id: %v", err)
+	}
+	if len(id) > 0 {
+		if err := hsm_api.CheckKeyID(id); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request id: %v", err)
+		}
+		return id, nil
+	}
+	return hsm_api.NewKeyID()
+}
+
+// keyTemplate returns the configured template of the name, nil for an empty name.
+func (s Server) keyTemplate(name string) (*hsm_api.KeyTemplate, error) {
+	if name == "" {
+		return nil, nil
+	}
+	t, ok := s.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown key template: %s", name)
+	}
+	return t, nil
+}
+
+// registerPolicy records the purpose of the template, and its allowed mechanisms for the
+// new keys whose token did not keep CKA_ALLOWED_MECHANISMS.
+func (s Server) registerPolicy(t *hsm_api.KeyTemplate, objs ...pkcs11.ObjectHandle) error {
+	if t == nil {
+		return nil
+	}
+	for _, obj := range objs {
+		if err := s.policy.Register(obj, t.AllowedMechanisms, t.Purpose); err != nil {
+			return fmt.Errorf("failed to register key policy: %v", err)
+		}
+	}
+	return nil
+}
+
+// keyInfos converts the keys with the id, all of them when id is empty.
+func (s Server) keyInfos(infos []*hsm_api.KeyInfo, id []byte) []*KeyInfo {
+	keys := []*KeyInfo{}
+	for _, info := range infos {
+		if len(id) > 0 && hex.EncodeToString(info.ID) != hex.EncodeToString(id) {
+			continue
+		}
+		keys = append(keys, s.keyInfo(info))
+	}
+	return keys
+}
+
+func (s Server) keyInfo(info *hsm_api.KeyInfo) *KeyInfo {
+	_, state, err := s.lifecycle.State(info.Handle)
+	if err != nil {
+		log.Printf("failed to get state of key %s: %v", info.Label, err)
+	}
+	mechs, err := s.policy.Mechanisms(info.Handle)
+	if err != nil {
+		log.Printf("failed to get allowed mechanisms of key %s: %v", info.Label, err)
+	}
+	allowed := make([]string, 0, len(mechs))
+	for _, m := range mechs {
+		allowed = append(allowed, hsm_api.MechanismName(m))
+	}
+
+	k := &KeyInfo{
+		Label:             tenant.Local(tenant.Of(info.Label), info.Label),
+		Id:                hex.EncodeToString(info.ID),
+		KeyClass:          info.Class,
+		KeyType:           info.KeyType,
+		Bits:              int32(info.Bits),
+		Usage:             info.Usage,
+		Sensitive:         info.Sensitive,
+		Extractable:       info.Extractable,
+		State:             string(state),
+		AllowedMechanisms: allowed,
+	}
+	if e, ok := s.metadata.Get(info.ID); ok {
+		k.Metadata = keyMetadata(e)
+	}
+	return k
+}
+
+// checkValue computes the check value of the key, empty for the keys that have none. It
+// runs one or two encryptions on the token, so only the calls on a single key set it.
+func (s Server) checkValue(info *hsm_api.KeyInfo) (string, error) {
+	if !checkable(info) {
+		return "", nil
+	}
+	kcv, err := hsm_api.KeyCheckValue(s.ctx, s.ss, info.Handle, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to get check value of key %s: %v", info.Label, err)
+	}
+	return hex.EncodeToString(kcv), nil
+}
+
+// checkable reports whether the key has a check value: AES and DES secret keys.
+func checkable(info *hsm_api.KeyInfo) bool {
+	if info.Class != hsm_api.ClassNames[pkcs11.CKO_SECRET_KEY] {
+		return false
+	}
+	switch info.KeyType {
+	case "AES", hsm_api.KeyTypeDES2, hsm_api.KeyTypeDES3:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	hsm_api "hsm/pkg/hsm-api"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewKeyID(t *testing.T) {
+	want := []byte{0x5c, 0x1e, 0x2f, 0x94, 0x10, 0x3b, 0x4d, 0x8a, 0x9f, 0x33, 0x0e, 0x61, 0x7c, 0xd2, 0x05, 0xbb}
+	for _, reqID := range []string{"5c1e2f94103b4d8a9f330e617cd205bb", "5c1e2f94-103b-4d8a-9f33-0e617cd205bb"} {
+		id, err := newKeyID(reqID)
+		if err != nil || !bytes.Equal(id, want) {
+			t.Errorf("%s: got %x, %v", reqID, id, err)
+		}
+	}
+
+	id, err := newKeyID("")
+	if err != nil || len(id) != 16 {
+		t.Errorf("got %x, %v, want a new UUID", id, err)
+	}
+
+	for _, reqID := range []string{"zz", "01", "0a0b"} {
+		if _, err := newKeyID(reqID); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", reqID, err)
+		}
+	}
+}
+
+func TestKeyErrorCodes(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("%w: label data-key is in use", hsm_api.ErrDuplicateKey), codes.AlreadyExists},
+		{fmt.Errorf("%w: 2 keys with label data-key, set the id", hsm_api.ErrAmbiguousKey), codes.FailedPrecondition},
+		{hsm_api.ErrNoRetailMacKey, codes.FailedPrecondition},
+		{fmt.Errorf("failed to decrypt: version 1 of key ring data-key: %w", hsm_api.ErrKeyVersionDestroyed), codes.FailedPrecondition},
+		{fmt.Errorf("not found key"), codes.Unknown},
+	}
+	for _, c := range cases {
+		if got := status.Code(keyError("failed to create key", c.err)); got != c.code {
+			t.Errorf("%v: got %s, want %s", c.err, got, c.code)
+		}
+	}
+}
+
+func TestCheckable(t *testing.T) {
+	cases := []struct {
+		class, keyType string
+		want           bool
+	}{
+		{"secret", "AES", true},
+		{"secret", hsm_api.KeyTypeDES2, true},
+		{"secret", hsm_api.KeyTypeGenericSecret, false},
+		{"private", "RSA", false},
+	}
+	for _, c := range cases {
+		if got := checkable(&hsm_api.KeyInfo{Class: c.class, KeyType: c.keyType}); got != c.want {
+			t.Errorf("%s %s: got %v, want %v", c.class, c.keyType, got, c.want)
+		}
+	}
+}
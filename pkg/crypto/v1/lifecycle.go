@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hsm/pkg/approval"
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+)
+
+func (s Server) SetKeyState(ctx context.Context, req *SetKeyStateRequest) (*KeyStateResponse, error) {
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.lifecycle.Transition(label, id, lifecycle.State(strings.ToUpper(req.State)), auth.Caller(ctx), req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change key state: %v", err)
+	}
+	if r.State == lifecycle.Destroyed {
+		s.destroyed(label, id, auth.Caller(ctx))
+	}
+
+	return keyStateResponse(r), nil
+}
+
+// destroyed drops the metadata of a destroyed key and cancels the pending deletions of it,
+// its ring version is already marked destroyed. A deletion of the label without id is
+// only canceled once no key of the label is left.
+func (s Server) destroyed(label string, id []byte, actor string) {
+	if err := s.dropMetadata([][]byte{id}); err != nil {
+		log.Printf("failed to drop metadata of key %s: %v", label, err)
+	}
+
+	left, err := hsm_api.ListKeys(s.ctx, s.ss, "", label, "")
+	if err != nil {
+		log.Printf("failed to cancel deletions of key %s: %v", label, err)
+		return
+	}
+	s.approvals.Cancel(approval.OpDeleteKey, label, func(r *approval.Request) bool {
+		reqID, _ := hex.DecodeString(r.Params["id"])
+		if len(reqID) == 0 {
+			return len(left) == 0
+		}
+		return bytes.Equal(reqID, id)
+	}, actor, "key destroyed")
+}
+
+func (s Server) GetKeyState(ctx context.Context, req *GetKeyStateRequest) (*KeyStateResponse, error) {
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.lifecycle.Record(label, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key state: %v", err)
+	}
+
+	return keyStateResponse(r), nil
+}
+
+func keyStateResponse(r *lifecycle.Record) *KeyStateResponse {
+	res := &KeyStateResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		State:        string(r.State),
+	}
+	for _, t := range r.History {
+		res.History = append(res.History, &KeyStateTransition{
+			From:   string(t.From),
+			To:     string(t.To),
+			Actor:  t.Actor,
+			Reason: t.Reason,
+			Time:   t.Time.Format(time.RFC3339),
+		})
+	}
+	return res
+}
@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const defaultMacLength = 8
+
+func (s Server) GenerateMac(ctx context.Context, req *GenerateMacRequest) (*GenerateMacResponse, error) {
+	data, err := base64.StdEncoding.DecodeString(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request data: %v", err)
+	}
+
+	macLength := int(req.MacLength)
+	if macLength == 0 {
+		macLength = defaultMacLength
+	}
+	if macLength < hsm_api.MinMacLength || macLength > defaultMacLength {
+		return nil, fmt.Errorf("invalid mac length: %d", macLength)
+	}
+
+	obj, err := s.key(ctx, pkcs11.CKO_SECRET_KEY, req.KeyLabel, req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lifecycle.Check(obj, lifecycle.Protect); err != nil {
+		return nil, keyError("failed to generate mac", err)
+	}
+	if err := s.checkMacPolicy(obj, int(req.Algorithm)); err != nil {
+		return nil, keyError("failed to generate mac", err)
+	}
+	// the PIN keys are DES keys too, the MAC of chosen data would be their encryption
+	if err := s.policy.CheckPurpose(obj, ""); err != nil {
+		return nil, keyError("failed to generate mac", err)
+	}
+
+	mac, err := hsm_api.GenerateMac(s.ctx, s.ss, obj, int(req.Algorithm), int(req.Padding), data)
+	if err != nil {
+		return nil, keyError("failed to generate mac", err)
+	}
+
+	return &GenerateMacResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Mac:          hex.EncodeToString(mac[:macLength]),
+	}, nil
+}
+
+func (s Server) VerifyMac(ctx context.Context, req *VerifyMacRequest) (*VerifyMacResponse, error) {
+	data, err := base64.StdEncoding.DecodeString(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request data: %v", err)
+	}
+
+	mac, err := hex.DecodeString(req.Mac)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request mac: %v", err)
+	}
+
+	obj, err := s.key(ctx, pkcs11.CKO_SECRET_KEY, req.KeyLabel, req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lifecycle.Check(obj, lifecycle.Process); err != nil {
+		return nil, keyError("failed to verify mac", err)
+	}
+	if err := s.checkMacPolicy(obj, int(req.Algorithm)); err != nil {
+		return nil, keyError("failed to verify mac", err)
+	}
+	// the PIN keys are DES keys too, the MAC of chosen data would be their encryption
+	if err := s.policy.CheckPurpose(obj, ""); err != nil {
+		return nil, keyError("failed to verify mac", err)
+	}
+
+	verified, err := hsm_api.VerifyMac(s.ctx, s.ss, obj, int(req.Algorithm), int(req.Padding), data, mac)
+	if err != nil {
+		return nil, keyError("failed to verify mac", err)
+	}
+
+	return &VerifyMacResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Verified:     verified,
+	}, nil
+}
+
+// checkMacPolicy checks the key policy against the mechanism of the mac algorithm, the
+// mac is computed by encryption for both generation and verification.
+func (s Server) checkMacPolicy(key pkcs11.ObjectHandle, algorithm int) error {
+	keyType, err := hsm_api.GetKeyType(s.ctx, s.ss, key)
+	if err != nil {
+		return err
+	}
+	mech, err := hsm_api.MacMechanism(keyType, algorithm)
+	if err != nil {
+		return err
+	}
+	return s.policy.Check(key, pkcs11.CKA_ENCRYPT, mech)
+}
@@ -0,0 +1,209 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"hsm/pkg/audit"
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/metadata"
+	"hsm/pkg/tenant"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func (s Server) GetKeyMetadata(ctx context.Context, req *GetKeyMetadataRequest) (*KeyMetadataResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.keyID(label, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := s.metadata.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("no metadata for key %s", req.Label)
+	}
+
+	return &KeyMetadataResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Metadata:     keyMetadata(e),
+	}, nil
+}
+
+func (s Server) SetKeyMetadata(ctx context.Context, req *SetKeyMetadataRequest) (*KeyMetadataResponse, error) {
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.keyID(label, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.putMetadata(label, id, req.Metadata, auth.Caller(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyMetadataResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Metadata:     keyMetadata(e),
+	}, nil
+}
+
+// ListKeyMetadata only lists the entries of the keys in the namespace of the caller.
+func (s Server) ListKeyMetadata(ctx context.Context, req *ListKeyMetadataRequest) (*ListKeyMetadataResponse, error) {
+	entries := s.metadata.List(metadata.Filter{Tenant: req.Tenant, Owner: req.Owner, Tag: req.Tag})
+
+	list := make([]*KeyMetadata, 0, len(entries))
+	for _, e := range entries {
+		if tenant.Owns(auth.Tenant(ctx), e.Label) {
+			list = append(list, keyMetadata(e))
+		}
+	}
+
+	return &ListKeyMetadataResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Metadata:     list,
+	}, nil
+}
+
+// ReconcileKeyMetadata only reports the differences in the namespace of the caller,
+// nothing is created or deleted.
+func (s Server) ReconcileKeyMetadata(ctx context.Context, req *ReconcileKeyMetadataRequest) (*ReconcileKeyMetadataResponse, error) {
+	r, err := s.metadata.Reconcile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile metadata: %v", err)
+	}
+
+	entries := make([]*KeyMetadata, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		if tenant.Owns(auth.Tenant(ctx), e.Label) {
+			entries = append(entries, keyMetadata(e))
+		}
+	}
+
+	return &ReconcileKeyMetadataResponse{
+		ErrorCode:        "0000",
+		ErrorMessage:     "success",
+		OrphanedKeys:     s.keyInfos(tenant.Keys(auth.Tenant(ctx), r.Objects), nil),
+		OrphanedMetadata: entries,
+	}, nil
+}
+
+// keyID returns the CKA_ID of the keys with the label, the id of the request must be one
+// of them and can be left out when they all share one.
+func (s Server) keyID(label, reqID string) ([]byte, error) {
+	id, err := hex.DecodeString(reqID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+
+	infos, err := hsm_api.ListKeys(s.ctx, s.ss, "", label, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key: %v", err)
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("failed to find key: not found key")
+	}
+
+	if len(id) > 0 {
+		for _, info := range infos {
+			if bytes.Equal(info.ID, id) {
+				return id, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to find key: not found key")
+	}
+
+	id = infos[0].ID
+	for _, info := range infos[1:] {
+		if !bytes.Equal(info.ID, id) {
+			return nil, fmt.Errorf("keys with label %s have several ids, set the id", label)
+		}
+	}
+	if len(id) == 0 {
+		return nil, fmt.Errorf("key %s has no id for metadata", label)
+	}
+	return id, nil
+}
+
+// putMetadata stores the metadata of the request for the key and audits the change.
+func (s Server) putMetadata(label string, id []byte, m *KeyMetadata, actor string) (*metadata.Entry, error) {
+	if m == nil {
+		m = &KeyMetadata{}
+	}
+
+	e, err := s.metadata.Put(&metadata.Entry{
+		ID:             hex.EncodeToString(id),
+		Label:          label,
+		Owner:          m.Owner,
+		Description:    m.Description,
+		Tags:           m.Tags,
+		Tenant:         tenant.Of(label),
+		RotationPolicy: m.RotationPolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set key metadata: %v", err)
+	}
+
+	s.audit.Log(audit.Event{
+		Actor:  actor,
+		Action: "set-metadata",
+		Key:    label,
+		Detail: map[string]string{"id": e.ID, "owner": e.Owner, "tenant": e.Tenant},
+	})
+	return e, nil
+}
+
+// dropMetadata removes the entries of the ids no token key uses anymore.
+func (s Server) dropMetadata(ids [][]byte) error {
+	for _, id := range ids {
+		if len(id) == 0 {
+			continue
+		}
+		left, err := hsm_api.FindObjects(s.ctx, s.ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		})
+		if err != nil {
+			return err
+		}
+		if len(left) > 0 {
+			continue
+		}
+		if err := s.metadata.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func keyMetadata(e *metadata.Entry) *KeyMetadata {
+	if e == nil {
+		return nil
+	}
+	return &KeyMetadata{
+		Id:             e.ID,
+		Label:          tenant.Local(e.Tenant, e.Label),
+		Owner:          e.Owner,
+		Description:    e.Description,
+		Tags:           e.Tags,
+		Tenant:         e.Tenant,
+		RotationPolicy: e.RotationPolicy,
+		CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      e.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/inventory"
+	"hsm/pkg/tenant"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultPageSize = 100
+
+// FindObjects searches token and session objects of the namespace of the caller, the page
+// token is the offset of the next page in the objects ordered by handle.
+func (s Server) FindObjects(ctx context.Context, req *FindObjectsRequest) (*FindObjectsResponse, error) {
+	caller := auth.Tenant(ctx)
+	f := hsm_api.ObjectFilter{
+		Class:      req.ObjectClass,
+		KeyType:    req.KeyType,
+		Usage:      req.Usage,
+		LabelMatch: func(label string) bool { return tenant.Owns(caller, label) },
+	}
+
+	if req.Label != "" {
+		label, err := s.label(ctx, req.Label)
+		if err != nil {
+			return nil, err
+		}
+		f.Label = label
+	}
+
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	f.ID = id
+
+	switch req.Token {
+	case "":
+	case "token", "session":
+		token := req.Token == "token"
+		f.Token = &token
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "token must be token or session, got %s", req.Token)
+	}
+
+	offset := 0
+	if req.PageToken != "" {
+		if offset, err = strconv.Atoi(req.PageToken); err != nil || offset < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %s", req.PageToken)
+		}
+	}
+	size := int(req.PageSize)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+
+	objs, total, err := hsm_api.SearchObjects(s.ctx, s.ss, f, offset, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find objects: %v", err)
+	}
+
+	objects := make([]*ObjectInfo, 0, len(objs))
+	for _, o := range objs {
+		objects = append(objects, s.objectInfo(o))
+	}
+
+	next := ""
+	if offset+len(objs) < total {
+		next = strconv.Itoa(offset + len(objs))
+	}
+
+	return &FindObjectsResponse{
+		ErrorCode:     "0000",
+		ErrorMessage:  "success",
+		Objects:       objects,
+		NextPageToken: next,
+		Total:         int32(total),
+	}, nil
+}
+
+func (s Server) objectInfo(o *hsm_api.Object) *ObjectInfo {
+	info := &ObjectInfo{
+		ObjectClass: o.Class,
+		Label:       tenant.Local(tenant.Of(o.Label), o.Label),
+		Id:          hex.EncodeToString(o.ID),
+		Token:       o.Token,
+		Private:     o.Private,
+		Modifiable:  o.Modifiable,
+		Application: o.Application,
+	}
+	if o.Key != nil {
+		info.Key = s.keyInfo(o.Key)
+	}
+	return info
+}
+
+// GetInventory reports the objects of the slot in the namespace of the caller with their findings.
+func (s Server) GetInventory(ctx context.Context, req *GetInventoryRequest) (*GetInventoryResponse, error) {
+	r, err := inventory.Take(s.ctx, s.ss, s.conf.HSM.SlotID)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := auth.Tenant(ctx)
+	items := []*InventoryItem{}
+	risky := 0
+	for _, item := range r.Items {
+		if !tenant.Owns(caller, item.Label) {
+			continue
+		}
+		if len(item.Findings) > 0 {
+			risky++
+		}
+		items = append(items, &InventoryItem{
+			ObjectClass: item.Class,
+			KeyType:     item.KeyType,
+			Bits:        int32(item.Bits),
+			Label:       tenant.Local(caller, item.Label),
+			Id:          item.ID,
+			Token:       item.Token,
+			Sensitive:   item.Sensitive,
+			Extractable: item.Extractable,
+			Local:       item.Local,
+			StartDate:   item.StartDate,
+			EndDate:     item.EndDate,
+			Application: item.Application,
+			Findings:    item.Findings,
+		})
+	}
+
+	return &GetInventoryResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Slot:         uint32(r.Slot),
+		Items:        items,
+		Risky:        int32(risky),
+	}, nil
+}
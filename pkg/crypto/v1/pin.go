@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/lifecycle"
+	"hsm/pkg/policy"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	pinMethodIBM3624 = "IBM3624"
+	pinMethodVisaPVV = "VISA_PVV"
+)
+
+func (s Server) VerifyPin(ctx context.Context, req *VerifyPinRequest) (*VerifyPinResponse, error) {
+	pin, pvk, err := s.pinAndPVK(ctx, req.ZpkLabel, req.ZpkId, req.PvkLabel, req.PvkId, req.PinBlock, req.Pan, lifecycle.Process)
+	if err != nil {
+		return nil, err
+	}
+
+	var verified bool
+	switch req.Method {
+	case pinMethodIBM3624:
+		verified, err = hsm_api.VerifyPinOffset(s.ctx, s.ss, pvk, pin, req.Offset, req.ValidationData, req.DecimalizationTable)
+	case pinMethodVisaPVV:
+		verified, err = hsm_api.VerifyPVV(s.ctx, s.ss, pvk, req.Pan, int(req.Pvki), pin, req.Pvv)
+	default:
+		return nil, fmt.Errorf("unsupported pin verification method: %s", req.Method)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify pin: %v", err)
+	}
+
+	return &VerifyPinResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Verified:     verified,
+	}, nil
+}
+
+func (s Server) GeneratePinOffset(ctx context.Context, req *GeneratePinOffsetRequest) (*GeneratePinOffsetResponse, error) {
+	pin, pvk, err := s.pinAndPVK(ctx, req.ZpkLabel, req.ZpkId, req.PvkLabel, req.PvkId, req.PinBlock, req.Pan, lifecycle.Protect)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := hsm_api.GeneratePinOffset(s.ctx, s.ss, pvk, pin, req.ValidationData, req.DecimalizationTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pin offset: %v", err)
+	}
+
+	return &GeneratePinOffsetResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Offset:       offset,
+	}, nil
+}
+
+func (s Server) GeneratePVV(ctx context.Context, req *GeneratePVVRequest) (*GeneratePVVResponse, error) {
+	pin, pvk, err := s.pinAndPVK(ctx, req.ZpkLabel, req.ZpkId, req.PvkLabel, req.PvkId, req.PinBlock, req.Pan, lifecycle.Protect)
+	if err != nil {
+		return nil, err
+	}
+
+	pvv, err := hsm_api.GeneratePVV(s.ctx, s.ss, pvk, req.Pan, int(req.Pvki), pin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pvv: %v", err)
+	}
+
+	return &GeneratePVVResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Pvv:          pvv,
+	}, nil
+}
+
+// pinAndPVK decrypts the PIN block under the zpk and finds the PIN verification key,
+// the pvk is used for the purpose and the zpk always processes. Both keys must have been
+// created for the PIN processing, see policy.PurposePINEncryption.
+func (s Server) pinAndPVK(ctx context.Context, zpkLabel, zpkID, pvkLabel, pvkID, pinBlock, pan string, purpose lifecycle.Purpose) (string, pkcs11.ObjectHandle, error) {
+	block, err := hex.DecodeString(pinBlock)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to decode request pinBlock: %v", err)
+	}
+
+	zpk, err := s.key(ctx, pkcs11.CKO_SECRET_KEY, zpkLabel, zpkID)
+	if err != nil {
+		return "", 0, err
+	}
+	pvk, err := s.key(ctx, pkcs11.CKO_SECRET_KEY, pvkLabel, pvkID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := s.policy.CheckPurpose(zpk, policy.PurposePINEncryption); err != nil {
+		return "", 0, keyError("zpk", err)
+	}
+	if err := s.policy.CheckPurpose(pvk, policy.PurposePINVerification); err != nil {
+		return "", 0, keyError("pvk", err)
+	}
+	if err := s.lifecycle.Check(zpk, lifecycle.Process); err != nil {
+		return "", 0, keyError("zpk", err)
+	}
+	if err := s.lifecycle.Check(pvk, purpose); err != nil {
+		return "", 0, keyError("pvk", err)
+	}
+	if err := s.checkPinPolicy(zpk, pkcs11.CKA_DECRYPT); err != nil {
+		return "", 0, keyError("zpk", err)
+	}
+	if err := s.checkPinPolicy(pvk, pkcs11.CKA_ENCRYPT); err != nil {
+		return "", 0, keyError("pvk", err)
+	}
+
+	pin, err := hsm_api.DecryptPinBlock(s.ctx, s.ss, zpk, block, pan)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to decrypt pin block: %v", err)
+	}
+
+	return pin, pvk, nil
+}
+
+// checkPinPolicy checks the key policy against the DES/3DES ECB mechanism of the PIN
+// processing, the zpk decrypts the PIN block and the pvk encrypts the validation data.
+func (s Server) checkPinPolicy(key pkcs11.ObjectHandle, usage uint) error {
+	keyType, err := hsm_api.GetKeyType(s.ctx, s.ss, key)
+	if err != nil {
+		return err
+	}
+	mech, err := hsm_api.PinMechanism(keyType)
+	if err != nil {
+		return err
+	}
+	return s.policy.Check(key, usage, mech)
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/jwk"
+)
+
+// GetPublicKey exports the public key of an RSA or EC key pair as PEM, DER and JWK. The
+// public key object is read, an RSA private key alone is enough.
+func (s Server) GetPublicKey(ctx context.Context, req *GetPublicKeyRequest) (*GetPublicKeyResponse, error) {
+	id, err := hex.DecodeString(req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	label, err := s.label(ctx, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *hsm_api.KeyInfo
+	for _, class := range []string{"public", "private"} {
+		infos, err := hsm_api.ListKeys(s.ctx, s.ss, class, label, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find key: %v", err)
+		}
+		for _, info := range infos {
+			if len(id) == 0 || hex.EncodeToString(info.ID) == req.Id {
+				key = info
+				break
+			}
+		}
+		if key != nil {
+			break
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("failed to find key: not found key")
+	}
+
+	pub, err := hsm_api.PublicKey(s.ctx, s.ss, key.Handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %v", err)
+	}
+	keyID, err := hsm_api.PublicKeyID(pub)
+	if err != nil {
+		return nil, err
+	}
+	k, err := jwk.New(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetPublicKeyResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		KeyId:        keyID,
+		Thumbprint:   k.Kid,
+		Pem:          string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Der:          base64.StdEncoding.EncodeToString(der),
+		Jwk: &JWK{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Crv: k.Crv,
+			X:   k.X,
+			Y:   k.Y,
+			N:   k.N,
+			E:   k.E,
+		},
+	}, nil
+}
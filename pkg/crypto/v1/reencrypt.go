@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxReEncryptBatch bounds the work, and the time on the shared session, of one batch.
+const maxReEncryptBatch = 1000
+
+func (s Server) ReEncrypt(ctx context.Context, req *ReEncryptRequest) (*ReEncryptResponse, error) {
+	cipherText, err := s.reEncrypt(ctx, req.SourceType, req.DestinationType, req.Algorithm, req.CipherText)
+	if err != nil {
+		return nil, keyError("failed to re-encrypt", err)
+	}
+
+	return &ReEncryptResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		CipherText:   cipherText,
+	}, nil
+}
+
+func (s Server) ReEncryptBatch(ctx context.Context, req *ReEncryptBatchRequest) (*ReEncryptBatchResponse, error) {
+	if len(req.CipherTexts) > maxReEncryptBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d cipher texts per batch, got %d", maxReEncryptBatch, len(req.CipherTexts))
+	}
+
+	res := &ReEncryptBatchResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Results:      make([]*ReEncryptResult, len(req.CipherTexts)),
+	}
+
+	for i, cipherText := range req.CipherTexts {
+		out, err := s.reEncrypt(ctx, req.SourceType, req.DestinationType, req.Algorithm, cipherText)
+		if err != nil {
+			res.Results[i] = &ReEncryptResult{ErrorMessage: err.Error()}
+			continue
+		}
+		res.Results[i] = &ReEncryptResult{CipherText: out}
+	}
+
+	return res, nil
+}
+
+// reEncrypt decrypts and encrypts again inside the service, the plain text is wiped
+// before returning. The key types are resolved like the ones of Decrypt and Encrypt, so
+// the aliases that only decrypt or encrypt hold here too. An empty algorithm keeps the
+// one of the destination alias, or else of the cipher text.
+func (s Server) reEncrypt(ctx context.Context, source, destination, algorithm, cipherText string) (string, error) {
+	if destination == "" {
+		destination = source
+	}
+
+	in, err := openEnvelope(cipherText)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode cipherText: %v", err)
+	}
+
+	sourceRing, err := s.decryptionKey(ctx, source, in.algorithm)
+	if err != nil {
+		return "", err
+	}
+	destinationRing, algorithm, err := s.encryptionKey(ctx, destination, algorithm)
+	if err != nil {
+		return "", err
+	}
+	if algorithm == "" {
+		algorithm = in.algorithm
+	}
+
+	plainText, err := s.decrypt(sourceRing, in)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	defer func() {
+		for i := range plainText {
+			plainText[i] = 0
+		}
+	}()
+
+	out, err := s.encrypt(destinationRing, algorithm, plainText)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+
+	return out.seal(), nil
+}
@@ -3,17 +3,36 @@ package crypto
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"hsm/configs"
+	"hsm/pkg/approval"
+	"hsm/pkg/audit"
+	"hsm/pkg/auth"
+	"hsm/pkg/byok"
+	"hsm/pkg/capability"
+	"hsm/pkg/ceremony"
+	"hsm/pkg/keyalias"
+	"hsm/pkg/keytemplate"
+	"hsm/pkg/lifecycle"
+	"hsm/pkg/metadata"
+	"hsm/pkg/policy"
+	"hsm/pkg/rotation"
+	"hsm/pkg/tenant"
+	"hsm/pkg/tokenadmin"
 	"log"
 	"net"
 	"net/http"
+	"strings"
 
 	hsm_api "hsm/pkg/hsm-api"
 
 	"github.com/gemalto/pkcs11"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -21,10 +40,54 @@ type (
 		conf *configs.Config
 		ctx  *pkcs11.Ctx
 		ss   pkcs11.SessionHandle
+
+		ceremony  *ceremony.Manager
+		auth      *auth.Authorizer
+		audit     *audit.Logger
+		lifecycle *lifecycle.Manager
+		policy    *policy.Enforcer
+		rotation  *rotation.Scheduler
+		// session of the rotation scheduler
+		rotationSS pkcs11.SessionHandle
+		byok       *byok.Manager
+		metadata   *metadata.Store
+		approvals  *approval.Manager
+		tokens     *tokenadmin.Admin
+		templates  map[string]*hsm_api.KeyTemplate
+		aliases    map[string]*keyalias.Alias
 		UnimplementedCryptoServer
+		UnimplementedKeyCeremonyServer
+		UnimplementedKeyManagementServer
+		UnimplementedTokenAdminServer
 	}
 )
 
+// the keys of the data operations are those of the tenant of the token or the global ones
+// for a token without tenant
+var cryptoRoles = map[string][]string{
+	"/crypto.Crypto/Encrypt":           {auth.RoleData},
+	"/crypto.Crypto/Decrypt":           {auth.RoleData},
+	"/crypto.Crypto/ReEncrypt":         {auth.RoleData},
+	"/crypto.Crypto/ReEncryptBatch":    {auth.RoleData},
+	"/crypto.Crypto/GenerateMac":       {auth.RoleMAC},
+	"/crypto.Crypto/VerifyMac":         {auth.RoleMAC},
+	"/crypto.Crypto/VerifyPin":         {auth.RolePIN},
+	"/crypto.Crypto/GeneratePinOffset": {auth.RolePIN},
+	"/crypto.Crypto/GeneratePVV":       {auth.RolePIN},
+	"/crypto.Crypto/GetKeyCheckValue":  {auth.RoleKeyAdmin, auth.RoleKeyReader},
+}
+
+// roles returns the rules of the methods of all services.
+func roles() map[string][]string {
+	rules := map[string][]string{}
+	for _, roles := range []map[string][]string{cryptoRoles, ceremonyRoles, keyManagementRoles, tokenAdminRoles} {
+		for method, r := range roles {
+			rules[method] = r
+		}
+	}
+	return rules
+}
+
 func NewServer(conf *configs.Config) Server {
 	// init hsm
 	ctx, err := hsm_api.GetContext(conf.ModulePath)
@@ -37,16 +100,88 @@ func NewServer(conf *configs.Config) Server {
 		panic(err)
 	}
 
-	return Server{
-		conf: conf,
-		ctx:  ctx,
-		ss:   ss,
+	for _, t := range conf.Auth.Tokens {
+		if err := tenant.Validate(t.Tenant); err != nil {
+			panic(err)
+		}
+	}
+
+	templates, err := keytemplate.Load(conf.KeyTemplates)
+	if err != nil {
+		panic(err)
+	}
+
+	aliases, err := keyalias.Load(conf.KeyAliases)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := ceremony.CheckRotation(conf); err != nil {
+		panic(err)
+	}
+
+	// refuse a configuration the token cannot serve
+	session, err := ctx.GetSessionInfo(ss)
+	if err != nil {
+		panic(err)
+	}
+	mechs, err := hsm_api.GetMechanisms(ctx, session.SlotID)
+	if err != nil {
+		panic(err)
+	}
+	if err := capability.Check(mechs, capability.Needs(conf, templates, aliases)); err != nil {
+		panic(err)
 	}
+
+	store, err := metadata.NewStore(conf, ctx, ss)
+	if err != nil {
+		panic(err)
+	}
+
+	auditLog := audit.NewLogger(conf.Audit.Path)
+
+	approvals, err := approval.NewManager(conf, auditLog)
+	if err != nil {
+		panic(err)
+	}
+
+	s := Server{
+		conf:      conf,
+		ctx:       ctx,
+		ss:        ss,
+		ceremony:  ceremony.NewManager(conf, ctx, ss),
+		auth:      auth.New(conf.Auth.Tokens, roles()),
+		audit:     auditLog,
+		lifecycle: lifecycle.NewManager(ctx, ss, auditLog),
+		policy:    policy.NewEnforcer(ctx, ss, auditLog),
+		byok:      byok.NewManager(conf, ctx, ss, auditLog),
+		metadata:  store,
+		approvals: approvals,
+		tokens:    tokenadmin.New(ctx, &conf.HSM, ss),
+		templates: templates,
+		aliases:   aliases,
+	}
+
+	// the scheduler runs beside the requests, it gets a session of its own
+	rss, err := hsm_api.OpenSession(ctx, ss)
+	if err != nil {
+		panic(err)
+	}
+	rs := s
+	rs.ss = rss
+	rs.policy = policy.NewEnforcer(ctx, rss, auditLog)
+	s.rotationSS = rss
+	s.rotation = rotation.NewScheduler(conf, ctx, rss, rs.rotateKey, auditLog)
+	approvals.Handle(approval.OpDeleteKey, s.deleteApproved)
+	approvals.Handle(approval.OpExportKeys, s.exportApproved)
+	approvals.Handle(approval.OpChangePIN, s.changePINApproved)
+	return s
 }
 
 func (s Server) Start() {
 	go s.StartGRPC()
 	go s.StartHTTP()
+	s.rotation.Start()
 }
 
 func (s Server) StartGRPC() {
@@ -55,8 +190,11 @@ func (s Server) StartGRPC() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	g := grpc.NewServer()
+	g := grpc.NewServer(grpc.UnaryInterceptor(s.auth.UnaryInterceptor))
 	RegisterCryptoServer(g, s)
+	RegisterKeyCeremonyServer(g, s)
+	RegisterKeyManagementServer(g, s)
+	RegisterTokenAdminServer(g, s)
 
 	log.Printf("start grpc at port: %s", s.conf.Servers.GRPC.Port)
 	if err := g.Serve(lis); err != nil {
@@ -68,9 +206,16 @@ func (s Server) StartHTTP() {
 	mux := runtime.NewServeMux()
 	opts := []grpc.DialOption{grpc.WithInsecure()}
 
-	err := RegisterCryptoHandlerFromEndpoint(context.Background(), mux, fmt.Sprintf(":%s", s.conf.Servers.GRPC.Port), opts)
-	if err != nil {
-		panic(err)
+	endpoint := fmt.Sprintf(":%s", s.conf.Servers.GRPC.Port)
+	for _, register := range []func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error{
+		RegisterCryptoHandlerFromEndpoint,
+		RegisterKeyCeremonyHandlerFromEndpoint,
+		RegisterKeyManagementHandlerFromEndpoint,
+		RegisterTokenAdminHandlerFromEndpoint,
+	} {
+		if err := register(context.Background(), mux, endpoint, opts); err != nil {
+			panic(err)
+		}
 	}
 
 	log.Printf("start http at port: %s", s.conf.Servers.HTTP.Port)
@@ -80,6 +225,8 @@ func (s Server) StartHTTP() {
 }
 
 func (s Server) Stop() {
+	s.rotation.Stop()
+	s.ctx.CloseSession(s.rotationSS)
 	hsm_api.FinishSession(s.ctx, s.ss)
 	if s.ctx != nil {
 		hsm_api.FinishContext(s.ctx)
@@ -94,54 +241,89 @@ func (s Server) Encrypt(ctx context.Context, req *EncryptRequest) (*EncryptRespo
 	}
 	log.Printf("plain text after decode: %s", string(plainText))
 
-	// encrypt
-	cipher, err := s.encrypt(pkcs11.CKO_SECRET_KEY, s.conf.HSM.N2kLabel, plainText)
+	ring, algorithm, err := s.encryptionKey(ctx, req.Type, req.Algorithm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt: %v", err)
+		return nil, keyError("failed to encrypt", err)
 	}
 
-	// encode cipher before response back
-	eCipher := base64.StdEncoding.EncodeToString(cipher)
+	// encrypt
+	e, err := s.encrypt(ring, algorithm, plainText)
+	if err != nil {
+		return nil, keyError("failed to encrypt", err)
+	}
 
 	return &EncryptResponse{
 		ErrorCode:    "0000",
 		ErrorMessage: "success",
-		CipherText:   eCipher,
+		CipherText:   e.seal(),
 	}, nil
 }
 
-// encrypt with pre-generated iv then append the iv to the output.
-func (s Server) encrypt(keyClass uint, keyLabel string, plainText []byte) ([]byte, error) {
-	obj, err := hsm_api.FindKeys(s.ctx, s.ss, keyClass, keyLabel)
+// encrypt with the primary version of the key ring. CBC uses a pre-generated iv
+// prepended to the output, an empty algorithm is CBC.
+func (s Server) encrypt(ring, algorithm string, plainText []byte) (envelope, error) {
+	key, err := hsm_api.PrimaryKeyVersion(s.ctx, s.ss, ring)
+	if err != nil {
+		return envelope{}, fmt.Errorf("failed to find key: %v", err)
+	}
+	if err := s.lifecycle.Check(key.Handle, lifecycle.Protect); err != nil {
+		return envelope{}, err
+	}
+
+	s.rotation.Count(ring, key.Version)
+
+	e := envelope{version: key.Version, algorithm: algorithmCBC}
+	switch strings.ToUpper(algorithm) {
+	case "", algorithmCBC:
+	case algorithmGCM:
+		if err := s.policy.Check(key.Handle, pkcs11.CKA_ENCRYPT, pkcs11.CKM_AES_GCM); err != nil {
+			return envelope{}, err
+		}
+		e.algorithm = algorithmGCM
+		e.data, err = hsm_api.EncryptGCM(s.ctx, s.ss, key.Handle, plainText, nil)
+		return e, err
+	default:
+		return envelope{}, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	mech, ivSize, err := s.cbcPadMechanism(key.Handle)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find key: %v", err)
+		return envelope{}, err
+	}
+	if err := s.policy.Check(key.Handle, pkcs11.CKA_ENCRYPT, mech); err != nil {
+		return envelope{}, err
 	}
 
-	iv := hsm_api.GenIV(s.conf.HSM.IVSize)
-	cipher, err := hsm_api.Encrypt(s.ctx, s.ss, obj[0], pkcs11.CKM_AES_CBC_PAD, plainText, iv)
+	iv := hsm_api.GenIV(ivSize)
+	cipher, err := hsm_api.Encrypt(s.ctx, s.ss, key.Handle, mech, plainText, iv)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt: %v", err)
+		return envelope{}, fmt.Errorf("failed to encrypt: %v", err)
 	}
 
 	// prepend iv to cipher
-	ret := make([]byte, len(iv)+len(cipher))
-	copy(ret[:len(iv)], iv)
-	copy(ret[len(iv):], cipher)
+	e.data = make([]byte, len(iv)+len(cipher))
+	copy(e.data[:len(iv)], iv)
+	copy(e.data[len(iv):], cipher)
 
-	return ret, nil
+	return e, nil
 }
 
 func (s Server) Decrypt(ctx context.Context, req *DecryptRequest) (*DecryptResponse, error) {
 	// decode cipher text
-	cipher, err := base64.StdEncoding.DecodeString(req.CipherText)
+	e, err := openEnvelope(req.CipherText)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode request cipherText: %v", err)
 	}
 
+	ring, err := s.decryptionKey(ctx, req.Type, e.algorithm)
+	if err != nil {
+		return nil, keyError("failed to decrypt", err)
+	}
+
 	// decrypt
-	plainText, err := s.decrypt(pkcs11.CKO_SECRET_KEY, s.conf.HSM.N2kLabel, cipher)
+	plainText, err := s.decrypt(ring, e)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt: %v", err)
+		return nil, keyError("failed to decrypt", err)
 	}
 
 	// encode plain text before response
@@ -154,20 +336,141 @@ func (s Server) Decrypt(ctx context.Context, req *DecryptRequest) (*DecryptRespo
 	}, nil
 }
 
-// extract the iv from cipher and decrypt.
-func (s Server) decrypt(keyClass uint, keyLabel string, cipher []byte) ([]byte, error) {
-	obj, err := hsm_api.FindKeys(s.ctx, s.ss, keyClass, keyLabel)
+// extract the iv from cipher and decrypt with the version of the key ring.
+func (s Server) decrypt(ring string, e envelope) ([]byte, error) {
+	key, err := hsm_api.FindKeyVersion(s.ctx, s.ss, ring, e.version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lifecycle.Check(key.Handle, lifecycle.Process); err != nil {
+		return nil, err
+	}
+
+	if e.algorithm == algorithmGCM {
+		if err := s.policy.Check(key.Handle, pkcs11.CKA_DECRYPT, pkcs11.CKM_AES_GCM); err != nil {
+			return nil, err
+		}
+		return hsm_api.DecryptGCM(s.ctx, s.ss, key.Handle, e.data, nil)
+	}
+
+	mech, ivSize, err := s.cbcPadMechanism(key.Handle)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.policy.Check(key.Handle, pkcs11.CKA_DECRYPT, mech); err != nil {
+		return nil, err
+	}
 
 	// extract iv and cipher
-	iv := cipher[:s.conf.HSM.IVSize]
-	c := cipher[s.conf.HSM.IVSize:]
-	plain, err := hsm_api.Decrypt(s.ctx, s.ss, obj[0], pkcs11.CKM_AES_CBC_PAD, c, iv)
+	if len(e.data) <= ivSize {
+		return nil, fmt.Errorf("cipher is too short")
+	}
+	iv := e.data[:ivSize]
+	c := e.data[ivSize:]
+	plain, err := hsm_api.Decrypt(s.ctx, s.ss, key.Handle, mech, c, iv)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt: %v", err)
 	}
 
 	return plain, nil
 }
+
+// cbcPadMechanism picks AES or 3DES CBC with padding from the key type,
+// AES keeps the iv size from config.
+func (s Server) cbcPadMechanism(key pkcs11.ObjectHandle) (uint, int, error) {
+	keyType, err := hsm_api.GetKeyType(s.ctx, s.ss, key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	mech, ivSize, err := hsm_api.CBCPadMechanism(keyType)
+	if err != nil {
+		return 0, 0, err
+	}
+	if keyType == pkcs11.CKK_AES && s.conf.HSM.IVSize > 0 {
+		ivSize = s.conf.HSM.IVSize
+	}
+
+	return mech, ivSize, nil
+}
+
+// label resolves a key label of the request in the namespace of the caller tenant, the
+// n2k key of a tenant is its own key with the same label.
+func (s Server) label(ctx context.Context, label string) (string, error) {
+	if label == "" {
+		return "", status.Error(codes.InvalidArgument, "missing key label")
+	}
+	l, err := tenant.Label(auth.Tenant(ctx), label)
+	if err != nil {
+		return "", status.Error(codes.NotFound, err.Error())
+	}
+	if s.internal(l) {
+		return "", status.Errorf(codes.PermissionDenied, "key %s is internal to the service", l)
+	}
+	return l, nil
+}
+
+// internal tells the labels of the keys the service keeps for itself, no call reaches them:
+// the metadata sealing key, the ceremony signing and transport keys, the BYOK wrapping keys
+// and the K1 keys of the retail MAC keys. Tenant labels are never internal.
+func (s Server) internal(label string) bool {
+	if s.conf == nil || tenant.Of(label) != "" {
+		return false
+	}
+	return label == metadata.KeyLabel(s.conf) || label == s.conf.Ceremony.SigningKey ||
+		strings.HasPrefix(label, ceremony.TransportKeyPrefix) || strings.HasPrefix(label, byok.WrappingKeyPrefix) ||
+		hsm_api.IsRetailMacKeyLabel(label)
+}
+
+// key resolves the key of the class named by the id of the request, by its label, or by
+// both. A key named by id alone must be in the namespace of the caller.
+func (s Server) key(ctx context.Context, class uint, label, id string) (pkcs11.ObjectHandle, error) {
+	keyID, err := hex.DecodeString(id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode request id: %v", err)
+	}
+	if label != "" || len(keyID) == 0 {
+		if label, err = s.label(ctx, label); err != nil {
+			return 0, err
+		}
+	}
+
+	obj, err := hsm_api.FindKey(s.ctx, s.ss, class, label, keyID)
+	if err != nil {
+		return 0, keyError("failed to find key", err)
+	}
+	if label == "" {
+		attrs, err := hsm_api.GetAttributes(s.ctx, s.ss, obj, pkcs11.CKA_LABEL)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find key: %v", err)
+		}
+		if !tenant.Owns(auth.Tenant(ctx), string(attrs[pkcs11.CKA_LABEL])) {
+			return 0, fmt.Errorf("failed to find key: not found key")
+		}
+		if s.internal(string(attrs[pkcs11.CKA_LABEL])) {
+			return 0, status.Errorf(codes.PermissionDenied, "key %s is internal to the service", attrs[pkcs11.CKA_LABEL])
+		}
+	}
+	return obj, nil
+}
+
+// keyError returns lifecycle and policy refusals with their own status code so clients
+// can tell a compromised or restricted key from other failures.
+func keyError(msg string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, lifecycle.ErrCompromised), errors.Is(err, policy.ErrNotAllowed), errors.Is(err, keyalias.ErrNotAllowed):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", msg, err)
+	case errors.Is(err, lifecycle.ErrNotUsable), errors.Is(err, hsm_api.ErrKeyVersionDestroyed),
+		errors.Is(err, hsm_api.ErrAmbiguousKey), errors.Is(err, hsm_api.ErrNoRetailMacKey):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, hsm_api.ErrDuplicateKey):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case errors.Is(err, hsm_api.ErrInvalidKeyID):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	}
+	return fmt.Errorf("%s: %v", msg, err)
+}
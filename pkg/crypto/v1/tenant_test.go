@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"context"
+	"testing"
+
+	"hsm/configs"
+	"hsm/pkg/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// sha256 of "cards-token", "loyalty-token" and "admin-token"
+var tenantTokens = []configs.APIToken{
+	{Name: "cards-admin", TokenHash: "f0f57901a377e5248fc0756e32066f719214d2cb7c1663963a095f475b51d3c0", Roles: []string{auth.RoleKeyAdmin, auth.RoleData}, Tenant: "cards"},
+	{Name: "loyalty-admin", TokenHash: "99d4413b0a329e987874513ead9b3376b8ecc56118bf8822863425f861c1724c", Roles: []string{auth.RoleKeyAdmin, auth.RoleData}, Tenant: "loyalty"},
+	{Name: "admin", TokenHash: "10a4c7c9fc5206d6f36dc6944a81bb6f4a3cb0e25014ae3b12e6c3e52712292a", Roles: []string{auth.RoleKeyAdmin, auth.RoleData}},
+}
+
+func TestLabel(t *testing.T) {
+	a := auth.New(tenantTokens, cryptoRoles)
+	conf := &configs.Config{}
+	conf.Ceremony.SigningKey = "ceremony-signing-key"
+	s := Server{conf: conf}
+
+	resolve := func(bearer, label string) (string, error) {
+		ctx := context.Background()
+		if bearer != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+bearer))
+		}
+		res, err := a.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/crypto.Crypto/Encrypt"},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.label(ctx, label)
+			})
+		if err != nil {
+			return "", err
+		}
+		return res.(string), nil
+	}
+
+	cases := []struct {
+		name   string
+		bearer string
+		label  string
+		want   string
+		code   codes.Code
+	}{
+		{"tenant key", "cards-token", "n2k-master-key", "cards:n2k-master-key", codes.OK},
+		{"other tenant key", "cards-token", "loyalty:n2k-master-key", "cards:loyalty:n2k-master-key", codes.OK},
+		{"other tenant", "loyalty-token", "n2k-master-key", "loyalty:n2k-master-key", codes.OK},
+		{"shared key", "admin-token", "n2k-master-key", "n2k-master-key", codes.OK},
+		{"tenant key without tenant", "admin-token", "cards:n2k-master-key", "", codes.NotFound},
+		{"without token", "", "n2k-master-key", "", codes.Unauthenticated},
+		{"metadata key", "admin-token", "metadata-key", "", codes.PermissionDenied},
+		{"ceremony signing key", "admin-token", "ceremony-signing-key", "", codes.PermissionDenied},
+		{"byok key", "admin-token", "byok-0123abcd", "", codes.PermissionDenied},
+		{"k1 key", "admin-token", "mac-key/k1", "", codes.PermissionDenied},
+		{"tenant key named like an internal one", "cards-token", "metadata-key", "cards:metadata-key", codes.OK},
+		{"missing label", "cards-token", "", "", codes.InvalidArgument},
+	}
+	for _, c := range cases {
+		got, err := resolve(c.bearer, c.label)
+		if status.Code(err) != c.code || got != c.want {
+			t.Errorf("%s: got %q, %v, want %q, %v", c.name, got, status.Code(err), c.want, c.code)
+		}
+	}
+}
+
+// the interceptor refuses the methods without roles, every RPC needs some
+func TestRoles(t *testing.T) {
+	rules := roles()
+	for _, desc := range []grpc.ServiceDesc{_Crypto_serviceDesc, _KeyCeremony_serviceDesc, _KeyManagement_serviceDesc, _TokenAdmin_serviceDesc} {
+		for _, m := range desc.Methods {
+			method := "/" + desc.ServiceName + "/" + m.MethodName
+			if len(rules[method]) == 0 {
+				t.Errorf("%s has no roles", method)
+			}
+		}
+	}
+
+	// approvals are only given by the approvers
+	if approve := rules["/crypto.KeyManagement/Approve"]; len(approve) != 1 || approve[0] != auth.RoleApprover {
+		t.Errorf("Approve: got roles %v, want %s", approve, auth.RoleApprover)
+	}
+}
@@ -0,0 +1,257 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hsm/pkg/approval"
+	"hsm/pkg/audit"
+	"hsm/pkg/auth"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/tokenadmin"
+
+	"github.com/gemalto/pkcs11"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// roles accepted by the token administration methods
+var tokenAdminRoles = map[string][]string{
+	"/crypto.TokenAdmin/InitToken": {auth.RoleTokenAdmin},
+	"/crypto.TokenAdmin/InitPIN":   {auth.RoleTokenAdmin},
+	"/crypto.TokenAdmin/SetPIN":    {auth.RoleTokenAdmin},
+	"/crypto.TokenAdmin/GetInfo":   {auth.RoleTokenAdmin, auth.RoleKeyAdmin, auth.RoleKeyReader},
+
+	"/crypto.TokenAdmin/ExportBackup": {auth.RoleTokenAdmin},
+}
+
+func (s Server) InitToken(ctx context.Context, req *InitTokenRequest) (*InitTokenResponse, error) {
+	if err := tokenCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	slot, err := s.tokens.InitToken(uint(req.SlotId), req.Label, req.SoPin)
+	if err != nil {
+		return nil, tokenError("failed to init token", err)
+	}
+	s.tokenEvent(auth.Caller(ctx), "token-init", req.Label, map[string]string{"slot": fmt.Sprint(slot)})
+
+	return &InitTokenResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		SlotId:       uint64(slot),
+		Label:        req.Label,
+	}, nil
+}
+
+func (s Server) InitPIN(ctx context.Context, req *InitPINRequest) (*InitPINResponse, error) {
+	if err := tokenCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.approvals.Guarded(approval.OpChangePIN) {
+		if err := tokenadmin.CheckPIN(s.conf.HSM.PinPolicy, req.Pin); err != nil {
+			return nil, tokenError("failed to init pin", err)
+		}
+		r, err := s.approvals.SubmitSecret(approval.OpChangePIN, req.Label, map[string]string{
+			"method": "InitPIN",
+		}, map[string]string{
+			"soPin": req.SoPin,
+			"pin":   req.Pin,
+		}, auth.Caller(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &InitPINResponse{
+			ErrorCode:    "0000",
+			ErrorMessage: "success",
+			Approval:     s.approvalRequest(r),
+		}, nil
+	}
+
+	if err := s.initPIN(req.Label, req.SoPin, req.Pin, auth.Caller(ctx)); err != nil {
+		return nil, tokenError("failed to init pin", err)
+	}
+
+	return &InitPINResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}, nil
+}
+
+func (s Server) SetPIN(ctx context.Context, req *SetPINRequest) (*SetPINResponse, error) {
+	if err := tokenCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	userType := req.UserType
+	if userType == "" {
+		userType = tokenadmin.UserTypeUser
+	}
+	if s.approvals.Guarded(approval.OpChangePIN) {
+		if err := tokenadmin.CheckPIN(s.conf.HSM.PinPolicy, req.NewPin); err != nil {
+			return nil, tokenError("failed to set pin", err)
+		}
+		r, err := s.approvals.SubmitSecret(approval.OpChangePIN, req.Label, map[string]string{
+			"method":   "SetPIN",
+			"userType": userType,
+		}, map[string]string{
+			"oldPin": req.OldPin,
+			"newPin": req.NewPin,
+		}, auth.Caller(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &SetPINResponse{
+			ErrorCode:    "0000",
+			ErrorMessage: "success",
+			Approval:     s.approvalRequest(r),
+		}, nil
+	}
+
+	if err := s.setPIN(req.Label, userType, req.OldPin, req.NewPin, auth.Caller(ctx)); err != nil {
+		return nil, tokenError("failed to set pin", err)
+	}
+
+	return &SetPINResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+	}, nil
+}
+
+func (s Server) initPIN(label, soPIN, pin, actor string) error {
+	if err := s.tokens.InitPIN(label, soPIN, pin); err != nil {
+		return err
+	}
+	s.tokenEvent(actor, "pin-init", label, nil)
+	return nil
+}
+
+func (s Server) setPIN(label, userType, oldPIN, newPIN, actor string) error {
+	err := s.tokens.SetPIN(label, userType, oldPIN, newPIN)
+	switch {
+	case errors.Is(err, tokenadmin.ErrPINNotSaved):
+		s.tokenEvent(actor, "pin-change", label, map[string]string{"userType": userType, "saved": "false"})
+	case err == nil:
+		s.tokenEvent(actor, "pin-change", label, map[string]string{"userType": userType})
+	}
+	return err
+}
+
+// changePINApproved is the executor of approved PIN changes.
+func (s Server) changePINApproved(r *approval.Request) error {
+	switch r.Params["method"] {
+	case "InitPIN":
+		return s.initPIN(r.Target, r.Secret("soPin"), r.Secret("pin"), r.Requester)
+	case "SetPIN":
+		return s.setPIN(r.Target, r.Params["userType"], r.Secret("oldPin"), r.Secret("newPin"), r.Requester)
+	}
+	return fmt.Errorf("unknown pin change %s", r.Params["method"])
+}
+
+// GetInfo reads the library, the slots with their token and the capabilities of the
+// mechanisms of the token.
+func (s Server) GetInfo(ctx context.Context, req *GetInfoRequest) (*GetInfoResponse, error) {
+	info, err := hsm_api.GetModuleInfo(s.ctx, uint(req.SlotId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get info: %v", err)
+	}
+	session, err := s.ctx.GetSessionInfo(s.ss)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session info: %v", err)
+	}
+
+	res := &GetInfoResponse{
+		ErrorCode:    "0000",
+		ErrorMessage: "success",
+		Library: &LibraryInfo{
+			CryptokiVersion: info.CryptokiVersion,
+			ManufacturerId:  info.ManufacturerID,
+			Description:     info.LibraryDescription,
+			Version:         info.LibraryVersion,
+		},
+		ServiceSlotId: uint64(session.SlotID),
+	}
+	for _, slot := range info.Slots {
+		res.Slots = append(res.Slots, slotInfo(slot))
+	}
+	return res, nil
+}
+
+func slotInfo(slot *hsm_api.SlotInfo) *SlotInfo {
+	res := &SlotInfo{
+		SlotId:         uint64(slot.ID),
+		Description:    slot.Description,
+		ManufacturerId: slot.ManufacturerID,
+		Flags:          slot.Flags,
+	}
+	if t := slot.Token; t != nil {
+		res.Token = &TokenInfo{
+			Label:              t.Label,
+			ManufacturerId:     t.ManufacturerID,
+			Model:              t.Model,
+			SerialNumber:       t.SerialNumber,
+			Flags:              t.Flags,
+			MaxSessionCount:    uint64(t.MaxSessionCount),
+			SessionCount:       uint64(t.SessionCount),
+			MaxRwSessionCount:  uint64(t.MaxRwSessionCount),
+			RwSessionCount:     uint64(t.RwSessionCount),
+			MinPinLen:          uint64(t.MinPinLen),
+			MaxPinLen:          uint64(t.MaxPinLen),
+			TotalPublicMemory:  uint64(t.TotalPublicMemory),
+			FreePublicMemory:   uint64(t.FreePublicMemory),
+			TotalPrivateMemory: uint64(t.TotalPrivateMemory),
+			FreePrivateMemory:  uint64(t.FreePrivateMemory),
+			HardwareVersion:    t.HardwareVersion,
+			FirmwareVersion:    t.FirmwareVersion,
+		}
+	}
+	for _, m := range slot.Mechanisms {
+		res.Mechanisms = append(res.Mechanisms, &MechanismInfo{
+			Name:       m.Name,
+			Type:       uint64(m.Type),
+			MinKeySize: uint64(m.MinKeySize),
+			MaxKeySize: uint64(m.MaxKeySize),
+			Flags:      m.FlagNames(),
+		})
+	}
+	return res
+}
+
+// tokenCaller refuses tenant tokens, a token holds the keys of every tenant.
+func tokenCaller(ctx context.Context) error {
+	if t := auth.Tenant(ctx); t != "" {
+		return status.Errorf(codes.PermissionDenied, "tenant %s cannot administer tokens", t)
+	}
+	return nil
+}
+
+func (s Server) tokenEvent(actor, action, label string, detail map[string]string) {
+	s.audit.Log(audit.Event{
+		Actor:  actor,
+		Action: action,
+		Key:    label,
+		Detail: detail,
+	})
+}
+
+func tokenError(msg string, err error) error {
+	var ckr pkcs11.Error
+	switch {
+	case errors.Is(err, tokenadmin.ErrPolicy):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	case errors.Is(err, hsm_api.ErrTokenNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, tokenadmin.ErrInitialized), errors.Is(err, tokenadmin.ErrLabelInUse):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case errors.Is(err, tokenadmin.ErrServiceLoggedIn), errors.Is(err, tokenadmin.ErrNoPINFile):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, tokenadmin.ErrPINNotSaved):
+		// the PIN did change, the caller must not retry with the old one
+		return status.Errorf(codes.DataLoss, "%s: %v", msg, err)
+	case errors.As(err, &ckr) && (ckr == pkcs11.CKR_PIN_INCORRECT || ckr == pkcs11.CKR_PIN_LOCKED):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", msg, err)
+	}
+	return fmt.Errorf("%s: %v", msg, err)
+}
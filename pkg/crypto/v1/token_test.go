@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hsm/configs"
+	"hsm/pkg/approval"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/tokenadmin"
+
+	"github.com/gemalto/pkcs11"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenErrorCodes(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("%w: at least 6 characters", tokenadmin.ErrPolicy), codes.InvalidArgument},
+		{fmt.Errorf("%w: test-hsm", hsm_api.ErrTokenNotFound), codes.NotFound},
+		{fmt.Errorf("%w: test-hsm", tokenadmin.ErrLabelInUse), codes.AlreadyExists},
+		{fmt.Errorf("%w: test-hsm", tokenadmin.ErrServiceLoggedIn), codes.FailedPrecondition},
+		{fmt.Errorf("%w: the new PIN of the service would be lost on restart", tokenadmin.ErrNoPINFile), codes.FailedPrecondition},
+		{fmt.Errorf("%w: write the new PIN to ./hsm.pin before the next restart", tokenadmin.ErrPINNotSaved), codes.DataLoss},
+		{fmt.Errorf("failed to login: %w", pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)), codes.PermissionDenied},
+		{fmt.Errorf("failed to open session"), codes.Unknown},
+	}
+	for _, c := range cases {
+		if got := status.Code(tokenError("failed to set pin", c.err)); got != c.code {
+			t.Errorf("%v: got %s, want %s", c.err, got, c.code)
+		}
+	}
+}
+
+// under dual control the pin changes and the backup wait for approvals, the token is not
+// touched before
+func TestDualControlTokenAdmin(t *testing.T) {
+	conf := configs.LoadConfig("../../../configs", "dev")
+	if conf == nil {
+		t.Fatal("failed to load dev config")
+	}
+	approvals, err := approval.NewManager(conf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Server{conf: conf, approvals: approvals}
+	ctx := context.Background()
+
+	set, err := s.SetPIN(ctx, &SetPINRequest{Label: "hsm", OldPin: "1234", NewPin: "new-PIN-5678"})
+	if err != nil || set.Approval == nil || set.Approval.Operation != approval.OpChangePIN {
+		t.Fatalf("set pin: got %+v, %v", set, err)
+	}
+	init, err := s.InitPIN(ctx, &InitPINRequest{Label: "hsm", SoPin: "so-1234", Pin: "new-PIN-5678"})
+	if err != nil || init.Approval == nil {
+		t.Fatalf("init pin: got %+v, %v", init, err)
+	}
+	for _, r := range []*ApprovalRequest{set.Approval, init.Approval} {
+		if r.Status != approval.StatusPending || r.Params["newPin"] != "" || r.Params["pin"] != "" {
+			t.Errorf("unexpected request %+v", r)
+		}
+	}
+
+	// the dev config has no custodian directories to write the shares to
+	if _, err := s.ExportBackup(ctx, &ExportBackupRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("backup without custodians: got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf.Backup.Dir = filepath.Join(dir, "bundles")
+	for i := 0; i < conf.Backup.Shares; i++ {
+		path := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.Mkdir(path, 0700); err != nil {
+			t.Fatal(err)
+		}
+		conf.Backup.Custodians = append(conf.Backup.Custodians, configs.ShareCustodian{Name: fmt.Sprint(i), Path: path})
+	}
+	res, err := s.ExportBackup(ctx, &ExportBackupRequest{})
+	if err != nil || res.Approval == nil || res.Approval.Params["shares"] != fmt.Sprint(conf.Backup.Shares) {
+		t.Fatalf("backup: got %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,157 @@
+package hsm_api
+
+import (
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// algorithms a tenant wraps its key material with, named as in the cloud KMS import APIs
+const (
+	WrapRSAOAEPSHA256       = "RSAES_OAEP_SHA_256"
+	WrapRSAAESKeyWrapSHA256 = "RSA_AES_KEY_WRAP_SHA_256"
+
+	// WrappingKeyBits is the size of the RSA wrapping keys
+	WrappingKeyBits = 3072
+)
+
+// GenerateWrappingKeyPair creates a session RSA key pair for key import, the private key
+// can only unwrap and never leaves the token.
+func GenerateWrappingKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	priv, pub, err := generateSessionKeyPair(ctx, ss, label, pkcs11.CKA_WRAP, pkcs11.CKA_UNWRAP)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to generate wrapping key: %v", err)
+	}
+	return priv, pub, nil
+}
+
+// GenerateTransportKeyPair creates a session RSA key pair clear values are encrypted to on
+// their way to the service, the private key can only decrypt and never leaves the token.
+func GenerateTransportKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	priv, pub, err := generateSessionKeyPair(ctx, ss, label, pkcs11.CKA_ENCRYPT, pkcs11.CKA_DECRYPT)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to generate transport key: %v", err)
+	}
+	return priv, pub, nil
+}
+
+// generateSessionKeyPair creates a session RSA key pair, the public key has the usage pubUsage
+// and the private key privUsage only.
+func generateSessionKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, pubUsage, privUsage uint) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	publicKeyTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pubUsage, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, WrappingKeyBits),
+	}
+	if pubUsage != pkcs11.CKA_ENCRYPT {
+		publicKeyTemplate = append(publicKeyTemplate, pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true))
+	}
+	privateKeyTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_UNWRAP, privUsage == pkcs11.CKA_UNWRAP),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, privUsage == pkcs11.CKA_DECRYPT),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+	}
+
+	pub, priv, err := ctx.GenerateKeyPair(ss,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		publicKeyTemplate, privateKeyTemplate)
+	if err != nil {
+		return 0, 0, err
+	}
+	return priv, pub, nil
+}
+
+// DecryptOAEP decrypts a value encrypted with RSA-OAEP SHA-256 to a transport key.
+func DecryptOAEP(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, cipher []byte) ([]byte, error) {
+	if err := ctx.DecryptInit(ss, []*pkcs11.Mechanism{oaepSHA256()}, key); err != nil {
+		return nil, fmt.Errorf("failed to init decrypt: %v", err)
+	}
+
+	decrypted, err := ctx.Decrypt(ss, cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %v", err)
+	}
+
+	return decrypted, nil
+}
+
+// UnwrapImportedKey unwraps tenant key material as a secret key of the template with the
+// private wrapping key. RSAES_OAEP_SHA_256 is the key value under RSA-OAEP with SHA-256,
+// RSA_AES_KEY_WRAP_SHA_256 is an ephemeral AES key under RSA-OAEP followed by the key value
+// under AES key wrap with padding, the same layout as CKM_RSA_AES_KEY_WRAP.
+// The length of the key is checked against the template.
+func UnwrapImportedKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, wrappingKey pkcs11.ObjectHandle, algorithm string, wrapped []byte, label string, id []byte, t *KeyTemplate) (pkcs11.ObjectHandle, error) {
+	if t.Class != pkcs11.CKO_SECRET_KEY {
+		return 0, fmt.Errorf("template %s is not a secret key template", t.Name)
+	}
+	id, err := uniqueKeyID(ctx, ss, label, id)
+	if err != nil {
+		return 0, err
+	}
+	template, _ := t.attributes(label, id)
+
+	var key pkcs11.ObjectHandle
+	switch algorithm {
+	case WrapRSAOAEPSHA256:
+		obj, err := ctx.UnwrapKey(ss, []*pkcs11.Mechanism{oaepSHA256()}, wrappingKey, wrapped, template)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unwrap key: %v", err)
+		}
+		key = obj
+
+	case WrapRSAAESKeyWrapSHA256:
+		pub, err := RSAPublicKey(ctx, ss, wrappingKey)
+		if err != nil {
+			return 0, err
+		}
+		size := pub.Size()
+		if len(wrapped) <= size {
+			return 0, fmt.Errorf("wrapped key is too short")
+		}
+
+		ephemeral, err := ctx.UnwrapKey(ss, []*pkcs11.Mechanism{oaepSHA256()}, wrappingKey, wrapped[:size], []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_UNWRAP, true),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to unwrap ephemeral key: %v", err)
+		}
+		defer RemoveKey(ctx, ss, ephemeral)
+
+		if key, err = UnwrapKey(ctx, ss, ephemeral, wrapped[size:], template); err != nil {
+			return 0, err
+		}
+
+	default:
+		return 0, fmt.Errorf("unsupported wrapping algorithm: %s", algorithm)
+	}
+
+	if t.Bits > 0 {
+		attrs, err := GetAttributes(ctx, ss, key, pkcs11.CKA_VALUE_LEN)
+		if err != nil || int(AttributeUint(attrs[pkcs11.CKA_VALUE_LEN])) != t.Bits/8 {
+			RemoveKey(ctx, ss, key)
+			return 0, fmt.Errorf("key material is not a %d bits key", t.Bits)
+		}
+	}
+
+	return key, nil
+}
+
+func oaepSHA256() *pkcs11.Mechanism {
+	return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP,
+		pkcs11.NewOAEPParams(pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, pkcs11.CKZ_DATA_SPECIFIED, nil))
+}
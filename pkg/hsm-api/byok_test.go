@@ -0,0 +1,75 @@
+package hsm_api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+)
+
+// wrap an AES key the way a tenant does with the software implementations.
+func TestUnwrapImportedKey(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	priv, pub, err := GenerateWrappingKeyPair(ctx, ss, "test-wrapping-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, priv)
+	defer RemoveKey(ctx, ss, pub)
+
+	wrappingKey, err := RSAPublicKey(ctx, ss, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aes256, _ := DefaultKeyTemplate(KeyTypeAES256, false)
+	value := GenIV(32)
+	want, _ := SoftKeyCheckValue(value, KeyTypeAES256, KCVMethodECB)
+
+	oaep := func(data []byte) []byte {
+		out, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, wrappingKey, data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	ephemeral := GenIV(32)
+	kwp, _ := KeyWrapPad(ephemeral, value)
+
+	for algorithm, wrapped := range map[string][]byte{
+		WrapRSAOAEPSHA256:       oaep(value),
+		WrapRSAAESKeyWrapSHA256: append(oaep(ephemeral), kwp...),
+	} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := UnwrapImportedKey(ctx, ss, priv, algorithm, wrapped, "test-byok-"+algorithm, nil, aes256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer RemoveKey(ctx, ss, key)
+
+			kcv, err := KeyCheckValue(ctx, ss, key, KCVMethodECB)
+			if err != nil {
+				t.Error(err)
+			}
+			if !bytes.Equal(kcv, want) {
+				t.Errorf("kcv: got %X, want %X", kcv, want)
+			}
+		})
+	}
+
+	if _, err := UnwrapImportedKey(ctx, ss, priv, WrapRSAOAEPSHA256, oaep(value[:16]), "test-byok-short", nil, aes256); err == nil {
+		t.Error("expected error for key length mismatch")
+	}
+}
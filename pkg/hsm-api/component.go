@@ -0,0 +1,106 @@
+package hsm_api
+
+import (
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ImportKeyComponent creates a session generic secret from the first clear component,
+// the next components are XORed into it with XorKeyComponent.
+func ImportKeyComponent(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, component []byte) (pkcs11.ObjectHandle, error) {
+	obj, err := ctx.CreateObject(ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_DERIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, component),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import key component: %v", err)
+	}
+	return obj, nil
+}
+
+// XorKeyComponent derives base XOR component inside the token with CKM_XOR_BASE_AND_DATA.
+// A nil template keeps the result a derivable session generic secret.
+func XorKeyComponent(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, base pkcs11.ObjectHandle, component []byte, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if template == nil {
+		template = []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+			pkcs11.NewAttribute(pkcs11.CKA_DERIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		}
+	}
+
+	params, free := stringDataParams(component)
+	defer free()
+
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_XOR_BASE_AND_DATA, params)}
+	obj, err := ctx.DeriveKey(ss, mech, base, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to combine key component: %v", err)
+	}
+	return obj, nil
+}
+
+// SupportsMechanism tells if the token of the session implements the mechanism.
+func SupportsMechanism(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, mech uint) (bool, error) {
+	info, err := ctx.GetSessionInfo(ss)
+	if err != nil {
+		return false, fmt.Errorf("failed to get session info: %v", err)
+	}
+
+	mechs, err := ctx.GetMechanismList(info.SlotID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get mechanism list: %v", err)
+	}
+
+	for _, m := range mechs {
+		if m.Mechanism == mech {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SecretKeyTemplate returns the attributes of a non-extractable token secret key of one of
+// the KeyType* types, for keys that are derived or unwrapped rather than generated.
+// The AES value length is only set when withValueLen is true since unwrapping implies it.
+func SecretKeyTemplate(label, keyType string, withValueLen bool) ([]*pkcs11.Attribute, error) {
+	spec, ok := secretKeySpecs[keyType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported secret key type: %s", keyType)
+	}
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, spec.keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, spec.cipher),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, spec.cipher),
+	}
+	if withValueLen && spec.valueLen > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, spec.valueLen))
+	}
+	return template, nil
+}
+
+// SecretKeyLength is the size in bytes of the key type, 0 when unknown.
+func SecretKeyLength(keyType string) int {
+	switch keyType {
+	case KeyTypeDES2:
+		return 16
+	case KeyTypeDES3:
+		return 24
+	}
+	return secretKeySpecs[keyType].valueLen
+}
@@ -0,0 +1,55 @@
+package hsm_api
+
+import (
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	GCMIVSize  = 12
+	gcmTagSize = 16
+)
+
+// EncryptGCM encrypts with AES-GCM under a fresh iv and returns iv || cipher || tag.
+func EncryptGCM(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, plainText, aad []byte) ([]byte, error) {
+	iv := GenIV(GCMIVSize)
+	if iv == nil {
+		return nil, fmt.Errorf("failed to generate iv")
+	}
+
+	params := pkcs11.NewGCMParams(iv, aad, gcmTagSize*8)
+	defer params.Free()
+
+	if err := ctx.EncryptInit(ss, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, key); err != nil {
+		return nil, fmt.Errorf("failed to init encrypt: %v", err)
+	}
+
+	cipher, err := ctx.Encrypt(ss, plainText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %v", err)
+	}
+
+	return append(iv, cipher...), nil
+}
+
+// DecryptGCM opens iv || cipher || tag, it fails when the tag does not authenticate.
+func DecryptGCM(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, cipher, aad []byte) ([]byte, error) {
+	if len(cipher) < GCMIVSize+gcmTagSize {
+		return nil, fmt.Errorf("cipher is too short")
+	}
+
+	params := pkcs11.NewGCMParams(cipher[:GCMIVSize], aad, gcmTagSize*8)
+	defer params.Free()
+
+	if err := ctx.DecryptInit(ss, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, key); err != nil {
+		return nil, fmt.Errorf("failed to init decrypt: %v", err)
+	}
+
+	plain, err := ctx.Decrypt(ss, cipher[GCMIVSize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %v", err)
+	}
+
+	return plain, nil
+}
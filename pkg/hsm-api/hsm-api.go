@@ -3,6 +3,7 @@ package hsm_api
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -47,80 +48,161 @@ func GetSession(ctx *pkcs11.Ctx, slotID uint, pin string) (pkcs11.SessionHandle,
 	return ss, nil
 }
 
+// OpenSession opens another session on the slot of ss, it shares the login of ss.
+func OpenSession(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle) (pkcs11.SessionHandle, error) {
+	info, err := ctx.GetSessionInfo(ss)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session info: %v", err)
+	}
+	other, err := ctx.OpenSession(info.SlotID, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open session: %v", err)
+	}
+	return other, nil
+}
+
 func FinishSession(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle) {
 	ctx.Logout(ss)
 	ctx.CloseSession(ss)
 }
 
+// secret key types that can be generated
+const (
+	KeyTypeDES2          = "DES2"
+	KeyTypeDES3          = "DES3"
+	KeyTypeAES128        = "AES128"
+	KeyTypeAES192        = "AES192"
+	KeyTypeAES256        = "AES256"
+	KeyTypeGenericSecret = "GENERIC_SECRET"
+)
+
+type secretKeySpec struct {
+	keyType  uint
+	valueLen int // 0 when the length is implied by the key type
+	cipher   bool
+}
+
+var secretKeySpecs = map[string]secretKeySpec{
+	KeyTypeDES2:          {pkcs11.CKK_DES2, 0, true},
+	KeyTypeDES3:          {pkcs11.CKK_DES3, 0, true},
+	KeyTypeAES128:        {pkcs11.CKK_AES, 16, true},
+	KeyTypeAES192:        {pkcs11.CKK_AES, 24, true},
+	KeyTypeAES256:        {pkcs11.CKK_AES, 32, true},
+	KeyTypeGenericSecret: {pkcs11.CKK_GENERIC_SECRET, 32, false},
+}
+
 // secret key
 func CreateSecretKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, error) {
-	aesKeyTemplate := []*pkcs11.Attribute{
-		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY), // O
-		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),     // O
-		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
-		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
-		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
-		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
-		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
-		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
-		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+	return CreateTypedSecretKey(ctx, ss, label, KeyTypeAES256)
+}
+
+// triple DES key: double length (DES2) or triple length (DES3)
+func CreateDES3Key(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, doubleLength bool) (pkcs11.ObjectHandle, error) {
+	if doubleLength {
+		return CreateTypedSecretKey(ctx, ss, label, KeyTypeDES2)
 	}
-	return ctx.GenerateKey(ss, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)}, aesKeyTemplate)
+	return CreateTypedSecretKey(ctx, ss, label, KeyTypeDES3)
 }
 
-// RSA key pair: public and private key
-func CreateRSAKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
-	publicKeyTemplate := []*pkcs11.Attribute{
-		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
-		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
-		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
-		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
-		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
-		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
-		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
-		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048), // M
-	}
-	privateKeyTemplate := []*pkcs11.Attribute{
-		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
-		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
-		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
-		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
-		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
-		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
-		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
-		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+// CreateTypedSecretKey generates an extractable secret key of one of the KeyType* types.
+// Cipher keys can encrypt and decrypt, generic secrets can sign, verify and derive. The
+// retail MAC of more than one block needs a DES2 key of a retail_mac template instead.
+func CreateTypedSecretKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label, keyType string) (pkcs11.ObjectHandle, error) {
+	id, err := uniqueKeyID(ctx, ss, label, nil)
+	if err != nil {
+		return 0, err
 	}
-	return ctx.GenerateKeyPair(ss,
-		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
-		publicKeyTemplate, privateKeyTemplate)
+	return generateSecretKey(ctx, ss, label, id, keyType, true)
 }
 
-func FindKeys(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, keyClass uint, label string) ([]pkcs11.ObjectHandle, error) {
-	searchTemplate := []*pkcs11.Attribute{
-		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
-		pkcs11.NewAttribute(pkcs11.CKA_CLASS, keyClass),
+func generateSecretKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte, keyType string, extractable bool) (pkcs11.ObjectHandle, error) {
+	t, err := DefaultKeyTemplate(keyType, extractable)
+	if err != nil || t.Class != pkcs11.CKO_SECRET_KEY {
+		return 0, fmt.Errorf("unsupported secret key type: %s", keyType)
 	}
 
-	// init
-	if err := ctx.FindObjectsInit(ss, searchTemplate); err != nil {
-		return nil, fmt.Errorf("failed to init finding key: %v", err)
+	objs, err := t.generate(ctx, ss, label, id)
+	if err != nil {
+		return 0, err
 	}
+	return objs[0], nil
+}
 
-	// finding
-	obj, _, err := ctx.FindObjects(ss, 1)
+// CBCPadMechanism returns the CBC mechanism with PKCS#7 padding of the key type and its iv size.
+func CBCPadMechanism(keyType uint) (uint, int, error) {
+	switch keyType {
+	case pkcs11.CKK_AES:
+		return pkcs11.CKM_AES_CBC_PAD, 16, nil
+	case pkcs11.CKK_DES2, pkcs11.CKK_DES3:
+		return pkcs11.CKM_DES3_CBC_PAD, desBlockSize, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported key type for encryption: %#x", keyType)
+}
+
+// GetKeyType reads CKA_KEY_TYPE of the key object.
+func GetKeyType(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle) (uint, error) {
+	attrs, err := ctx.GetAttributeValue(ss, key, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
 	if err != nil {
-		return nil, fmt.Errorf("failed to find key %v", err)
+		return 0, fmt.Errorf("failed to get key type: %v", err)
 	}
-	if len(obj) == 0 {
-		return nil, fmt.Errorf("not found key")
+	return bytesToUint(attrs[0].Value), nil
+}
+
+// uintToBytes encodes v as a CK_ULONG in the native layout of the module.
+func uintToBytes(v uint) []byte {
+	return pkcs11.NewAttribute(0, v).Value
+}
+
+// bytesToUint decodes a CK_ULONG attribute value returned by the module.
+func bytesToUint(b []byte) uint {
+	switch len(b) {
+	case 4:
+		return uint(binary.LittleEndian.Uint32(b))
+	case 8:
+		return uint(binary.LittleEndian.Uint64(b))
 	}
+	return 0
+}
 
-	//final
-	if err := ctx.FindObjectsFinal(ss); err != nil {
-		return nil, fmt.Errorf("failed to finalize finding key: %v", err)
+// RSA key pair: public and private key
+func CreateRSAKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	id, err := uniqueKeyID(ctx, ss, label, nil)
+	if err != nil {
+		return 0, 0, err
 	}
+	return generateRSAKeyPair(ctx, ss, label, id, 2048, true)
+}
 
-	return obj, nil
+func generateRSAKeyPair(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte, bits int, extractable bool) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	t := &KeyTemplate{
+		Name:        "RSA",
+		Class:       pkcs11.CKO_PRIVATE_KEY,
+		KeyType:     pkcs11.CKK_RSA,
+		Bits:        bits,
+		Usage:       []uint{pkcs11.CKA_SIGN, pkcs11.CKA_DECRYPT},
+		Extractable: extractable,
+	}
+
+	objs, err := t.generate(ctx, ss, label, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return objs[1], objs[0], nil
+}
+
+// FindKeys returns every object of the class with the label, it fails when there is none.
+func FindKeys(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, keyClass uint, label string) ([]pkcs11.ObjectHandle, error) {
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, keyClass),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("not found key")
+	}
+	return objs, nil
 }
 
 func RemoveKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) error {
@@ -170,6 +252,32 @@ func Decrypt(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle,
 	return decrypted, nil
 }
 
+// signature
+func Sign(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, mech uint, data []byte) ([]byte, error) {
+	if err := ctx.SignInit(ss, []*pkcs11.Mechanism{pkcs11.NewMechanism(mech, nil)}, key); err != nil {
+		return nil, fmt.Errorf("failed to init sign: %v", err)
+	}
+
+	signature, err := ctx.Sign(ss, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+
+	return signature, nil
+}
+
+func Verify(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, mech uint, data, signature []byte) error {
+	if err := ctx.VerifyInit(ss, []*pkcs11.Mechanism{pkcs11.NewMechanism(mech, nil)}, key); err != nil {
+		return fmt.Errorf("failed to init verify: %v", err)
+	}
+
+	if err := ctx.Verify(ss, data, signature); err != nil {
+		return fmt.Errorf("failed to verify: %v", err)
+	}
+
+	return nil
+}
+
 // pkcs7Pad right-pads the b slice, so its length becomes the multiply of the blocksize.
 func pkcs7Pad(b []byte, blocksize int) ([]byte, error) {
 	if blocksize <= 0 {
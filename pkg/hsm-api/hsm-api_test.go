@@ -119,6 +119,44 @@ func TestCreateSecretKey(t *testing.T) {
 	})
 }
 
+// typed secret keys with their check value
+func TestCreateTypedSecretKey(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	for _, keyType := range []string{KeyTypeDES2, KeyTypeDES3, KeyTypeAES128, KeyTypeAES192, KeyTypeAES256, KeyTypeGenericSecret} {
+		t.Run(keyType, func(t *testing.T) {
+			k, err := CreateTypedSecretKey(ctx, ss, "test-"+keyType, keyType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer RemoveKey(ctx, ss, k)
+
+			if keyType == KeyTypeGenericSecret {
+				return
+			}
+			kcv, err := KeyCheckValue(ctx, ss, k, "")
+			if err != nil {
+				t.Error(err)
+			}
+			t.Logf("create %s key successfully: %v, kcv: %X", keyType, k, kcv)
+		})
+	}
+
+	if _, err := CreateTypedSecretKey(ctx, ss, "test-unknown", "RC4"); err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+}
+
 // find secret key that created from TestCreateSecretKey
 func TestFindSecretKeys(t *testing.T) {
 	ctx, err := GetContext(modulePath)
@@ -284,7 +322,95 @@ func TestSymmetric(t *testing.T) {
 	})
 }
 
+// use triple DES key for both encryption and decryption
+func TestSymmetricDES3(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	obj, err := CreateDES3Key(ctx, ss, "test-symmetric-des3", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, obj)
+
+	padded, _ := pkcs7Pad([]byte(plainText), 8)
+
+	for name, c := range map[string]struct {
+		mech  uint
+		iv    []byte
+		plain []byte
+	}{
+		"ECB":     {pkcs11.CKM_DES3_ECB, nil, padded},
+		"CBC":     {pkcs11.CKM_DES3_CBC, GenIV(8), padded},
+		"CBC-PAD": {pkcs11.CKM_DES3_CBC_PAD, GenIV(8), []byte(plainText)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cipher, err := Encrypt(ctx, ss, obj, c.mech, c.plain, c.iv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("cipher: %s", base64.StdEncoding.EncodeToString(cipher))
+
+			decrypted, err := Decrypt(ctx, ss, obj, c.mech, cipher, c.iv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Compare(c.plain, decrypted) != 0 {
+				t.Error("missmatch")
+			}
+		})
+	}
+}
+
 // public key and private key
+func TestSymmetricGCM(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	obj, err := CreateSecretKey(ctx, ss, "test-symmetric-gcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, obj)
+
+	cipher, err := EncryptGCM(ctx, ss, obj, []byte(plainText), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("cipher: %s", base64.StdEncoding.EncodeToString(cipher))
+
+	decrypted, err := DecryptGCM(ctx, ss, obj, cipher, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal([]byte(plainText), decrypted) {
+		t.Error("missmatch")
+	}
+
+	cipher[len(cipher)-1] ^= 1
+	if _, err := DecryptGCM(ctx, ss, obj, cipher, nil); err == nil {
+		t.Error("expected error for tampered cipher")
+	}
+}
+
 func TestCreateRSAKeyPair(t *testing.T) {
 	ctx, err := GetContext(modulePath)
 	if err != nil {
@@ -0,0 +1,151 @@
+package hsm_api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// named curve object identifiers for CKA_EC_PARAMS
+var curveOIDs = map[string]asn1.ObjectIdentifier{
+	"P-224": {1, 3, 132, 0, 33},
+	"P-256": {1, 2, 840, 10045, 3, 1, 7},
+	"P-384": {1, 3, 132, 0, 34},
+	"P-521": {1, 3, 132, 0, 35},
+}
+
+// ParsePrivateKey decodes an RSA or EC private key, PEM or DER, in PKCS#1, PKCS#8 or SEC1.
+func ParsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if x509.IsEncryptedPEMBlock(block) {
+			return nil, fmt.Errorf("encrypted pem keys are not supported")
+		}
+		data = block.Bytes
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		switch key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key: not a PKCS#1, PKCS#8 or SEC1 key")
+}
+
+// ImportPrivateKey brings an RSA or EC private key into the token as an object of the
+// template, non-extractable when the template is nil. The PKCS#8 encoding is wrapped under
+// a transport key and unwrapped by the token. The public key is created next to it with
+// the same label and id.
+func ImportPrivateKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte, key crypto.PrivateKey, t *KeyTemplate) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	if label == "" {
+		return 0, 0, fmt.Errorf("missing key label")
+	}
+	id, err := uniqueKeyID(ctx, ss, label, id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	privTemplate, pubTemplate, err := importTemplates(label, id, key, t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to encode private key: %v", err)
+	}
+	defer zero(der)
+
+	priv, err := UnwrapSecretKey(ctx, ss, der, privTemplate)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pub, err := ctx.CreateObject(ss, pubTemplate)
+	if err != nil {
+		RemoveKey(ctx, ss, priv)
+		return 0, 0, fmt.Errorf("failed to create public key: %v", err)
+	}
+
+	return priv, pub, nil
+}
+
+// importTemplates returns the private and public key templates of the key. The key must match
+// the type and size of the template, without template it is non-extractable and signs, RSA
+// keys also decrypt.
+func importTemplates(label string, id []byte, key crypto.PrivateKey, t *KeyTemplate) ([]*pkcs11.Attribute, []*pkcs11.Attribute, error) {
+	var (
+		keyType uint
+		bits    int
+		usage   []uint
+		public  []*pkcs11.Attribute
+	)
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		keyType, bits = pkcs11.CKK_RSA, k.N.BitLen()
+		usage = []uint{pkcs11.CKA_SIGN, pkcs11.CKA_DECRYPT}
+		public = []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, k.N.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, big.NewInt(int64(k.E)).Bytes()),
+		}
+
+	case *ecdsa.PrivateKey:
+		params, point, err := ecPublicAttributes(&k.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyType, bits = pkcs11.CKK_EC, k.Curve.Params().BitSize
+		usage = []uint{pkcs11.CKA_SIGN}
+		public = []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, point),
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+
+	if t == nil {
+		t = &KeyTemplate{Name: "import", Class: pkcs11.CKO_PRIVATE_KEY, KeyType: keyType, Bits: bits, Usage: usage}
+	}
+	if t.Class != pkcs11.CKO_PRIVATE_KEY || t.KeyType != keyType || t.Bits != bits {
+		return nil, nil, fmt.Errorf("key of %d bits does not match template %s", bits, t.Name)
+	}
+
+	priv, pub := t.attributes(label, id)
+	return priv, append(pub, public...), nil
+}
+
+// ecPublicAttributes encodes CKA_EC_PARAMS as the DER curve oid and CKA_EC_POINT as the
+// DER octet string of the uncompressed point.
+func ecPublicAttributes(pub *ecdsa.PublicKey) ([]byte, []byte, error) {
+	oid, ok := curveOIDs[pub.Curve.Params().Name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported curve: %s", pub.Curve.Params().Name)
+	}
+
+	params, err := asn1.Marshal(oid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode ec params: %v", err)
+	}
+	point, err := asn1.Marshal(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode ec point: %v", err)
+	}
+	return params, point, nil
+}
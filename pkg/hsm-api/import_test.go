@@ -0,0 +1,118 @@
+package hsm_api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	pkcs1, err := ioutil.ReadFile("../../misc/openssl/private.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ParsePrivateKey(pkcs1); err != nil {
+		t.Error(err)
+	} else if _, ok := key.(*rsa.PrivateKey); !ok {
+		t.Errorf("pkcs1: got %T", key)
+	}
+
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sec1, _ := x509.MarshalECPrivateKey(ec)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(ec)
+
+	for name, data := range map[string][]byte{
+		"sec1 pem":  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+		"sec1 der":  sec1,
+		"pkcs8 pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		"pkcs8 der": pkcs8,
+	} {
+		key, err := ParsePrivateKey(data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if k, ok := key.(*ecdsa.PrivateKey); !ok || k.D.Cmp(ec.D) != 0 {
+			t.Errorf("%s: key mismatch", name)
+		}
+	}
+
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestECPublicAttributes(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	params, point, err := ecPublicAttributes(&ec.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(params) != "06082a8648ce3d030107" {
+		t.Errorf("params: got %x", params)
+	}
+	// octet string of 65 bytes: 04 || x || y
+	if len(point) != 67 || point[0] != 0x04 || point[1] != 65 || point[2] != 0x04 {
+		t.Errorf("point: got %x", point)
+	}
+}
+
+func TestImportPrivateKey(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	data, err := ioutil.ReadFile("../../misc/openssl/private.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, pub, err := ImportPrivateKey(ctx, ss, "test-import-rsa", nil, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, priv)
+	defer RemoveKey(ctx, ss, pub)
+
+	info, err := DescribeKey(ctx, ss, priv)
+	if err != nil {
+		t.Error(err)
+	}
+	if info.Extractable || info.Bits != 2048 {
+		t.Errorf("imported key: extractable %v, %d bits", info.Extractable, info.Bits)
+	}
+}
+
+func TestECPublicKey(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	params, point, _ := ecPublicAttributes(&ec.PublicKey)
+
+	// DER octet string and raw point
+	for _, p := range [][]byte{point, point[2:]} {
+		pub, err := ecPublicKey(params, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.Curve != elliptic.P384() || pub.X.Cmp(ec.X) != 0 || pub.Y.Cmp(ec.Y) != 0 {
+			t.Error("public key mismatch")
+		}
+	}
+}
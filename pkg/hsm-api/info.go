@@ -0,0 +1,276 @@
+package hsm_api
+
+import (
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ModuleInfo is the library and the slots with their tokens and mechanisms.
+type ModuleInfo struct {
+	CryptokiVersion    string
+	ManufacturerID     string
+	LibraryDescription string
+	LibraryVersion     string
+	Slots              []*SlotInfo
+}
+
+// SlotInfo is a slot, Token is nil when the slot has no token.
+type SlotInfo struct {
+	ID             uint
+	Description    string
+	ManufacturerID string
+	Flags          []string
+	Token          *TokenInfo
+	Mechanisms     []*MechanismInfo
+}
+
+// TokenInfo is the token of a slot, memory sizes are in bytes.
+type TokenInfo struct {
+	Label              string
+	ManufacturerID     string
+	Model              string
+	SerialNumber       string
+	Flags              []string
+	MaxSessionCount    uint
+	SessionCount       uint
+	MaxRwSessionCount  uint
+	RwSessionCount     uint
+	MinPinLen          uint
+	MaxPinLen          uint
+	TotalPublicMemory  uint
+	FreePublicMemory   uint
+	TotalPrivateMemory uint
+	FreePrivateMemory  uint
+	HardwareVersion    string
+	FirmwareVersion    string
+}
+
+// MechanismInfo is a mechanism of a token, key sizes are in bits for RSA and EC and in
+// bytes for the secret key mechanisms. Flags are the CKF_* capabilities.
+type MechanismInfo struct {
+	Type       uint
+	Name       string
+	MinKeySize uint
+	MaxKeySize uint
+	Flags      uint
+}
+
+type flagName struct {
+	flag uint
+	name string
+}
+
+var slotFlags = []flagName{
+	{pkcs11.CKF_TOKEN_PRESENT, "TOKEN_PRESENT"},
+	{pkcs11.CKF_REMOVABLE_DEVICE, "REMOVABLE_DEVICE"},
+	{pkcs11.CKF_HW_SLOT, "HW_SLOT"},
+}
+
+var tokenFlags = []flagName{
+	{pkcs11.CKF_RNG, "RNG"},
+	{pkcs11.CKF_WRITE_PROTECTED, "WRITE_PROTECTED"},
+	{pkcs11.CKF_LOGIN_REQUIRED, "LOGIN_REQUIRED"},
+	{pkcs11.CKF_USER_PIN_INITIALIZED, "USER_PIN_INITIALIZED"},
+	{pkcs11.CKF_RESTORE_KEY_NOT_NEEDED, "RESTORE_KEY_NOT_NEEDED"},
+	{pkcs11.CKF_CLOCK_ON_TOKEN, "CLOCK_ON_TOKEN"},
+	{pkcs11.CKF_PROTECTED_AUTHENTICATION_PATH, "PROTECTED_AUTHENTICATION_PATH"},
+	{pkcs11.CKF_DUAL_CRYPTO_OPERATIONS, "DUAL_CRYPTO_OPERATIONS"},
+	{pkcs11.CKF_TOKEN_INITIALIZED, "TOKEN_INITIALIZED"},
+	{pkcs11.CKF_SECONDARY_AUTHENTICATION, "SECONDARY_AUTHENTICATION"},
+	{pkcs11.CKF_USER_PIN_COUNT_LOW, "USER_PIN_COUNT_LOW"},
+	{pkcs11.CKF_USER_PIN_FINAL_TRY, "USER_PIN_FINAL_TRY"},
+	{pkcs11.CKF_USER_PIN_LOCKED, "USER_PIN_LOCKED"},
+	{pkcs11.CKF_USER_PIN_TO_BE_CHANGED, "USER_PIN_TO_BE_CHANGED"},
+	{pkcs11.CKF_SO_PIN_COUNT_LOW, "SO_PIN_COUNT_LOW"},
+	{pkcs11.CKF_SO_PIN_FINAL_TRY, "SO_PIN_FINAL_TRY"},
+	{pkcs11.CKF_SO_PIN_LOCKED, "SO_PIN_LOCKED"},
+	{pkcs11.CKF_SO_PIN_TO_BE_CHANGED, "SO_PIN_TO_BE_CHANGED"},
+}
+
+var mechanismFlags = []flagName{
+	{pkcs11.CKF_HW, "HW"},
+	{pkcs11.CKF_ENCRYPT, "ENCRYPT"},
+	{pkcs11.CKF_DECRYPT, "DECRYPT"},
+	{pkcs11.CKF_DIGEST, "DIGEST"},
+	{pkcs11.CKF_SIGN, "SIGN"},
+	{pkcs11.CKF_SIGN_RECOVER, "SIGN_RECOVER"},
+	{pkcs11.CKF_VERIFY, "VERIFY"},
+	{pkcs11.CKF_VERIFY_RECOVER, "VERIFY_RECOVER"},
+	{pkcs11.CKF_GENERATE, "GENERATE"},
+	{pkcs11.CKF_GENERATE_KEY_PAIR, "GENERATE_KEY_PAIR"},
+	{pkcs11.CKF_WRAP, "WRAP"},
+	{pkcs11.CKF_UNWRAP, "UNWRAP"},
+	{pkcs11.CKF_DERIVE, "DERIVE"},
+	{pkcs11.CKF_EC_F_P, "EC_F_P"},
+	{pkcs11.CKF_EC_F_2M, "EC_F_2M"},
+	{pkcs11.CKF_EC_ECPARAMETERS, "EC_ECPARAMETERS"},
+	{pkcs11.CKF_EC_NAMEDCURVE, "EC_NAMEDCURVE"},
+	{pkcs11.CKF_EC_UNCOMPRESS, "EC_UNCOMPRESS"},
+	{pkcs11.CKF_EC_COMPRESS, "EC_COMPRESS"},
+}
+
+// mechanisms used by the service that cannot be named in a template
+var otherMechanismNames = map[string]uint{
+	"CKM_AES_KEY_GEN":            pkcs11.CKM_AES_KEY_GEN,
+	"CKM_DES2_KEY_GEN":           pkcs11.CKM_DES2_KEY_GEN,
+	"CKM_DES3_KEY_GEN":           pkcs11.CKM_DES3_KEY_GEN,
+	"CKM_GENERIC_SECRET_KEY_GEN": pkcs11.CKM_GENERIC_SECRET_KEY_GEN,
+	"CKM_RSA_PKCS_KEY_PAIR_GEN":  pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN,
+	"CKM_EC_KEY_PAIR_GEN":        pkcs11.CKM_EC_KEY_PAIR_GEN,
+	"CKM_RSA_AES_KEY_WRAP":       pkcs11.CKM_RSA_AES_KEY_WRAP,
+	"CKM_SHA256":                 pkcs11.CKM_SHA256,
+	"CKM_XOR_BASE_AND_DATA":      pkcs11.CKM_XOR_BASE_AND_DATA,
+	"CKM_EXTRACT_KEY_FROM_KEY":   pkcs11.CKM_EXTRACT_KEY_FROM_KEY,
+}
+
+// mechanisms with key sizes in bytes
+var byteSizedMechanisms = map[uint]bool{
+	pkcs11.CKM_AES_KEY_GEN:            true,
+	pkcs11.CKM_GENERIC_SECRET_KEY_GEN: true,
+	pkcs11.CKM_AES_ECB:                true,
+	pkcs11.CKM_AES_CBC:                true,
+	pkcs11.CKM_AES_CBC_PAD:            true,
+	pkcs11.CKM_AES_GCM:                true,
+	pkcs11.CKM_AES_CMAC:               true,
+	pkcs11.CKM_AES_KEY_WRAP:           true,
+	pkcs11.CKM_AES_KEY_WRAP_PAD:       true,
+	pkcs11.CKM_SHA256_HMAC:            true,
+	pkcs11.CKM_SHA384_HMAC:            true,
+	pkcs11.CKM_SHA512_HMAC:            true,
+}
+
+// GetModuleInfo reads the library info and the slot, every slot when it is 0, with its
+// token and mechanisms when it has a token.
+func GetModuleInfo(ctx *pkcs11.Ctx, slot uint) (*ModuleInfo, error) {
+	info, err := ctx.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module info: %v", err)
+	}
+	m := &ModuleInfo{
+		CryptokiVersion:    version(info.CryptokiVersion),
+		ManufacturerID:     info.ManufacturerID,
+		LibraryDescription: info.LibraryDescription,
+		LibraryVersion:     version(info.LibraryVersion),
+	}
+
+	slots := []uint{slot}
+	if slot == 0 {
+		if slots, err = ctx.GetSlotList(false); err != nil {
+			return nil, fmt.Errorf("failed to get slot list: %v", err)
+		}
+	}
+	for _, slot := range slots {
+		s, err := GetSlotInfo(ctx, slot)
+		if err != nil {
+			return nil, err
+		}
+		m.Slots = append(m.Slots, s)
+	}
+	return m, nil
+}
+
+// GetSlotInfo reads a slot, with its token and mechanisms when it has a token.
+func GetSlotInfo(ctx *pkcs11.Ctx, slot uint) (*SlotInfo, error) {
+	info, err := ctx.GetSlotInfo(slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get info of slot %d: %v", slot, err)
+	}
+	s := &SlotInfo{
+		ID:             slot,
+		Description:    info.SlotDescription,
+		ManufacturerID: info.ManufacturerID,
+		Flags:          flagNames(slotFlags, info.Flags),
+	}
+	if info.Flags&pkcs11.CKF_TOKEN_PRESENT == 0 {
+		return s, nil
+	}
+
+	t, err := ctx.GetTokenInfo(slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+	}
+	s.Token = &TokenInfo{
+		Label:              t.Label,
+		ManufacturerID:     t.ManufacturerID,
+		Model:              t.Model,
+		SerialNumber:       t.SerialNumber,
+		Flags:              flagNames(tokenFlags, t.Flags),
+		MaxSessionCount:    t.MaxSessionCount,
+		SessionCount:       t.SessionCount,
+		MaxRwSessionCount:  t.MaxRwSessionCount,
+		RwSessionCount:     t.RwSessionCount,
+		MinPinLen:          t.MinPinLen,
+		MaxPinLen:          t.MaxPinLen,
+		TotalPublicMemory:  t.TotalPublicMemory,
+		FreePublicMemory:   t.FreePublicMemory,
+		TotalPrivateMemory: t.TotalPrivateMemory,
+		FreePrivateMemory:  t.FreePrivateMemory,
+		HardwareVersion:    version(t.HardwareVersion),
+		FirmwareVersion:    version(t.FirmwareVersion),
+	}
+
+	if s.Mechanisms, err = GetMechanisms(ctx, slot); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetMechanisms reads the mechanisms of the token of the slot with their capabilities.
+func GetMechanisms(ctx *pkcs11.Ctx, slot uint) ([]*MechanismInfo, error) {
+	mechs, err := ctx.GetMechanismList(slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mechanisms of slot %d: %v", slot, err)
+	}
+
+	infos := make([]*MechanismInfo, 0, len(mechs))
+	for _, m := range mechs {
+		info, err := ctx.GetMechanismInfo(slot, []*pkcs11.Mechanism{m})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info of mechanism %s: %v", MechanismName(m.Mechanism), err)
+		}
+		infos = append(infos, &MechanismInfo{
+			Type:       m.Mechanism,
+			Name:       MechanismName(m.Mechanism),
+			MinKeySize: info.MinKeySize,
+			MaxKeySize: info.MaxKeySize,
+			Flags:      info.Flags,
+		})
+	}
+	return infos, nil
+}
+
+// Supports reports whether the mechanism has every flag and takes keys of the size in
+// bits, a size of 0 is not checked. Sizes are not checked for DES mechanisms.
+func (m *MechanismInfo) Supports(flags uint, bits int) bool {
+	if m.Flags&flags != flags {
+		return false
+	}
+	if bits <= 0 || (m.MinKeySize == 0 && m.MaxKeySize == 0) {
+		return true
+	}
+	size := uint(bits)
+	if byteSizedMechanisms[m.Type] {
+		size = uint(bits / 8)
+	}
+	return size >= m.MinKeySize && (m.MaxKeySize == 0 || size <= m.MaxKeySize)
+}
+
+// FlagNames returns the names of the capabilities of the mechanism.
+func (m *MechanismInfo) FlagNames() []string {
+	return flagNames(mechanismFlags, m.Flags)
+}
+
+func flagNames(names []flagName, flags uint) []string {
+	var set []string
+	for _, f := range names {
+		if flags&f.flag != 0 {
+			set = append(set, f.name)
+		}
+	}
+	return set
+}
+
+func version(v pkcs11.Version) string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
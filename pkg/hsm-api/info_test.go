@@ -0,0 +1,54 @@
+package hsm_api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestGetModuleInfo(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer FinishContext(ctx)
+
+	info, err := GetModuleInfo(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Slots) == 0 || info.Slots[0].Token == nil || len(info.Slots[0].Mechanisms) == 0 {
+		t.Errorf("module info: %s", ToJsonString(info))
+	}
+}
+
+func TestMechanismSupports(t *testing.T) {
+	aes := &MechanismInfo{Type: pkcs11.CKM_AES_KEY_GEN, MinKeySize: 16, MaxKeySize: 32, Flags: pkcs11.CKF_GENERATE}
+	rsa := &MechanismInfo{Type: pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, MinKeySize: 512, MaxKeySize: 16384, Flags: pkcs11.CKF_GENERATE_KEY_PAIR}
+	des := &MechanismInfo{Type: pkcs11.CKM_DES3_ECB, Flags: pkcs11.CKF_ENCRYPT | pkcs11.CKF_DECRYPT}
+
+	cases := []struct {
+		m     *MechanismInfo
+		flags uint
+		bits  int
+		ok    bool
+	}{
+		{aes, pkcs11.CKF_GENERATE, 256, true},
+		{aes, pkcs11.CKF_GENERATE, 512, false},
+		{aes, pkcs11.CKF_GENERATE_KEY_PAIR, 0, false},
+		{rsa, pkcs11.CKF_GENERATE_KEY_PAIR, 3072, true},
+		{rsa, pkcs11.CKF_GENERATE_KEY_PAIR, 256, false},
+		{des, pkcs11.CKF_ENCRYPT, 112, true},
+		{des, pkcs11.CKF_ENCRYPT | pkcs11.CKF_SIGN, 0, false},
+	}
+	for _, c := range cases {
+		if got := c.m.Supports(c.flags, c.bits); got != c.ok {
+			t.Errorf("%s %#x %d: got %v, want %v", MechanismName(c.m.Type), c.flags, c.bits, got, c.ok)
+		}
+	}
+
+	if got := des.FlagNames(); !reflect.DeepEqual(got, []string{"ENCRYPT", "DECRYPT"}) {
+		t.Errorf("flag names: got %v", got)
+	}
+}
@@ -0,0 +1,165 @@
+package hsm_api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// key check value methods
+const (
+	KCVMethodECB  = "ECB"  // first 3 bytes of a zero block encrypted in ECB mode
+	KCVMethodCMAC = "CMAC" // first 5 bytes of the AES CMAC of a zero block
+
+	kcvLengthECB  = 3
+	kcvLengthCMAC = 5
+	aesBlockSize  = 16
+)
+
+// KeyCheckValue computes the check value of a DES/3DES or AES key.
+// An empty method means CMAC for AES keys and ECB for the others.
+func KeyCheckValue(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, method string) ([]byte, error) {
+	keyType, err := GetKeyType(ctx, ss, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == "" {
+		method = KCVMethodECB
+		if keyType == pkcs11.CKK_AES {
+			method = KCVMethodCMAC
+		}
+	}
+
+	switch method {
+	case KCVMethodECB:
+		block, err := zeroBlockECB(ctx, ss, key, keyType)
+		if err != nil {
+			return nil, err
+		}
+		return block[:kcvLengthECB], nil
+	case KCVMethodCMAC:
+		if keyType != pkcs11.CKK_AES {
+			return nil, fmt.Errorf("cmac check value requires an AES key")
+		}
+		mac, err := zeroBlockCMAC(ctx, ss, key)
+		if err != nil {
+			return nil, err
+		}
+		return mac[:kcvLengthCMAC], nil
+	}
+	return nil, fmt.Errorf("unsupported check value method: %s", method)
+}
+
+// VerifyKeyCheckValue compares the check value of the key with the expected one.
+func VerifyKeyCheckValue(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, method string, expected []byte) (bool, error) {
+	kcv, err := KeyCheckValue(ctx, ss, key, method)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(kcv, expected) == 1, nil
+}
+
+// SoftKeyCheckValue computes the check value of a clear key value of one of the KeyType*
+// types, such as a key component held by a custodian.
+func SoftKeyCheckValue(value []byte, keyType, method string) ([]byte, error) {
+	if len(value) != SecretKeyLength(keyType) {
+		return nil, fmt.Errorf("invalid %s key length: %d", keyType, len(value))
+	}
+
+	var block cipher.Block
+	var err error
+	switch keyType {
+	case KeyTypeAES128, KeyTypeAES192, KeyTypeAES256:
+		block, err = aes.NewCipher(value)
+	case KeyTypeDES2:
+		block, err = des.NewTripleDESCipher(append(append([]byte{}, value...), value[:8]...))
+	case KeyTypeDES3:
+		block, err = des.NewTripleDESCipher(value)
+	default:
+		return nil, fmt.Errorf("unsupported key type for check value: %s", keyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if method == "" {
+		method = KCVMethodECB
+		if block.BlockSize() == aesBlockSize {
+			method = KCVMethodCMAC
+		}
+	}
+
+	l := make([]byte, block.BlockSize())
+	block.Encrypt(l, l)
+	switch method {
+	case KCVMethodECB:
+		return l[:kcvLengthECB], nil
+	case KCVMethodCMAC:
+		if block.BlockSize() != aesBlockSize {
+			return nil, fmt.Errorf("cmac check value requires an AES key")
+		}
+		tag := make([]byte, aesBlockSize)
+		block.Encrypt(tag, cmacSubkey(l))
+		return tag[:kcvLengthCMAC], nil
+	}
+	return nil, fmt.Errorf("unsupported check value method: %s", method)
+}
+
+func zeroBlockECB(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, keyType uint) ([]byte, error) {
+	var mech uint
+	var block []byte
+	switch keyType {
+	case pkcs11.CKK_AES:
+		mech, block = pkcs11.CKM_AES_ECB, make([]byte, aesBlockSize)
+	case pkcs11.CKK_DES:
+		mech, block = pkcs11.CKM_DES_ECB, make([]byte, desBlockSize)
+	case pkcs11.CKK_DES2, pkcs11.CKK_DES3:
+		mech, block = pkcs11.CKM_DES3_ECB, make([]byte, desBlockSize)
+	default:
+		return nil, fmt.Errorf("unsupported key type for check value: %#x", keyType)
+	}
+
+	cipher, err := Encrypt(ctx, ss, key, mech, block, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute check value: %v", err)
+	}
+
+	return cipher, nil
+}
+
+// zeroBlockCMAC computes the CMAC (NIST SP 800-38B) of one zero block with ECB only, so keys
+// that can encrypt but not sign still get a check value: L = E(K, 0), K1 = dbl(L) and
+// since the message is one complete zero block the tag is E(K, K1).
+func zeroBlockCMAC(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle) ([]byte, error) {
+	l, err := zeroBlockECB(ctx, ss, key, pkcs11.CKK_AES)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := Encrypt(ctx, ss, key, pkcs11.CKM_AES_ECB, cmacSubkey(l), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute check value: %v", err)
+	}
+
+	return tag, nil
+}
+
+// cmacSubkey doubles the block in GF(2^128).
+func cmacSubkey(l []byte) []byte {
+	k := make([]byte, len(l))
+	for i := 0; i < len(l); i++ {
+		k[i] = l[i] << 1
+		if i+1 < len(l) {
+			k[i] |= l[i+1] >> 7
+		}
+	}
+	if l[0]&0x80 != 0 {
+		k[len(k)-1] ^= 0x87
+	}
+	return k
+}
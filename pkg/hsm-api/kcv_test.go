@@ -0,0 +1,96 @@
+package hsm_api
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// RFC 4493 subkey generation sample
+func TestCMACSubkey(t *testing.T) {
+	l, _ := hex.DecodeString("7df76b0c1ab899b33e42f047b91b546f")
+	k1 := cmacSubkey(l)
+	if hex.EncodeToString(k1) != "fbeed618357133667c85e08f7236a8de" {
+		t.Errorf("k1: got %x", k1)
+	}
+
+	k2 := cmacSubkey(k1)
+	if hex.EncodeToString(k2) != "f7ddac306ae266ccf90bc11ee46d513b" {
+		t.Errorf("k2: got %x", k2)
+	}
+}
+
+func TestSoftKeyCheckValue(t *testing.T) {
+	des2, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	kcv, err := SoftKeyCheckValue(des2, KeyTypeDES2, "")
+	if err != nil {
+		t.Error(err)
+	}
+	if hex.EncodeToString(kcv) != "08d7b4" {
+		t.Errorf("des2 kcv: got %x", kcv)
+	}
+
+	aes128, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	if _, err := SoftKeyCheckValue(aes128, KeyTypeAES256, ""); err == nil {
+		t.Error("expected error for key length mismatch")
+	}
+	if _, err := SoftKeyCheckValue(des2, KeyTypeDES2, KCVMethodCMAC); err == nil {
+		t.Error("expected error for cmac on des key")
+	}
+}
+
+func TestKeyCheckValue(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	value, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	key, err := ctx.CreateObject(ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, key)
+
+	soft, _ := aes.NewCipher(value)
+	l := make([]byte, aesBlockSize)
+	soft.Encrypt(l, l)
+	tag := make([]byte, aesBlockSize)
+	soft.Encrypt(tag, cmacSubkey(l))
+
+	t.Run("ECB", func(t *testing.T) {
+		kcv, err := KeyCheckValue(ctx, ss, key, KCVMethodECB)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(kcv, l[:kcvLengthECB]) {
+			t.Errorf("kcv: got %X, want %X", kcv, l[:kcvLengthECB])
+		}
+	})
+
+	t.Run("CMAC", func(t *testing.T) {
+		ok, err := VerifyKeyCheckValue(ctx, ss, key, "", tag[:kcvLengthCMAC])
+		if err != nil {
+			t.Error(err)
+		}
+		if !ok {
+			t.Error("kcv not verified")
+		}
+	})
+}
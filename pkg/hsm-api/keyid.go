@@ -0,0 +1,115 @@
+package hsm_api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gemalto/pkcs11"
+)
+
+var (
+	// ErrDuplicateKey is returned when a new key would share the label or the id of a token key.
+	ErrDuplicateKey = errors.New("key already exists")
+	// ErrAmbiguousKey is returned when a label names several keys and no id tells them apart.
+	ErrAmbiguousKey = errors.New("several keys match")
+	// ErrInvalidKeyID is returned when the id of a new key is not a UUID.
+	ErrInvalidKeyID = errors.New("key id is not a UUID")
+)
+
+// NewKeyID returns a random UUID (version 4) used as the CKA_ID of a new key.
+func NewKeyID() ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, fmt.Errorf("failed to generate key id: %v", err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id, nil
+}
+
+// CheckKeyID fails with ErrInvalidKeyID unless the id is an RFC 4122 UUID of 16 bytes.
+func CheckKeyID(id []byte) error {
+	if len(id) != 16 || id[8]>>6 != 2 || id[6]>>4 < 1 || id[6]>>4 > 5 {
+		return fmt.Errorf("%w: %x", ErrInvalidKeyID, id)
+	}
+	return nil
+}
+
+// CheckNewKey fails with ErrDuplicateKey when a token key already has the label, or the id
+// when it is not empty. Key ring versions share their label and are generated without it.
+func CheckNewKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte) error {
+	for _, attr := range []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	} {
+		if len(attr.Value) == 0 {
+			continue
+		}
+		for _, class := range []uint{pkcs11.CKO_SECRET_KEY, pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY} {
+			objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+				pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+				pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+				attr,
+			})
+			if err != nil {
+				return err
+			}
+			if len(objs) > 0 {
+				if attr.Type == pkcs11.CKA_LABEL {
+					return fmt.Errorf("%w: label %s is in use", ErrDuplicateKey, label)
+				}
+				return fmt.Errorf("%w: id %x is in use", ErrDuplicateKey, id)
+			}
+		}
+	}
+	return nil
+}
+
+// FindKey returns the key of the class with the id, or with the label when the id is empty.
+// When both are set the key must have both. It fails with ErrAmbiguousKey when the label
+// alone names several keys, such as the versions of a key ring.
+func FindKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, keyClass uint, label string, id []byte) (pkcs11.ObjectHandle, error) {
+	if label == "" && len(id) == 0 {
+		return 0, fmt.Errorf("missing key label or id")
+	}
+
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, keyClass)}
+	if label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, label))
+	}
+	if len(id) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+	}
+
+	objs, err := FindObjects(ctx, ss, template)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case len(objs) == 0:
+		return 0, fmt.Errorf("not found key")
+	case len(objs) > 1 && len(id) == 0:
+		return 0, fmt.Errorf("%w: %d keys with label %s, set the id", ErrAmbiguousKey, len(objs), label)
+	case len(objs) > 1:
+		return 0, fmt.Errorf("%w: %d keys with id %x", ErrAmbiguousKey, len(objs), id)
+	}
+	return objs[0], nil
+}
+
+// uniqueKeyID checks that the label and id are free and returns the id, a new one when empty.
+func uniqueKeyID(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte) ([]byte, error) {
+	if len(id) > 0 {
+		if err := CheckKeyID(id); err != nil {
+			return nil, err
+		}
+	}
+	if err := CheckNewKey(ctx, ss, label, id); err != nil {
+		return nil, err
+	}
+	if len(id) > 0 {
+		return id, nil
+	}
+	return NewKeyID()
+}
@@ -0,0 +1,40 @@
+package hsm_api
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewKeyID(t *testing.T) {
+	a, err := NewKeyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewKeyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a) != 16 || bytes.Equal(a, b) {
+		t.Fatalf("got ids %x and %x", a, b)
+	}
+	if a[6]>>4 != 4 || a[8]>>6 != 2 {
+		t.Errorf("id %x is not a version 4 UUID", a)
+	}
+}
+
+func TestCheckKeyID(t *testing.T) {
+	id, err := NewKeyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckKeyID(id); err != nil {
+		t.Error(err)
+	}
+	for _, id := range [][]byte{nil, {0x01}, []byte("n2k-master-key/1"), make([]byte, 16)} {
+		if err := CheckKeyID(id); !errors.Is(err, ErrInvalidKeyID) {
+			t.Errorf("%x: got %v, want ErrInvalidKeyID", id, err)
+		}
+	}
+}
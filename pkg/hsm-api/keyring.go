@@ -0,0 +1,573 @@
+package hsm_api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// A key ring is the set of secret keys sharing a label, each with its own UUID CKA_ID like
+// any other key. The version of each key and whether it is enabled are kept by CKA_ID in a
+// ring record, a token data object next to the keys, so the attributes of the keys are
+// never changed to track them. Version 0 is the key the ring started from before it was
+// ever rotated. Keys missing from the record are added on first use: the CKA_ID
+// "<label>/<version>" given by earlier rotations tells the version, any other id is
+// version 0. A number is never given twice: the record counts the versions and keeps the
+// ones whose key was deleted, a key found later under a used number gets the next one, so
+// a cipher text never decrypts under another key than the one it was encrypted with. The
+// primary version is the highest enabled one, the others only decrypt until they are
+// disabled.
+//
+// The rings are cached once read, the record is only written when the ring is rotated,
+// a version is enabled, disabled or deleted, or the keys of the token changed.
+
+// CKA_APPLICATION of the ring records
+const RingApplication = "hsm-keyring"
+
+// ErrKeyVersionDestroyed is returned for a version whose key was destroyed at the end of
+// its lifecycle, its cipher texts can no longer be decrypted.
+var ErrKeyVersionDestroyed = errors.New("key version was destroyed")
+
+// KeyVersion is one version of a key ring. Since is when the version was created, or
+// first seen in a ring for keys created before the ring records. Operations is the number
+// of operations recorded with AddKeyVersionOperations.
+type KeyVersion struct {
+	Handle     pkcs11.ObjectHandle `json:"-"`
+	ID         []byte              `json:"id,omitempty"`
+	Version    int                 `json:"version"`
+	Primary    bool                `json:"primary"`
+	Enabled    bool                `json:"enabled"`
+	Since      time.Time           `json:"since,omitempty"`
+	Operations int                 `json:"operations"`
+}
+
+type (
+	// Next is the version the next key of the ring gets.
+	ringRecord struct {
+		Label    string           `json:"label"`
+		Next     int              `json:"next"`
+		Versions []*versionRecord `json:"versions"`
+	}
+
+	// Deleted versions are kept so their number is not given again, Destroyed tells the ones
+	// deleted by a lifecycle transition.
+	versionRecord struct {
+		ID         string    `json:"id"`
+		Version    int       `json:"version"`
+		Disabled   bool      `json:"disabled,omitempty"`
+		Deleted    bool      `json:"deleted,omitempty"`
+		Destroyed  bool      `json:"destroyed,omitempty"`
+		Created    time.Time `json:"created"`
+		Operations int       `json:"operations,omitempty"`
+	}
+
+	// cachedRing is a ring as last read or written, versions are the ones with a key,
+	// oldest first.
+	cachedRing struct {
+		r        *ringRecord
+		obj      pkcs11.ObjectHandle
+		versions []*KeyVersion
+	}
+)
+
+// ringMu guards the cached rings, the operations only take it for reading once their ring
+// is cached.
+var (
+	ringMu sync.RWMutex
+	rings  = map[string]*cachedRing{}
+)
+
+// parseVersionID returns the version of a CKA_ID "<ring>/<version>" of the rotations that
+// predate the ring records, 0 for any other id and false for an invalid version id of the ring.
+func parseVersionID(ring string, id []byte) (int, bool) {
+	s := string(id)
+	if !strings.HasPrefix(s, ring+"/") {
+		return 0, true
+	}
+	v, err := strconv.Atoi(strings.TrimPrefix(s, ring+"/"))
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// KeyVersions lists the versions of the key ring, oldest first.
+func KeyVersions(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) ([]*KeyVersion, error) {
+	var versions []*KeyVersion
+	err := readRing(ctx, ss, ring, func(c *cachedRing) error {
+		for _, v := range c.versions {
+			copied := *v
+			versions = append(versions, &copied)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// PrimaryKeyVersion returns the version new data is encrypted with, the highest enabled one.
+func PrimaryKeyVersion(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) (*KeyVersion, error) {
+	var primary *KeyVersion
+	err := readRing(ctx, ss, ring, func(c *cachedRing) error {
+		for _, v := range c.versions {
+			if v.Primary {
+				copied := *v
+				primary = &copied
+				return nil
+			}
+		}
+		return fmt.Errorf("key ring %s has no enabled version", ring)
+	})
+	return primary, err
+}
+
+// FindKeyVersion returns an enabled version of the key ring.
+func FindKeyVersion(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, version int) (*KeyVersion, error) {
+	var found *KeyVersion
+	err := readRing(ctx, ss, ring, func(c *cachedRing) error {
+		for _, v := range c.versions {
+			if v.Version != version {
+				continue
+			}
+			if !v.Enabled {
+				return fmt.Errorf("version %d of key ring %s is disabled", version, ring)
+			}
+			copied := *v
+			found = &copied
+			return nil
+		}
+		for _, vr := range c.r.Versions {
+			if vr.Version == version && vr.Destroyed {
+				return fmt.Errorf("version %d of key ring %s: %w", version, ring, ErrKeyVersionDestroyed)
+			}
+			if vr.Version == version && vr.Deleted {
+				return fmt.Errorf("version %d of key ring %s was deleted", version, ring)
+			}
+		}
+		return fmt.Errorf("not found version %d of key ring %s", version, ring)
+	})
+	return found, err
+}
+
+// RemoveKeyVersions marks the versions of the ring whose keys were removed as deleted, and
+// drops the cached ring. It is called once keys of the label are deleted.
+func RemoveKeyVersions(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) error {
+	return removeKeyVersions(ctx, ss, ring, false)
+}
+
+// DestroyKeyVersions is RemoveKeyVersions for keys destroyed by a lifecycle transition, their
+// versions are also marked destroyed.
+func DestroyKeyVersions(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) error {
+	return removeKeyVersions(ctx, ss, ring, true)
+}
+
+func removeKeyVersions(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, destroyed bool) error {
+	ringMu.Lock()
+	defer ringMu.Unlock()
+	delete(rings, ring)
+
+	r, recObj, err := loadRing(ctx, ss, ring)
+	if err != nil || recObj == 0 {
+		return err
+	}
+	live, err := ringKeyIDs(ctx, ss, ring)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, vr := range r.Versions {
+		if !live[vr.ID] && !vr.Deleted {
+			vr.Deleted, vr.Destroyed = true, destroyed
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	_, err = saveRing(ctx, ss, r, recObj)
+	return err
+}
+
+// readRing runs f with the cached ring under the read lock, the ring is loaded first when
+// it is not cached. f must not change the ring.
+func readRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, f func(c *cachedRing) error) error {
+	ringMu.RLock()
+	if c, ok := rings[ring]; ok {
+		defer ringMu.RUnlock()
+		return f(c)
+	}
+	ringMu.RUnlock()
+
+	ringMu.Lock()
+	defer ringMu.Unlock()
+	c, err := cachedRingLocked(ctx, ss, ring)
+	if err != nil {
+		return err
+	}
+	return f(c)
+}
+
+// updateRing runs f with the ring under the write lock, f saves the record it changes. The
+// cached ring is dropped when f fails, it is read again from the token on next use.
+func updateRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, f func(c *cachedRing) error) error {
+	ringMu.Lock()
+	defer ringMu.Unlock()
+
+	c, err := cachedRingLocked(ctx, ss, ring)
+	if err != nil {
+		return err
+	}
+	if err := f(c); err != nil {
+		delete(rings, ring)
+		return err
+	}
+	c.refresh()
+	return nil
+}
+
+// cachedRingLocked returns the cached ring or loads it, the caller holds ringMu for writing.
+func cachedRingLocked(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) (*cachedRing, error) {
+	if c, ok := rings[ring]; ok {
+		return c, nil
+	}
+	c, err := loadVersions(ctx, ss, ring)
+	if err != nil {
+		return nil, err
+	}
+	rings[ring] = c
+	return c, nil
+}
+
+// loadVersions reads the keys of the ring and its record, the record is saved when keys
+// were added to it or removed from the token.
+func loadVersions(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) (*cachedRing, error) {
+	r, recObj, err := loadRing(ctx, ss, ring)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, ring),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cachedRing{r: r}
+	changed := false
+	live := map[string]bool{}
+	seen := map[int]bool{}
+	for _, obj := range objs {
+		attrs, err := GetAttributes(ctx, ss, obj, pkcs11.CKA_ID, pkcs11.CKA_START_DATE)
+		if err != nil {
+			return nil, err
+		}
+		id := attrs[pkcs11.CKA_ID]
+
+		vr := r.version(id)
+		switch {
+		case vr == nil:
+			v, ok := parseVersionID(ring, id)
+			if !ok {
+				continue
+			}
+			if r.used(v) {
+				v = r.Next
+			}
+			created := AttributeDate(attrs[pkcs11.CKA_START_DATE])
+			if created.IsZero() {
+				created = time.Now().UTC()
+			}
+			vr = &versionRecord{ID: hex.EncodeToString(id), Version: v, Created: created}
+			r.Versions = append(r.Versions, vr)
+			changed = true
+		case vr.Deleted:
+			// restored from a backup
+			vr.Deleted, vr.Destroyed = false, false
+			changed = true
+		}
+		if vr.Version >= r.Next {
+			r.Next = vr.Version + 1
+			changed = true
+		}
+		if seen[vr.Version] {
+			return nil, fmt.Errorf("key ring %s has several keys of version %d", ring, vr.Version)
+		}
+		seen[vr.Version] = true
+		live[vr.ID] = true
+
+		c.versions = append(c.versions, &KeyVersion{Handle: obj, ID: id, Version: vr.Version})
+	}
+	if len(c.versions) == 0 {
+		for _, vr := range r.Versions {
+			if vr.Destroyed {
+				return nil, fmt.Errorf("key ring %s: %w", ring, ErrKeyVersionDestroyed)
+			}
+		}
+		return nil, fmt.Errorf("not found key ring %s", ring)
+	}
+
+	for _, vr := range r.Versions {
+		if !live[vr.ID] && !vr.Deleted {
+			vr.Deleted = true
+			changed = true
+		}
+	}
+	if changed {
+		if recObj, err = saveRing(ctx, ss, r, recObj); err != nil {
+			return nil, err
+		}
+	}
+	c.obj = recObj
+
+	sort.Slice(c.versions, func(i, j int) bool {
+		return c.versions[i].Version < c.versions[j].Version
+	})
+	c.refresh()
+	return c, nil
+}
+
+// refresh copies the state of the record into the versions and marks the primary one.
+func (c *cachedRing) refresh() {
+	for _, v := range c.versions {
+		vr := c.r.version(v.ID)
+		v.Enabled = !vr.Disabled
+		v.Since = vr.Created
+		v.Operations = vr.Operations
+		v.Primary = false
+	}
+	for i := len(c.versions) - 1; i >= 0; i-- {
+		if c.versions[i].Enabled {
+			c.versions[i].Primary = true
+			break
+		}
+	}
+}
+
+func (c *cachedRing) save(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle) error {
+	obj, err := saveRing(ctx, ss, c.r, c.obj)
+	if err != nil {
+		return err
+	}
+	c.obj = obj
+	return nil
+}
+
+// RotateKey generates the next version of the key ring with the type, usage and
+// extractability of the primary and makes it the primary, the previous one is left for
+// decryption only. The new version gets the allowed mechanisms of the primary, those of the
+// token or of the service records the caller passes and keeps for the new version when the
+// token does not store them.
+func RotateKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, allowed []uint) (*KeyVersion, error) {
+	var rotated *KeyVersion
+	err := updateRing(ctx, ss, ring, func(c *cachedRing) error {
+		primary := c.versions[len(c.versions)-1]
+		for _, v := range c.versions {
+			if v.Primary {
+				primary = v
+			}
+		}
+
+		t, err := versionTemplate(ctx, ss, primary.Handle)
+		if err != nil {
+			return fmt.Errorf("key ring %s: %v", ring, err)
+		}
+		t.AllowedMechanisms = allowed
+
+		next := c.r.Next
+		id, err := NewKeyID()
+		if err != nil {
+			return err
+		}
+		objs, err := t.generate(ctx, ss, ring, id)
+		if err != nil {
+			return fmt.Errorf("failed to generate version %d: %v", next, err)
+		}
+		obj := objs[0]
+
+		now := time.Now().UTC()
+		c.r.Versions = append(c.r.Versions, &versionRecord{ID: hex.EncodeToString(id), Version: next, Created: now})
+		c.r.Next = next + 1
+		if err := c.save(ctx, ss); err != nil {
+			RemoveKey(ctx, ss, obj)
+			RemoveRetailMacKey(ctx, ss, ring, id)
+			return err
+		}
+		rotated = &KeyVersion{Handle: obj, ID: id, Version: next}
+		c.versions = append(c.versions, rotated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	copied := *rotated
+	return &copied, nil
+}
+
+// versionTemplate returns the template of a new version of the key: its type, usage,
+// extractability, wrap with trusted and K1 key of the retail MAC.
+func versionTemplate(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle) (*KeyTemplate, error) {
+	info, err := DescribeKey(ctx, ss, key)
+	if err != nil {
+		return nil, err
+	}
+	keyType := SecretKeyTypeOf(info)
+	t, err := DefaultKeyTemplate(keyType, info.Extractable)
+	if err != nil || t.Class != pkcs11.CKO_SECRET_KEY {
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+
+	t.Usage = nil
+	for _, u := range usageAttributes {
+		for _, name := range info.Usage {
+			if name == u.name {
+				t.Usage = append(t.Usage, u.attr)
+			}
+		}
+	}
+	attrs, err := GetAttributes(ctx, ss, key, pkcs11.CKA_WRAP_WITH_TRUSTED)
+	if err != nil {
+		return nil, err
+	}
+	t.WrapWithTrusted = AttributeBool(attrs[pkcs11.CKA_WRAP_WITH_TRUSTED])
+	if t.KeyType == pkcs11.CKK_DES2 {
+		_, err := retailMacKey(ctx, ss, key)
+		t.RetailMac = err == nil
+	}
+	return t, nil
+}
+
+// SetKeyVersionEnabled disables a version once no data is encrypted under it anymore, or
+// enables it back. The primary version cannot be disabled.
+func SetKeyVersionEnabled(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, version int, enabled bool) error {
+	return updateRing(ctx, ss, ring, func(c *cachedRing) error {
+		for _, v := range c.versions {
+			if v.Version != version {
+				continue
+			}
+			if v.Primary && !enabled {
+				return fmt.Errorf("cannot disable the primary version of key ring %s", ring)
+			}
+			c.r.version(v.ID).Disabled = !enabled
+			return c.save(ctx, ss)
+		}
+		return fmt.Errorf("not found version %d of key ring %s", version, ring)
+	})
+}
+
+// AddKeyVersionOperations adds n operations to the count of the version in the ring record.
+func AddKeyVersionOperations(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string, version, n int) error {
+	return updateRing(ctx, ss, ring, func(c *cachedRing) error {
+		for _, v := range c.versions {
+			if v.Version == version {
+				c.r.version(v.ID).Operations += n
+				return c.save(ctx, ss)
+			}
+		}
+		return fmt.Errorf("not found version %d of key ring %s", version, ring)
+	})
+}
+
+// SecretKeyTypeOf returns the KeyType* name of a described secret key.
+func SecretKeyTypeOf(info *KeyInfo) string {
+	if info.KeyType == "AES" {
+		return fmt.Sprintf("AES%d", info.Bits)
+	}
+	return info.KeyType
+}
+
+func (r *ringRecord) version(id []byte) *versionRecord {
+	want := hex.EncodeToString(id)
+	for _, v := range r.Versions {
+		if v.ID == want {
+			return v
+		}
+	}
+	return nil
+}
+
+// used reports whether a key of the ring has, or had, the version.
+func (r *ringRecord) used(version int) bool {
+	for _, v := range r.Versions {
+		if v.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func loadRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) (*ringRecord, pkcs11.ObjectHandle, error) {
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, RingApplication),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, ring),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(objs) == 0 {
+		return &ringRecord{Label: ring}, 0, nil
+	}
+
+	attrs, err := GetAttributes(ctx, ss, objs[0], pkcs11.CKA_VALUE)
+	if err != nil {
+		return nil, 0, err
+	}
+	r := &ringRecord{}
+	if err := json.Unmarshal(attrs[pkcs11.CKA_VALUE], r); err != nil {
+		return nil, 0, fmt.Errorf("invalid ring record of %s: %v", ring, err)
+	}
+	return r, objs[0], nil
+}
+
+func saveRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, r *ringRecord, obj pkcs11.ObjectHandle) (pkcs11.ObjectHandle, error) {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal ring record: %v", err)
+	}
+
+	if obj != 0 {
+		err = ctx.SetAttributeValue(ss, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, value)})
+	} else {
+		obj, err = ctx.CreateObject(ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, RingApplication),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, r.Label),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to save ring record: %v", err)
+	}
+	return obj, nil
+}
+
+// ringKeyIDs returns the hex CKA_ID of the secret keys of the ring.
+func ringKeyIDs(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) (map[string]bool, error) {
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, ring),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, obj := range objs {
+		attrs, err := GetAttributes(ctx, ss, obj, pkcs11.CKA_ID)
+		if err != nil {
+			return nil, err
+		}
+		ids[hex.EncodeToString(attrs[pkcs11.CKA_ID])] = true
+	}
+	return ids, nil
+}
@@ -0,0 +1,191 @@
+package hsm_api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestParseVersionID(t *testing.T) {
+	cases := []struct {
+		id      string
+		version int
+		ok      bool
+	}{
+		{"", 0, true},
+		{"n2k-master-key/3", 3, true},
+		{"n2k-master-key/0", 0, false},
+		{"n2k-master-key/x", 0, false},
+		{"other/3", 0, true},
+		{"01", 0, true},
+		{"\x5c\x1e\x2f\x94\x10\x3b\x4d\x8a\x9f\x33\x0e\x61\x7c\xd2\x05\xbb", 0, true},
+	}
+	for _, c := range cases {
+		v, ok := parseVersionID("n2k-master-key", []byte(c.id))
+		if v != c.version || ok != c.ok {
+			t.Errorf("%q: got %d %v, want %d %v", c.id, v, ok, c.version, c.ok)
+		}
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	const ring = "test-key-ring"
+	if _, err := CreateTypedSecretKey(ctx, ss, ring, KeyTypeAES256); err != nil {
+		t.Fatal(err)
+	}
+	defer removeTestRing(ctx, ss, ring)
+
+	v, err := RotateKey(ctx, ss, ring, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 1 {
+		t.Errorf("rotated version: got %d, want 1", v.Version)
+	}
+	if err := CheckKeyID(v.ID); err != nil {
+		t.Error(err)
+	}
+
+	primary, err := PrimaryKeyVersion(ctx, ss, ring)
+	if err != nil || primary.Version != 1 {
+		t.Errorf("primary: got %v, %v", primary, err)
+	}
+
+	// the version state is kept in the ring record, not in the usage of the keys
+	versions, err := KeyVersions(ctx, ss, ring)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("versions: got %v, %v", versions, err)
+	}
+	attrs, err := GetAttributes(ctx, ss, versions[0].Handle, pkcs11.CKA_ENCRYPT, pkcs11.CKA_DECRYPT)
+	if err != nil || !AttributeBool(attrs[pkcs11.CKA_ENCRYPT]) || !AttributeBool(attrs[pkcs11.CKA_DECRYPT]) {
+		t.Errorf("usage of version 0 changed: %v, %v", attrs, err)
+	}
+
+	if err := AddKeyVersionOperations(ctx, ss, ring, 1, 3); err != nil {
+		t.Error(err)
+	}
+	if primary, err := PrimaryKeyVersion(ctx, ss, ring); err != nil || primary.Operations != 3 || primary.Since.IsZero() {
+		t.Errorf("saved state of the primary: got %v, %v", primary, err)
+	}
+
+	if err := SetKeyVersionEnabled(ctx, ss, ring, 1, false); err == nil {
+		t.Error("expected error disabling the primary version")
+	}
+	if err := SetKeyVersionEnabled(ctx, ss, ring, 0, false); err != nil {
+		t.Error(err)
+	}
+	if _, err := FindKeyVersion(ctx, ss, ring, 0); err == nil {
+		t.Error("expected error for disabled version")
+	}
+
+	// a deleted version keeps its number, the next rotation does not reuse it
+	if _, err := RotateKey(ctx, ss, ring, nil); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := PrimaryKeyVersion(ctx, ss, ring)
+	if err != nil || v2.Version != 2 {
+		t.Fatalf("primary: got %v, %v", v2, err)
+	}
+	if _, err := DeleteKeys(ctx, ss, "secret", ring, v2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindKeyVersion(ctx, ss, ring, 2); err == nil || !strings.Contains(err.Error(), "deleted") {
+		t.Errorf("deleted version: got %v", err)
+	}
+	if primary, err := PrimaryKeyVersion(ctx, ss, ring); err != nil || primary.Version != 1 {
+		t.Errorf("primary after delete: got %v, %v", primary, err)
+	}
+	if v, err := RotateKey(ctx, ss, ring, nil); err != nil || v.Version != 3 {
+		t.Errorf("rotation after delete: got %v, %v", v, err)
+	}
+}
+
+func TestRingPrimary(t *testing.T) {
+	c := &cachedRing{
+		r: &ringRecord{Label: "r", Next: 3, Versions: []*versionRecord{
+			{ID: "00", Version: 0},
+			{ID: "01", Version: 1},
+			{ID: "02", Version: 2, Disabled: true},
+			{ID: "03", Version: 3, Deleted: true},
+		}},
+		versions: []*KeyVersion{{ID: []byte{0}, Version: 0}, {ID: []byte{1}, Version: 1}, {ID: []byte{2}, Version: 2}},
+	}
+	c.refresh()
+	for _, v := range c.versions {
+		if v.Primary != (v.Version == 1) {
+			t.Errorf("version %d: primary %v", v.Version, v.Primary)
+		}
+	}
+	if !c.r.used(3) || c.r.used(4) {
+		t.Error("deleted versions stay used")
+	}
+}
+
+// removeTestRing deletes the keys of the ring and its record, so the next run starts at
+// version 0 again.
+func removeTestRing(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, ring string) {
+	DeleteKeys(ctx, ss, "secret", ring, nil)
+	if _, obj, err := loadRing(ctx, ss, ring); err == nil && obj != 0 {
+		ctx.DestroyObject(ss, obj)
+	}
+}
+
+func TestRotateKeyPolicy(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	const ring = "test-key-ring-policy"
+	tmpl := &KeyTemplate{
+		Name:              "gcm-only",
+		Class:             pkcs11.CKO_SECRET_KEY,
+		KeyType:           pkcs11.CKK_AES,
+		Bits:              256,
+		Usage:             []uint{pkcs11.CKA_ENCRYPT, pkcs11.CKA_DECRYPT},
+		AllowedMechanisms: []uint{pkcs11.CKM_AES_GCM},
+	}
+	if _, err := GenerateFromTemplate(ctx, ss, tmpl, ring, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer removeTestRing(ctx, ss, ring)
+
+	v, err := RotateKey(ctx, ss, ring, tmpl.AllowedMechanisms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := DescribeKey(ctx, ss, v.Handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"encrypt", "decrypt"}; !reflect.DeepEqual(info.Usage, want) {
+		t.Errorf("usage: got %v, want %v", info.Usage, want)
+	}
+	if want := []string{"CKM_AES_GCM"}; !reflect.DeepEqual(info.AllowedMechanisms, want) {
+		t.Errorf("allowed mechanisms: got %v, want %v", info.AllowedMechanisms, want)
+	}
+	if info.Extractable {
+		t.Error("the rotated version of a non extractable key is extractable")
+	}
+}
@@ -0,0 +1,244 @@
+package hsm_api
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// RSA key pair types that can be generated
+const (
+	KeyTypeRSA2048 = "RSA2048"
+	KeyTypeRSA3072 = "RSA3072"
+	KeyTypeRSA4096 = "RSA4096"
+)
+
+var rsaKeyBits = map[string]int{
+	KeyTypeRSA2048: 2048,
+	KeyTypeRSA3072: 3072,
+	KeyTypeRSA4096: 4096,
+}
+
+// object classes by name
+var ClassNames = map[uint]string{
+	pkcs11.CKO_SECRET_KEY:  "secret",
+	pkcs11.CKO_PRIVATE_KEY: "private",
+	pkcs11.CKO_PUBLIC_KEY:  "public",
+	pkcs11.CKO_DATA:        "data",
+}
+
+// key types by name, AES keys of every size are AES
+var KeyTypeNames = map[uint]string{
+	pkcs11.CKK_AES:            "AES",
+	pkcs11.CKK_DES:            "DES",
+	pkcs11.CKK_DES2:           KeyTypeDES2,
+	pkcs11.CKK_DES3:           KeyTypeDES3,
+	pkcs11.CKK_GENERIC_SECRET: KeyTypeGenericSecret,
+	pkcs11.CKK_RSA:            "RSA",
+	pkcs11.CKK_EC:             "EC",
+}
+
+// usage attributes by name
+var usageAttributes = []struct {
+	name string
+	attr uint
+}{
+	{"encrypt", pkcs11.CKA_ENCRYPT},
+	{"decrypt", pkcs11.CKA_DECRYPT},
+	{"sign", pkcs11.CKA_SIGN},
+	{"verify", pkcs11.CKA_VERIFY},
+	{"wrap", pkcs11.CKA_WRAP},
+	{"unwrap", pkcs11.CKA_UNWRAP},
+	{"derive", pkcs11.CKA_DERIVE},
+}
+
+// KeyInfo is the description of a key object, it never holds key material.
+type KeyInfo struct {
+	Handle      pkcs11.ObjectHandle `json:"-"`
+	Class       string              `json:"class"`
+	KeyType     string              `json:"key_type"`
+	Label       string              `json:"label"`
+	ID          []byte              `json:"id,omitempty"`
+	Bits        int                 `json:"bits,omitempty"`
+	Usage       []string            `json:"usage"`
+	Sensitive   bool                `json:"sensitive"`
+	Extractable bool                `json:"extractable"`
+
+	// AllowedMechanisms is empty when the token does not restrict the mechanisms.
+	AllowedMechanisms []string `json:"allowed_mechanisms,omitempty"`
+}
+
+// ClassByName returns the object class of a ClassNames name.
+func ClassByName(name string) (uint, bool) {
+	for class, n := range ClassNames {
+		if n == name {
+			return class, true
+		}
+	}
+	return 0, false
+}
+
+// KeyTypeByName returns the key type of a KeyTypeNames name.
+func KeyTypeByName(name string) (uint, bool) {
+	for keyType, n := range KeyTypeNames {
+		if n == name {
+			return keyType, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateKey generates a token key of one of the KeyType* types, a secret key or an
+// RSA key pair, and returns all the created objects. The label and id must be free, a
+// new id is generated when it is empty.
+func GenerateKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte, keyType string, extractable bool) ([]pkcs11.ObjectHandle, error) {
+	if label == "" {
+		return nil, fmt.Errorf("missing key label")
+	}
+
+	t, err := DefaultKeyTemplate(keyType, extractable)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = uniqueKeyID(ctx, ss, label, id); err != nil {
+		return nil, err
+	}
+
+	objs, err := t.generate(ctx, ss, label, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+	return objs, nil
+}
+
+func DescribeKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) (*KeyInfo, error) {
+	types := []uint{
+		pkcs11.CKA_CLASS, pkcs11.CKA_KEY_TYPE, pkcs11.CKA_LABEL, pkcs11.CKA_ID,
+		pkcs11.CKA_VALUE_LEN, pkcs11.CKA_MODULUS_BITS, pkcs11.CKA_MODULUS,
+		pkcs11.CKA_SENSITIVE, pkcs11.CKA_EXTRACTABLE, pkcs11.CKA_ALLOWED_MECHANISMS,
+	}
+	for _, u := range usageAttributes {
+		types = append(types, u.attr)
+	}
+
+	attrs, err := GetAttributes(ctx, ss, obj, types...)
+	if err != nil {
+		return nil, err
+	}
+
+	keyType := bytesToUint(attrs[pkcs11.CKA_KEY_TYPE])
+	info := &KeyInfo{
+		Handle:      obj,
+		Class:       ClassNames[bytesToUint(attrs[pkcs11.CKA_CLASS])],
+		KeyType:     KeyTypeNames[keyType],
+		Label:       string(attrs[pkcs11.CKA_LABEL]),
+		ID:          attrs[pkcs11.CKA_ID],
+		Usage:       []string{},
+		Sensitive:   AttributeBool(attrs[pkcs11.CKA_SENSITIVE]),
+		Extractable: AttributeBool(attrs[pkcs11.CKA_EXTRACTABLE]),
+	}
+
+	switch {
+	case keyType == pkcs11.CKK_DES2:
+		info.Bits = 128
+	case keyType == pkcs11.CKK_DES3:
+		info.Bits = 192
+	case attrs[pkcs11.CKA_VALUE_LEN] != nil:
+		info.Bits = int(bytesToUint(attrs[pkcs11.CKA_VALUE_LEN])) * 8
+	case attrs[pkcs11.CKA_MODULUS_BITS] != nil:
+		info.Bits = int(bytesToUint(attrs[pkcs11.CKA_MODULUS_BITS]))
+	case attrs[pkcs11.CKA_MODULUS] != nil:
+		info.Bits = len(attrs[pkcs11.CKA_MODULUS]) * 8
+	}
+
+	for _, u := range usageAttributes {
+		if AttributeBool(attrs[u.attr]) {
+			info.Usage = append(info.Usage, u.name)
+		}
+	}
+	for _, m := range MechanismList(attrs[pkcs11.CKA_ALLOWED_MECHANISMS]) {
+		info.AllowedMechanisms = append(info.AllowedMechanisms, MechanismName(m))
+	}
+	return info, nil
+}
+
+// ListKeys describes the token keys matching the filters, an empty filter matches all.
+// Keys are sorted by label then class.
+func ListKeys(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, class, label, keyType string) ([]*KeyInfo, error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true)}
+	if label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, label))
+	}
+	if keyType != "" {
+		kt, ok := KeyTypeByName(keyType)
+		if !ok {
+			return nil, fmt.Errorf("unknown key type: %s", keyType)
+		}
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, kt))
+	}
+
+	classes := []uint{pkcs11.CKO_SECRET_KEY, pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY}
+	if class != "" {
+		c, ok := ClassByName(class)
+		if !ok {
+			return nil, fmt.Errorf("unknown key class: %s", class)
+		}
+		classes = []uint{c}
+	}
+
+	keys := []*KeyInfo{}
+	for _, c := range classes {
+		objs, err := FindObjects(ctx, ss, append(template, pkcs11.NewAttribute(pkcs11.CKA_CLASS, c)))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			info, err := DescribeKey(ctx, ss, obj)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, info)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Label < keys[j].Label
+	})
+	return keys, nil
+}
+
+// DeleteKeys destroys the token keys with the label, and the id when it is not empty.
+// An empty class removes both halves of a key pair.
+func DeleteKeys(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, class, label string, id []byte) (int, error) {
+	if label == "" {
+		return 0, fmt.Errorf("missing key label")
+	}
+
+	keys, err := ListKeys(ctx, ss, class, label, "")
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, k := range keys {
+		if len(id) > 0 && !bytes.Equal(k.ID, id) {
+			continue
+		}
+		if err := RemoveKey(ctx, ss, k.Handle); err != nil {
+			return deleted, err
+		}
+		if err := RemoveRetailMacKey(ctx, ss, label, k.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return 0, fmt.Errorf("not found key")
+	}
+	if err := RemoveKeyVersions(ctx, ss, label); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,50 @@
+package hsm_api
+
+import (
+	"testing"
+)
+
+func TestGenerateListDeleteKeys(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	const label = "test-key-management"
+	id, err := NewKeyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := GenerateKey(ctx, ss, label, id, KeyTypeRSA2048, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("got %d objects, want the private and public key", len(objs))
+	}
+
+	keys, err := ListKeys(ctx, ss, "", label, "RSA")
+	if err != nil {
+		t.Error(err)
+	}
+	for _, k := range keys {
+		if k.Bits != 2048 || k.Extractable {
+			t.Errorf("%s key: got %d bits, extractable %v", k.Class, k.Bits, k.Extractable)
+		}
+	}
+
+	deleted, err := DeleteKeys(ctx, ss, "", label, id)
+	if err != nil {
+		t.Error(err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d objects, want 2", deleted)
+	}
+}
@@ -0,0 +1,108 @@
+package hsm_api
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// KeyWrapPad wraps the key with the kek using AES key wrap with padding (RFC 5649),
+// the format unwrapped by CKM_AES_KEY_WRAP_PAD.
+func KeyWrapPad(kek, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kek: %v", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty key")
+	}
+
+	// alternative initial value with the message length indicator
+	aiv := []byte{0xa6, 0x59, 0x59, 0xa6, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(aiv[4:], uint32(len(key)))
+
+	padded := make([]byte, (len(key)+7)/8*8)
+	copy(padded, key)
+
+	buf := make([]byte, 16)
+	if len(padded) == 8 {
+		copy(buf, aiv)
+		copy(buf[8:], padded)
+		block.Encrypt(buf, buf)
+		return buf, nil
+	}
+
+	// RFC 3394 wrapping process with the alternative initial value
+	n := len(padded) / 8
+	a := aiv
+	r := padded
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, a)
+			copy(buf[8:], r[(i-1)*8:i*8])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(r[(i-1)*8:i*8], buf[8:])
+		}
+	}
+
+	return append(append([]byte{}, a...), r...), nil
+}
+
+// UnwrapSecretKey brings a clear key value into the token without C_CreateObject on the
+// target: the value is wrapped under an ephemeral session transport key and unwrapped
+// with the template. The transport key is destroyed afterwards. A private key value is
+// its PKCS#8 encoding.
+func UnwrapSecretKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, value []byte, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	kek := GenIV(32)
+	if kek == nil {
+		return 0, fmt.Errorf("failed to generate transport key")
+	}
+	defer zero(kek)
+
+	wrapped, err := KeyWrapPad(kek, value)
+	if err != nil {
+		return 0, err
+	}
+
+	tk, err := ImportTransportKey(ctx, ss, kek, pkcs11.CKA_UNWRAP)
+	if err != nil {
+		return 0, err
+	}
+	defer RemoveKey(ctx, ss, tk)
+
+	return UnwrapKey(ctx, ss, tk, wrapped, template)
+}
+
+// ImportTransportKey creates a non-extractable session AES key from the clear kek,
+// usage lists the CKA_WRAP/CKA_UNWRAP attributes set on it.
+func ImportTransportKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, kek []byte, usage ...uint) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, kek),
+	}
+	for _, u := range usage {
+		template = append(template, pkcs11.NewAttribute(u, true))
+	}
+
+	tk, err := ctx.CreateObject(ss, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import transport key: %v", err)
+	}
+	return tk, nil
+}
+
+// zero wipes secret material once it is no longer needed.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,34 @@
+package hsm_api
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 5649 section 6 samples
+func TestKeyWrapPad(t *testing.T) {
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+
+	cases := []struct {
+		key     string
+		wrapped string
+	}{
+		{"c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{"466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+	}
+
+	for _, c := range cases {
+		key, _ := hex.DecodeString(c.key)
+		wrapped, err := KeyWrapPad(kek, key)
+		if err != nil {
+			t.Error(err)
+		}
+		if hex.EncodeToString(wrapped) != c.wrapped {
+			t.Errorf("wrap %s: got %x, want %s", c.key, wrapped, c.wrapped)
+		}
+	}
+
+	if _, err := KeyWrapPad(kek[:5], []byte{1}); err == nil {
+		t.Error("expected error for invalid kek")
+	}
+}
@@ -0,0 +1,282 @@
+package hsm_api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ISO/IEC 9797-1 MAC algorithms and padding methods.
+const (
+	MacAlgorithm1 = 1 // CBC-MAC with the whole key
+	MacAlgorithm3 = 3 // retail MAC: single DES CBC-MAC, 3DES on the last block
+
+	MacPadding1 = 1 // zeros up to the block boundary
+	MacPadding2 = 2 // 0x80 followed by zeros
+
+	desBlockSize = 8
+	MinMacLength = 4
+)
+
+// ErrNoRetailMacKey is returned for a retail MAC of more than one block with a DES2 key
+// created without its K1 key, see RetailMacKeyLabel.
+var ErrNoRetailMacKey = errors.New("mac algorithm 3 over more than one block needs a DES2 key created with its K1 key (retail_mac)")
+
+// the K1 key of a retail MAC key has the label and id of the DES2 key with this suffix
+const retailMacSuffix = "/k1"
+
+// MacPad pads data to a multiple of the DES block size using ISO 9797-1 padding method 1 or 2.
+func MacPad(data []byte, method int) ([]byte, error) {
+	switch method {
+	case MacPadding1:
+		n := len(data) % desBlockSize
+		if n == 0 && len(data) > 0 {
+			return data, nil
+		}
+		return append(append([]byte{}, data...), make([]byte, desBlockSize-n)...), nil
+	case MacPadding2:
+		padded := append(append([]byte{}, data...), 0x80)
+		if n := len(padded) % desBlockSize; n != 0 {
+			padded = append(padded, make([]byte, desBlockSize-n)...)
+		}
+		return padded, nil
+	}
+	return nil, fmt.Errorf("unsupported padding method: %d", method)
+}
+
+// GenerateMac computes the 8 bytes ISO 9797-1 MAC of data with a DES/3DES key.
+// Algorithm 3 requires a double length key (DES2), and its K1 key when the padded data is
+// longer than one block.
+func GenerateMac(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, algorithm, padding int, data []byte) ([]byte, error) {
+	padded, err := MacPad(data, padding)
+	if err != nil {
+		return nil, err
+	}
+
+	keyType, err := GetKeyType(ctx, ss, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch algorithm {
+	case MacAlgorithm1:
+		return cbcMac(ctx, ss, key, keyType, padded)
+	case MacAlgorithm3:
+		return retailMac(ctx, ss, key, keyType, padded)
+	}
+	return nil, fmt.Errorf("unsupported mac algorithm: %d", algorithm)
+}
+
+// VerifyMac recomputes the MAC and compares it with the (possibly truncated) mac.
+func VerifyMac(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, algorithm, padding int, data, mac []byte) (bool, error) {
+	if len(mac) < MinMacLength || len(mac) > desBlockSize {
+		return false, fmt.Errorf("invalid mac length: %d", len(mac))
+	}
+
+	expected, err := GenerateMac(ctx, ss, key, algorithm, padding, data)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(expected[:len(mac)], mac) == 1, nil
+}
+
+// MacMechanism returns the mechanism the key itself is used with for the MAC algorithm,
+// the retail MAC also runs CKM_DES_CBC with the K1 key.
+func MacMechanism(keyType uint, algorithm int) (uint, error) {
+	switch algorithm {
+	case MacAlgorithm1:
+		return cbcMacMechanism(keyType)
+	case MacAlgorithm3:
+		return pkcs11.CKM_DES3_ECB, nil
+	}
+	return 0, fmt.Errorf("unsupported mac algorithm: %d", algorithm)
+}
+
+func cbcMacMechanism(keyType uint) (uint, error) {
+	switch keyType {
+	case pkcs11.CKK_DES:
+		return pkcs11.CKM_DES_CBC, nil
+	case pkcs11.CKK_DES2, pkcs11.CKK_DES3:
+		return pkcs11.CKM_DES3_CBC, nil
+	}
+	return 0, fmt.Errorf("unsupported key type for mac: %#x", keyType)
+}
+
+// cbcMac encrypts the padded data in CBC mode with a zero iv and keeps the last block.
+func cbcMac(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, keyType uint, padded []byte) ([]byte, error) {
+	mech, err := cbcMacMechanism(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := Encrypt(ctx, ss, key, mech, padded, make([]byte, desBlockSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher[len(cipher)-desBlockSize:], nil
+}
+
+// retailMac runs the single DES CBC-MAC with K1, the left half of the key, over all blocks
+// but the last one. The last step E(K1, D(K2, E(K1, x))) is the 3DES encryption of the last
+// chained block, so it is done with the whole key. K1 is a key of its own, created with the
+// DES2 key, since tokens such as SoftHSM2 cannot derive it.
+func retailMac(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, keyType uint, padded []byte) ([]byte, error) {
+	if keyType != pkcs11.CKK_DES2 {
+		return nil, fmt.Errorf("mac algorithm 3 requires a double length key")
+	}
+
+	head, last := padded[:len(padded)-desBlockSize], padded[len(padded)-desBlockSize:]
+
+	chain := make([]byte, desBlockSize)
+	if len(head) > 0 {
+		k1, err := retailMacKey(ctx, ss, key)
+		if err != nil {
+			return nil, err
+		}
+
+		chain, err = cbcMac(ctx, ss, k1, pkcs11.CKK_DES, head)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	block := make([]byte, desBlockSize)
+	for i := range block {
+		block[i] = last[i] ^ chain[i]
+	}
+
+	mac, err := Encrypt(ctx, ss, key, pkcs11.CKM_DES3_ECB, block, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return mac[:desBlockSize], nil
+}
+
+// RetailMacKeyLabel returns the label and id of the K1 key of the DES2 key.
+func RetailMacKeyLabel(label string, id []byte) (string, []byte) {
+	return label + retailMacSuffix, append(append([]byte{}, id...), retailMacSuffix...)
+}
+
+// IsRetailMacKeyLabel tells whether the label is the one of a K1 key.
+func IsRetailMacKeyLabel(label string) bool {
+	return strings.HasSuffix(label, retailMacSuffix)
+}
+
+// retailMacKey finds the K1 key of the DES2 key.
+func retailMacKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle) (pkcs11.ObjectHandle, error) {
+	attrs, err := GetAttributes(ctx, ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID)
+	if err != nil {
+		return 0, err
+	}
+	label, id := RetailMacKeyLabel(string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID])
+
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_DES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(objs) != 1 {
+		return 0, ErrNoRetailMacKey
+	}
+	return objs[0], nil
+}
+
+// ImportRetailMacKey unwraps the 16 bytes of a DES2 key into a key of the template, and its
+// first 8 bytes into the K1 key that computes the chained blocks of the retail MAC. K1 only
+// encrypts with CKM_DES_CBC and is never extractable.
+func ImportRetailMacKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, value []byte, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if len(value) != 2*desBlockSize {
+		return 0, fmt.Errorf("a retail mac key has %d bytes, got %d", 2*desBlockSize, len(value))
+	}
+
+	var label string
+	var id []byte
+	token := true
+	for _, a := range template {
+		switch a.Type {
+		case pkcs11.CKA_LABEL:
+			label = string(a.Value)
+		case pkcs11.CKA_ID:
+			id = a.Value
+		case pkcs11.CKA_TOKEN:
+			token = AttributeBool(a.Value)
+		}
+	}
+	label, id = RetailMacKeyLabel(label, id)
+
+	key, err := UnwrapSecretKey(ctx, ss, value, template)
+	if err != nil {
+		return 0, err
+	}
+
+	k1Template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_DES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, token),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_ALLOWED_MECHANISMS, mechanismList([]uint{pkcs11.CKM_DES_CBC})),
+	}
+	_, err = UnwrapSecretKey(ctx, ss, value[:desBlockSize], k1Template)
+	if isAttributeTypeInvalid(err) {
+		_, err = UnwrapSecretKey(ctx, ss, value[:desBlockSize], withoutAllowedMechanisms(k1Template))
+	}
+	if err != nil {
+		RemoveKey(ctx, ss, key)
+		return 0, fmt.Errorf("failed to create K1 key: %v", err)
+	}
+	return key, nil
+}
+
+// GenerateRetailMacKey creates a DES2 key of the template and its K1 key from random bytes
+// of the token, they are in the memory of the service until both keys are created.
+func GenerateRetailMacKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	value, err := ctx.GenerateRandom(ss, 2*desBlockSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate key value: %v", err)
+	}
+	defer zero(value)
+
+	// odd parity, some tokens refuse DES keys without it
+	for i, b := range value {
+		b &= 0xfe
+		p := b ^ b>>4
+		p ^= p >> 2
+		p ^= p >> 1
+		value[i] = b | ^p&1
+	}
+
+	return ImportRetailMacKey(ctx, ss, value, template)
+}
+
+// RemoveRetailMacKey removes the K1 key of the DES2 key with the label and id, if it has one.
+func RemoveRetailMacKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte) error {
+	label, id = RetailMacKeyLabel(label, id)
+	objs, err := FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := RemoveKey(ctx, ss, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package hsm_api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ANSI X9.19 / ISO 9797-1 algorithm 3 sample
+const (
+	macKey  = "0123456789ABCDEFFEDCBA9876543210"
+	macData = "Now is the time for all "
+	macAlg3 = "A1C72E74EA3FA9B6"
+)
+
+func TestMacPad(t *testing.T) {
+	cases := []struct {
+		data   string
+		method int
+		padded string
+	}{
+		{"", MacPadding1, "0000000000000000"},
+		{"0102", MacPadding1, "0102000000000000"},
+		{"0102030405060708", MacPadding1, "0102030405060708"},
+		{"", MacPadding2, "8000000000000000"},
+		{"01020304050607", MacPadding2, "0102030405060780"},
+		{"0102030405060708", MacPadding2, "01020304050607088000000000000000"},
+	}
+
+	for _, c := range cases {
+		data, _ := hex.DecodeString(c.data)
+		padded, err := MacPad(data, c.method)
+		if err != nil {
+			t.Error(err)
+		}
+		if hex.EncodeToString(padded) != c.padded {
+			t.Errorf("method %d on %q: got %x, want %s", c.method, c.data, padded, c.padded)
+		}
+	}
+
+	if _, err := MacPad(nil, 3); err == nil {
+		t.Error("expected error for unsupported padding method")
+	}
+}
+
+func TestRetailMac(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	value, _ := hex.DecodeString(macKey)
+	key, err := ImportRetailMacKey(ctx, ss, value, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_DES2),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, "test-retail-mac"),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte("test-retail-mac")),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, key)
+	defer RemoveRetailMacKey(ctx, ss, "test-retail-mac", []byte("test-retail-mac"))
+
+	expected, _ := hex.DecodeString(macAlg3)
+
+	t.Run("Generate", func(t *testing.T) {
+		mac, err := GenerateMac(ctx, ss, key, MacAlgorithm3, MacPadding1, []byte(macData))
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(mac, expected) {
+			t.Errorf("mac: got %X, want %s", mac, macAlg3)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		ok, err := VerifyMac(ctx, ss, key, MacAlgorithm3, MacPadding1, []byte(macData), expected[:4])
+		if err != nil {
+			t.Error(err)
+		}
+		if !ok {
+			t.Error("mac not verified")
+		}
+	})
+
+	t.Run("Without-K1", func(t *testing.T) {
+		if err := RemoveRetailMacKey(ctx, ss, "test-retail-mac", []byte("test-retail-mac")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GenerateMac(ctx, ss, key, MacAlgorithm3, MacPadding1, []byte(macData)); !errors.Is(err, ErrNoRetailMacKey) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
+
+func TestCreateDES3Key(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	t.Run("Create-DES3-Key", func(t *testing.T) {
+		k, err := CreateDES3Key(ctx, ss, "test-des2", true)
+		if err != nil {
+			t.Error(err)
+		}
+		defer RemoveKey(ctx, ss, k)
+
+		mac, err := GenerateMac(ctx, ss, k, MacAlgorithm1, MacPadding2, []byte(plainText))
+		if err != nil {
+			t.Error(err)
+		}
+		t.Logf("mac: %X", mac)
+	})
+}
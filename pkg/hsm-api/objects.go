@@ -0,0 +1,111 @@
+package hsm_api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const findBatchSize = 64
+
+// FindObjects returns every object matching the template in batches until the token has
+// no more, the search is always finalized. An empty result is not an error.
+func FindObjects(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := ctx.FindObjectsInit(ss, template); err != nil {
+		return nil, fmt.Errorf("failed to init finding objects: %v", err)
+	}
+
+	var objs []pkcs11.ObjectHandle
+	for {
+		batch, _, err := ctx.FindObjects(ss, findBatchSize)
+		if err != nil {
+			ctx.FindObjectsFinal(ss)
+			return nil, fmt.Errorf("failed to find objects: %v", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		objs = append(objs, batch...)
+	}
+
+	if err := ctx.FindObjectsFinal(ss); err != nil {
+		return nil, fmt.Errorf("failed to finalize finding objects: %v", err)
+	}
+	return objs, nil
+}
+
+// GetAttributes reads the attributes of the object. C_GetAttributeValue fails as a whole
+// when one of them is invalid for the object class or sensitive, in that case they are
+// read one by one and those that cannot be read are left out of the result.
+func GetAttributes(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle, types ...uint) (map[uint][]byte, error) {
+	template := make([]*pkcs11.Attribute, len(types))
+	for i, t := range types {
+		template[i] = pkcs11.NewAttribute(t, nil)
+	}
+
+	values := make(map[uint][]byte, len(types))
+	attrs, err := ctx.GetAttributeValue(ss, obj, template)
+	if err == nil {
+		for _, a := range attrs {
+			values[a.Type] = a.Value
+		}
+		return values, nil
+	}
+
+	var lastErr error
+	for _, t := range template {
+		attrs, err := ctx.GetAttributeValue(ss, obj, []*pkcs11.Attribute{t})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		values[t.Type] = attrs[0].Value
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("failed to get attributes: %v", lastErr)
+	}
+	return values, nil
+}
+
+// AttributeBool decodes a CK_BBOOL attribute value.
+func AttributeBool(b []byte) bool {
+	return len(b) == 1 && b[0] != 0
+}
+
+// AttributeUint decodes a CK_ULONG attribute value.
+func AttributeUint(b []byte) uint {
+	return bytesToUint(b)
+}
+
+// AttributeDate decodes a CK_DATE attribute value, unset dates are the zero time.
+func AttributeDate(b []byte) time.Time {
+	t, err := time.Parse("20060102", string(b))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// WrapMechanism is the mechanism of WrapKey and UnwrapKey.
+const WrapMechanism = pkcs11.CKM_AES_KEY_WRAP_PAD
+
+// WrapKey exports the key encrypted under the AES kek with CKM_AES_KEY_WRAP_PAD.
+func WrapKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, kek, key pkcs11.ObjectHandle) ([]byte, error) {
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(WrapMechanism, nil)}
+	wrapped, err := ctx.WrapKey(ss, mech, kek, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %v", err)
+	}
+	return wrapped, nil
+}
+
+// UnwrapKey imports a key wrapped by WrapKey with the attributes of the template.
+func UnwrapKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, kek pkcs11.ObjectHandle, wrapped []byte, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(WrapMechanism, nil)}
+	obj, err := ctx.UnwrapKey(ss, mech, kek, wrapped, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unwrap key: %v", err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,23 @@
+package hsm_api
+
+/*
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+import "unsafe"
+
+// stringDataParams builds a CK_KEY_DERIVATION_STRING_DATA for derivation mechanisms such as
+// CKM_XOR_BASE_AND_DATA. The data is copied to C memory, free wipes and releases it.
+func stringDataParams(data []byte) (params []byte, free func()) {
+	p := C.CBytes(data)
+
+	params = make([]byte, unsafe.Sizeof(uintptr(0)))
+	*(*uintptr)(unsafe.Pointer(&params[0])) = uintptr(p)
+	params = append(params, uintToBytes(uint(len(data)))...)
+
+	return params, func() {
+		C.memset(p, 0, C.size_t(len(data)))
+		C.free(p)
+	}
+}
@@ -0,0 +1,245 @@
+package hsm_api
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	// DefaultDecimalizationTable maps the hex digits 0-F to 0123456789012345.
+	DefaultDecimalizationTable = "0123456789012345"
+
+	minPinLength = 4
+	maxPinLength = 12
+	pvvLength    = 4
+)
+
+// DecryptPinBlock decrypts an ISO 9564 format 0 PIN block under the PIN encryption key
+// and returns the clear PIN. The clear PIN only lives in memory for the verification.
+func DecryptPinBlock(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pek pkcs11.ObjectHandle, pinBlock []byte, pan string) (string, error) {
+	if len(pinBlock) != desBlockSize {
+		return "", fmt.Errorf("invalid pin block length: %d", len(pinBlock))
+	}
+
+	clear, err := desEcb(ctx, ss, pek, pinBlock, false)
+	if err != nil {
+		return "", err
+	}
+
+	return DecodePinBlock(clear, pan)
+}
+
+// DecodePinBlock extracts the PIN of a clear ISO 9564 format 0 PIN block.
+func DecodePinBlock(block []byte, pan string) (string, error) {
+	panBlock, err := panBlock(pan)
+	if err != nil {
+		return "", err
+	}
+
+	pinField := make([]byte, desBlockSize)
+	for i := range pinField {
+		pinField[i] = block[i] ^ panBlock[i]
+	}
+
+	digits := strings.ToUpper(hex.EncodeToString(pinField))
+	if digits[0] != '0' {
+		return "", fmt.Errorf("unsupported pin block format: %c", digits[0])
+	}
+
+	n := int(pinField[0] & 0x0f)
+	if n < minPinLength || n > maxPinLength {
+		return "", fmt.Errorf("invalid pin length: %d", n)
+	}
+
+	pin := digits[2 : 2+n]
+	if !isDecimal(pin) || strings.Trim(digits[2+n:], "F") != "" {
+		return "", fmt.Errorf("invalid pin block")
+	}
+
+	return pin, nil
+}
+
+// panBlock is 0000 followed by the 12 rightmost PAN digits excluding the check digit.
+func panBlock(pan string) ([]byte, error) {
+	if len(pan) < 13 || !isDecimal(pan) {
+		return nil, fmt.Errorf("invalid pan")
+	}
+	return hex.DecodeString("0000" + pan[len(pan)-13:len(pan)-1])
+}
+
+// GeneratePinOffset returns the IBM 3624 offset of the PIN for the validation data.
+func GeneratePinOffset(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pvk pkcs11.ObjectHandle, pin, validationData, decTable string) (string, error) {
+	natural, err := naturalPin(ctx, ss, pvk, validationData, decTable, len(pin))
+	if err != nil {
+		return "", err
+	}
+
+	return PinOffset(pin, natural), nil
+}
+
+// VerifyPinOffset checks the PIN against the IBM 3624 natural PIN plus offset.
+func VerifyPinOffset(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pvk pkcs11.ObjectHandle, pin, offset, validationData, decTable string) (bool, error) {
+	if len(offset) > len(pin) || !isDecimal(offset) {
+		return false, fmt.Errorf("invalid pin offset")
+	}
+
+	natural, err := naturalPin(ctx, ss, pvk, validationData, decTable, len(offset))
+	if err != nil {
+		return false, err
+	}
+
+	expected := PinOffset(pin[:len(offset)], natural)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(offset)) == 1, nil
+}
+
+// naturalPin encrypts the validation data with the PIN verification key and decimalizes the result.
+func naturalPin(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pvk pkcs11.ObjectHandle, validationData, decTable string, length int) (string, error) {
+	if length < minPinLength || length > maxPinLength {
+		return "", fmt.Errorf("invalid pin length: %d", length)
+	}
+
+	data, err := hex.DecodeString(validationData)
+	if err != nil || len(data) != desBlockSize {
+		return "", fmt.Errorf("validation data must be 16 hex digits")
+	}
+
+	cipher, err := desEcb(ctx, ss, pvk, data, true)
+	if err != nil {
+		return "", err
+	}
+
+	decimalized, err := Decimalize(hex.EncodeToString(cipher), decTable)
+	if err != nil {
+		return "", err
+	}
+
+	return decimalized[:length], nil
+}
+
+// Decimalize maps each hex digit to the digit at the same position of the decimalization table.
+func Decimalize(hexDigits, decTable string) (string, error) {
+	if decTable == "" {
+		decTable = DefaultDecimalizationTable
+	}
+	if len(decTable) != 16 || !isDecimal(decTable) {
+		return "", fmt.Errorf("decimalization table must be 16 decimal digits")
+	}
+
+	out := make([]byte, len(hexDigits))
+	for i, c := range strings.ToUpper(hexDigits) {
+		idx := strings.IndexRune("0123456789ABCDEF", c)
+		if idx < 0 {
+			return "", fmt.Errorf("invalid hex digit: %c", c)
+		}
+		out[i] = decTable[idx]
+	}
+
+	return string(out), nil
+}
+
+// PinOffset subtracts the natural PIN from the PIN digit by digit, modulo 10.
+func PinOffset(pin, natural string) string {
+	out := make([]byte, len(pin))
+	for i := range out {
+		out[i] = '0' + byte((int(pin[i]-'0')-int(natural[i]-'0')+10)%10)
+	}
+	return string(out)
+}
+
+// GeneratePVV computes the Visa PIN verification value of the PIN.
+func GeneratePVV(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pvk pkcs11.ObjectHandle, pan string, pvki int, pin string) (string, error) {
+	if len(pan) < 12 || !isDecimal(pan) {
+		return "", fmt.Errorf("invalid pan")
+	}
+	if pvki < 0 || pvki > 9 {
+		return "", fmt.Errorf("invalid pvki: %d", pvki)
+	}
+	if len(pin) < minPinLength {
+		return "", fmt.Errorf("invalid pin length: %d", len(pin))
+	}
+
+	// transformed security parameter: 11 rightmost PAN digits excluding the check digit,
+	// the PVK index and the 4 leftmost PIN digits.
+	tsp := fmt.Sprintf("%s%d%s", pan[len(pan)-12:len(pan)-1], pvki, pin[:pvvLength])
+	data, err := hex.DecodeString(tsp)
+	if err != nil {
+		return "", fmt.Errorf("invalid pin")
+	}
+
+	cipher, err := desEcb(ctx, ss, pvk, data, true)
+	if err != nil {
+		return "", err
+	}
+
+	return PVVDigits(hex.EncodeToString(cipher)), nil
+}
+
+// VerifyPVV checks the PIN against the PVV stored for the card.
+func VerifyPVV(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, pvk pkcs11.ObjectHandle, pan string, pvki int, pin, pvv string) (bool, error) {
+	expected, err := GeneratePVV(ctx, ss, pvk, pan, pvki, pin)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(pvv)) == 1, nil
+}
+
+// PVVDigits takes the decimal digits of the encrypted TSP from left to right, then the
+// hex digits A-F converted to 0-5 when there are not enough of them.
+func PVVDigits(hexDigits string) string {
+	hexDigits = strings.ToUpper(hexDigits)
+	out := make([]byte, 0, pvvLength)
+	for i := 0; i < len(hexDigits) && len(out) < pvvLength; i++ {
+		if c := hexDigits[i]; c >= '0' && c <= '9' {
+			out = append(out, c)
+		}
+	}
+	for i := 0; i < len(hexDigits) && len(out) < pvvLength; i++ {
+		if c := hexDigits[i]; c >= 'A' && c <= 'F' {
+			out = append(out, '0'+c-'A')
+		}
+	}
+	return string(out)
+}
+
+// PinMechanism is the DES/3DES ECB mechanism PIN keys of the type are used with.
+func PinMechanism(keyType uint) (uint, error) {
+	switch keyType {
+	case pkcs11.CKK_DES:
+		return pkcs11.CKM_DES_ECB, nil
+	case pkcs11.CKK_DES2, pkcs11.CKK_DES3:
+		return pkcs11.CKM_DES3_ECB, nil
+	}
+	return 0, fmt.Errorf("unsupported key type for pin processing: %#x", keyType)
+}
+
+// desEcb runs a single block through DES/3DES ECB depending on the key type.
+func desEcb(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, key pkcs11.ObjectHandle, block []byte, encrypt bool) ([]byte, error) {
+	keyType, err := GetKeyType(ctx, ss, key)
+	if err != nil {
+		return nil, err
+	}
+
+	mech, err := PinMechanism(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypt {
+		return Encrypt(ctx, ss, key, mech, block, nil)
+	}
+	return Decrypt(ctx, ss, key, mech, block, nil)
+}
+
+func isDecimal(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
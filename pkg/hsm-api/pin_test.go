@@ -0,0 +1,139 @@
+package hsm_api
+
+import (
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	pinPan   = "4111111111111111"
+	pinClear = "1234"
+	pinKey   = "0123456789ABCDEFFEDCBA9876543210"
+)
+
+func TestDecodePinBlock(t *testing.T) {
+	block, _ := hex.DecodeString("041225EEEEEEEEEE")
+	pin, err := DecodePinBlock(block, pinPan)
+	if err != nil {
+		t.Error(err)
+	}
+	if pin != pinClear {
+		t.Errorf("pin: got %s, want %s", pin, pinClear)
+	}
+
+	// wrong pan breaks the F padding
+	if _, err := DecodePinBlock(block, "4111111111111129"); err == nil {
+		t.Error("expected error for mismatching pan")
+	}
+}
+
+func TestDecimalize(t *testing.T) {
+	d, err := Decimalize("0123456789abcdef", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if d != DefaultDecimalizationTable {
+		t.Errorf("decimalized: got %s", d)
+	}
+
+	if _, err := Decimalize("0A", "012345"); err == nil {
+		t.Error("expected error for short decimalization table")
+	}
+}
+
+func TestPinOffset(t *testing.T) {
+	if o := PinOffset("1234", "5678"); o != "6666" {
+		t.Errorf("offset: got %s, want 6666", o)
+	}
+	if o := PinOffset("9876", "1234"); o != "8642" {
+		t.Errorf("offset: got %s, want 8642", o)
+	}
+}
+
+func TestPVVDigits(t *testing.T) {
+	cases := map[string]string{
+		"1A2B3C4D5E6F7A8B": "1234",
+		"ABCDEF1A2B3C4DEF": "1234",
+		"ABCDEFABCDEF9ABC": "9012",
+	}
+	for in, want := range cases {
+		if got := PVVDigits(in); got != want {
+			t.Errorf("pvv digits of %s: got %s, want %s", in, got, want)
+		}
+	}
+}
+
+// compare the token results with the software DES implementation.
+func TestPinVerification(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	value, _ := hex.DecodeString(pinKey)
+	pvk, err := ctx.CreateObject(ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_DES2),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(ctx, ss, pvk)
+
+	soft, _ := des.NewTripleDESCipher(append(value, value[:8]...))
+	encrypt := func(h string) string {
+		in, _ := hex.DecodeString(h)
+		out := make([]byte, len(in))
+		soft.Encrypt(out, in)
+		return hex.EncodeToString(out)
+	}
+
+	t.Run("IBM3624", func(t *testing.T) {
+		validation := "4111111111111111"
+		decimalized, _ := Decimalize(encrypt(validation), "")
+		want := PinOffset(pinClear, decimalized[:len(pinClear)])
+
+		offset, err := GeneratePinOffset(ctx, ss, pvk, pinClear, validation, "")
+		if err != nil {
+			t.Error(err)
+		}
+		if offset != want {
+			t.Errorf("offset: got %s, want %s", offset, want)
+		}
+
+		ok, err := VerifyPinOffset(ctx, ss, pvk, pinClear, offset, validation, "")
+		if err != nil || !ok {
+			t.Errorf("pin not verified: %v", err)
+		}
+	})
+
+	t.Run("VisaPVV", func(t *testing.T) {
+		want := PVVDigits(encrypt(pinPan[4:15] + "1" + pinClear))
+
+		pvv, err := GeneratePVV(ctx, ss, pvk, pinPan, 1, pinClear)
+		if err != nil {
+			t.Error(err)
+		}
+		if pvv != want {
+			t.Errorf("pvv: got %s, want %s", pvv, want)
+		}
+
+		ok, err := VerifyPVV(ctx, ss, pvk, pinPan, 1, "9999", pvv)
+		if err != nil || ok {
+			t.Errorf("wrong pin verified: %v", err)
+		}
+	})
+}
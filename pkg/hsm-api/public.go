@@ -0,0 +1,103 @@
+package hsm_api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/gemalto/pkcs11"
+)
+
+var curves = map[string]elliptic.Curve{
+	"P-224": elliptic.P224(),
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// PublicKey reads the public part of an RSA or EC key object, public or private.
+// Private EC keys do not carry the point, their public key object is needed.
+func PublicKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	keyType, err := GetKeyType(ctx, ss, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		return RSAPublicKey(ctx, ss, obj)
+	case pkcs11.CKK_EC:
+		attrs, err := GetAttributes(ctx, ss, obj, pkcs11.CKA_EC_PARAMS, pkcs11.CKA_EC_POINT)
+		if err != nil {
+			return nil, err
+		}
+		return ecPublicKey(attrs[pkcs11.CKA_EC_PARAMS], attrs[pkcs11.CKA_EC_POINT])
+	}
+	return nil, fmt.Errorf("not an asymmetric key: %#x", keyType)
+}
+
+// PublicKeyID is the hex SHA-256 of the DER SubjectPublicKeyInfo, it does not change with
+// the label, id or token of the key.
+func PublicKeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %v", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RSAPublicKey reads the modulus and public exponent of an RSA key object.
+func RSAPublicKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) (*rsa.PublicKey, error) {
+	attrs, err := GetAttributes(ctx, ss, obj, pkcs11.CKA_MODULUS, pkcs11.CKA_PUBLIC_EXPONENT)
+	if err != nil {
+		return nil, err
+	}
+
+	n, e := attrs[pkcs11.CKA_MODULUS], attrs[pkcs11.CKA_PUBLIC_EXPONENT]
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("not an RSA key")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// ecPublicKey decodes the named curve of CKA_EC_PARAMS and the uncompressed point of
+// CKA_EC_POINT, the point is a DER octet string but some tokens return it raw.
+func ecPublicKey(params, point []byte) (*ecdsa.PublicKey, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, fmt.Errorf("unsupported ec params: %v", err)
+	}
+
+	var curve elliptic.Curve
+	for name, c := range curveOIDs {
+		if c.Equal(oid) {
+			curve = curves[name]
+		}
+	}
+	if curve == nil {
+		return nil, fmt.Errorf("unsupported curve: %s", oid)
+	}
+
+	var raw []byte
+	x, y := elliptic.Unmarshal(curve, point)
+	if rest, err := asn1.Unmarshal(point, &raw); err == nil && len(rest) == 0 {
+		if dx, dy := elliptic.Unmarshal(curve, raw); dx != nil {
+			x, y = dx, dy
+		}
+	}
+	if x == nil {
+		return nil, fmt.Errorf("invalid ec point")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,162 @@
+package hsm_api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// ObjectFilter selects objects, empty fields match everything. Token is nil for token and
+// session objects, Usage names usage attributes (encrypt, sign, ...) that must all be set.
+// LabelMatch is applied by the service after the token search, for filters the token
+// cannot express.
+type ObjectFilter struct {
+	Class      string
+	KeyType    string
+	Label      string
+	ID         []byte
+	Token      *bool
+	Usage      []string
+	LabelMatch func(label string) bool
+}
+
+// Object describes a token or session object with the attributes that could be read,
+// Key is set for secret, private and public keys.
+type Object struct {
+	Handle      pkcs11.ObjectHandle
+	Class       string
+	Label       string
+	ID          []byte
+	Token       bool
+	Private     bool
+	Modifiable  bool
+	Application string
+	Key         *KeyInfo
+}
+
+// Template converts the filter to a search template.
+func (f *ObjectFilter) Template() ([]*pkcs11.Attribute, error) {
+	var template []*pkcs11.Attribute
+	if f.Class != "" {
+		c, ok := ClassByName(f.Class)
+		if !ok {
+			return nil, fmt.Errorf("unknown object class: %s", f.Class)
+		}
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_CLASS, c))
+	}
+	if f.KeyType != "" {
+		kt, ok := KeyTypeByName(f.KeyType)
+		if !ok {
+			return nil, fmt.Errorf("unknown key type: %s", f.KeyType)
+		}
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, kt))
+	}
+	if f.Label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, f.Label))
+	}
+	if len(f.ID) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, f.ID))
+	}
+	if f.Token != nil {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_TOKEN, *f.Token))
+	}
+	for _, name := range f.Usage {
+		u, ok := UsageByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown usage: %s", name)
+		}
+		template = append(template, pkcs11.NewAttribute(u, true))
+	}
+	return template, nil
+}
+
+// SearchObjects returns the page of limit objects from offset among those matching the
+// filter, ordered by handle, and the number of matching objects. Only the objects of the
+// page are described, a limit of 0 returns them all.
+func SearchObjects(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, f ObjectFilter, offset, limit int) ([]*Object, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid page: offset %d, limit %d", offset, limit)
+	}
+
+	template, err := f.Template()
+	if err != nil {
+		return nil, 0, err
+	}
+	handles, err := FindObjects(ctx, ss, template)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(handles, func(i, j int) bool { return handles[i] < handles[j] })
+
+	if f.LabelMatch != nil {
+		matching := handles[:0]
+		for _, obj := range handles {
+			attrs, err := GetAttributes(ctx, ss, obj, pkcs11.CKA_LABEL)
+			if err != nil {
+				return nil, 0, err
+			}
+			if f.LabelMatch(string(attrs[pkcs11.CKA_LABEL])) {
+				matching = append(matching, obj)
+			}
+		}
+		handles = matching
+	}
+
+	total := len(handles)
+	handles = page(handles, offset, limit)
+
+	objects := make([]*Object, 0, len(handles))
+	for _, obj := range handles {
+		o, err := DescribeObject(ctx, ss, obj)
+		if err != nil {
+			return nil, 0, err
+		}
+		objects = append(objects, o)
+	}
+	return objects, total, nil
+}
+
+// DescribeObject reads the common attributes of the object, and the key attributes of keys.
+func DescribeObject(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, obj pkcs11.ObjectHandle) (*Object, error) {
+	attrs, err := GetAttributes(ctx, ss, obj,
+		pkcs11.CKA_CLASS, pkcs11.CKA_LABEL, pkcs11.CKA_ID, pkcs11.CKA_TOKEN,
+		pkcs11.CKA_PRIVATE, pkcs11.CKA_MODIFIABLE, pkcs11.CKA_APPLICATION)
+	if err != nil {
+		return nil, err
+	}
+
+	class := bytesToUint(attrs[pkcs11.CKA_CLASS])
+	o := &Object{
+		Handle:      obj,
+		Class:       ClassNames[class],
+		Label:       string(attrs[pkcs11.CKA_LABEL]),
+		ID:          attrs[pkcs11.CKA_ID],
+		Token:       AttributeBool(attrs[pkcs11.CKA_TOKEN]),
+		Private:     AttributeBool(attrs[pkcs11.CKA_PRIVATE]),
+		Modifiable:  AttributeBool(attrs[pkcs11.CKA_MODIFIABLE]),
+		Application: string(attrs[pkcs11.CKA_APPLICATION]),
+	}
+	if o.Class == "" {
+		o.Class = fmt.Sprintf("%#x", class)
+	}
+
+	switch class {
+	case pkcs11.CKO_SECRET_KEY, pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY:
+		if o.Key, err = DescribeKey(ctx, ss, obj); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+func page(handles []pkcs11.ObjectHandle, offset, limit int) []pkcs11.ObjectHandle {
+	if offset >= len(handles) {
+		return nil
+	}
+	handles = handles[offset:]
+	if limit > 0 && limit < len(handles) {
+		handles = handles[:limit]
+	}
+	return handles
+}
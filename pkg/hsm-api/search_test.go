@@ -0,0 +1,90 @@
+package hsm_api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestObjectFilterTemplate(t *testing.T) {
+	token := true
+	f := ObjectFilter{Class: "secret", KeyType: "AES", ID: []byte{0x01}, Token: &token, Usage: []string{"encrypt", "wrap"}}
+	template, err := f.Template()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []uint{pkcs11.CKA_CLASS, pkcs11.CKA_KEY_TYPE, pkcs11.CKA_ID, pkcs11.CKA_TOKEN, pkcs11.CKA_ENCRYPT, pkcs11.CKA_WRAP}
+	if len(template) != len(want) {
+		t.Fatalf("got %d attributes, want %d", len(template), len(want))
+	}
+	for i, a := range template {
+		if a.Type != want[i] {
+			t.Errorf("attribute %d: got %#x, want %#x", i, a.Type, want[i])
+		}
+	}
+
+	for _, f := range []ObjectFilter{{Class: "certificate"}, {KeyType: "AES128"}, {Usage: []string{"mac"}}} {
+		if _, err := f.Template(); err == nil {
+			t.Errorf("%+v: want an error", f)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	handles := []pkcs11.ObjectHandle{1, 2, 3, 4, 5}
+	for _, c := range []struct {
+		offset, limit int
+		want          int
+	}{
+		{0, 0, 5},
+		{0, 2, 2},
+		{4, 2, 1},
+		{5, 2, 0},
+		{9, 0, 0},
+	} {
+		if got := page(handles, c.offset, c.limit); len(got) != c.want {
+			t.Errorf("offset %d, limit %d: got %d objects, want %d", c.offset, c.limit, len(got), c.want)
+		}
+	}
+}
+
+func TestSearchObjects(t *testing.T) {
+	ctx, err := GetContext(modulePath)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishContext(ctx)
+
+	ss, err := GetSession(ctx, 0, pin)
+	if err != nil {
+		t.Error(err)
+	}
+	defer FinishSession(ctx, ss)
+
+	const prefix = "test-object-search-"
+	for i := 0; i < 3; i++ {
+		label := fmt.Sprintf("%s%d", prefix, i)
+		if _, err := GenerateKey(ctx, ss, label, nil, KeyTypeAES256, false); err != nil {
+			t.Fatal(err)
+		}
+		defer DeleteKeys(ctx, ss, "", label, nil)
+	}
+
+	f := ObjectFilter{
+		Usage:      []string{"encrypt"},
+		LabelMatch: func(label string) bool { return strings.HasPrefix(label, prefix) },
+	}
+	objs, total, err := SearchObjects(ctx, ss, f, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(objs) != 1 {
+		t.Fatalf("got %d of %d objects, want 1 of 3", len(objs), total)
+	}
+	if objs[0].Class != "secret" || objs[0].Key == nil || objs[0].Key.KeyType != "AES" {
+		t.Errorf("got %+v", objs[0])
+	}
+}
@@ -0,0 +1,368 @@
+package hsm_api
+
+import (
+	"encoding/asn1"
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// KeyTemplate is the attribute policy of the keys created from it. Class is CKO_SECRET_KEY,
+// or CKO_PRIVATE_KEY for a key pair whose public key gets the counterparts of the usage.
+// Bits is the key size, the curve size for EC keys and 0 for DES keys. Purpose is kept in
+// the policy records of the service, the token has no attribute for it.
+type KeyTemplate struct {
+	Name              string
+	Class             uint
+	KeyType           uint
+	Bits              int
+	Usage             []uint
+	Extractable       bool
+	WrapWithTrusted   bool
+	AllowedMechanisms []uint
+	Purpose           string
+	RetailMac         bool // DES2 keys created with their K1 key, see GenerateRetailMacKey
+}
+
+// mechanisms that can be named in a template
+var MechanismNames = map[string]uint{
+	"CKM_AES_ECB":             pkcs11.CKM_AES_ECB,
+	"CKM_AES_CBC":             pkcs11.CKM_AES_CBC,
+	"CKM_AES_CBC_PAD":         pkcs11.CKM_AES_CBC_PAD,
+	"CKM_AES_GCM":             pkcs11.CKM_AES_GCM,
+	"CKM_AES_CMAC":            pkcs11.CKM_AES_CMAC,
+	"CKM_AES_KEY_WRAP":        pkcs11.CKM_AES_KEY_WRAP,
+	"CKM_AES_KEY_WRAP_PAD":    pkcs11.CKM_AES_KEY_WRAP_PAD,
+	"CKM_DES_ECB":             pkcs11.CKM_DES_ECB,
+	"CKM_DES_CBC":             pkcs11.CKM_DES_CBC,
+	"CKM_DES3_ECB":            pkcs11.CKM_DES3_ECB,
+	"CKM_DES3_CBC":            pkcs11.CKM_DES3_CBC,
+	"CKM_DES3_CBC_PAD":        pkcs11.CKM_DES3_CBC_PAD,
+	"CKM_DES3_MAC":            pkcs11.CKM_DES3_MAC,
+	"CKM_SHA256_HMAC":         pkcs11.CKM_SHA256_HMAC,
+	"CKM_SHA384_HMAC":         pkcs11.CKM_SHA384_HMAC,
+	"CKM_SHA512_HMAC":         pkcs11.CKM_SHA512_HMAC,
+	"CKM_RSA_PKCS":            pkcs11.CKM_RSA_PKCS,
+	"CKM_RSA_PKCS_OAEP":       pkcs11.CKM_RSA_PKCS_OAEP,
+	"CKM_RSA_PKCS_PSS":        pkcs11.CKM_RSA_PKCS_PSS,
+	"CKM_SHA256_RSA_PKCS":     pkcs11.CKM_SHA256_RSA_PKCS,
+	"CKM_SHA384_RSA_PKCS":     pkcs11.CKM_SHA384_RSA_PKCS,
+	"CKM_SHA512_RSA_PKCS":     pkcs11.CKM_SHA512_RSA_PKCS,
+	"CKM_SHA256_RSA_PKCS_PSS": pkcs11.CKM_SHA256_RSA_PKCS_PSS,
+	"CKM_ECDSA":               pkcs11.CKM_ECDSA,
+	"CKM_ECDSA_SHA256":        pkcs11.CKM_ECDSA_SHA256,
+	"CKM_ECDSA_SHA384":        pkcs11.CKM_ECDSA_SHA384,
+	"CKM_ECDH1_DERIVE":        pkcs11.CKM_ECDH1_DERIVE,
+}
+
+// curve names by size for EC templates
+var ecCurveNames = map[int]string{
+	224: "P-224",
+	256: "P-256",
+	384: "P-384",
+	521: "P-521",
+}
+
+var secretKeyGenMechanisms = map[uint]uint{
+	pkcs11.CKK_AES:            pkcs11.CKM_AES_KEY_GEN,
+	pkcs11.CKK_DES2:           pkcs11.CKM_DES2_KEY_GEN,
+	pkcs11.CKK_DES3:           pkcs11.CKM_DES3_KEY_GEN,
+	pkcs11.CKK_GENERIC_SECRET: pkcs11.CKM_GENERIC_SECRET_KEY_GEN,
+}
+
+// public key usage of the private key usage
+var publicUsage = map[uint]uint{
+	pkcs11.CKA_SIGN:    pkcs11.CKA_VERIFY,
+	pkcs11.CKA_DECRYPT: pkcs11.CKA_ENCRYPT,
+	pkcs11.CKA_UNWRAP:  pkcs11.CKA_WRAP,
+}
+
+// MechanismName returns the name of a mechanism used by the service, or its hex value.
+func MechanismName(mech uint) string {
+	for _, names := range []map[string]uint{MechanismNames, otherMechanismNames} {
+		for name, m := range names {
+			if m == mech {
+				return name
+			}
+		}
+	}
+	return fmt.Sprintf("%#x", mech)
+}
+
+// UsageName returns the name of a usage attribute, or its hex value.
+func UsageName(attr uint) string {
+	for _, u := range usageAttributes {
+		if u.attr == attr {
+			return u.name
+		}
+	}
+	return fmt.Sprintf("%#x", attr)
+}
+
+// UsageByName returns the usage attribute of a name: encrypt, decrypt, sign, verify, wrap, unwrap or derive.
+func UsageByName(name string) (uint, bool) {
+	for _, u := range usageAttributes {
+		if u.name == name {
+			return u.attr, true
+		}
+	}
+	return 0, false
+}
+
+// DefaultKeyTemplate is the built-in template of a KeyType* type, used when no template is named.
+func DefaultKeyTemplate(keyType string, extractable bool) (*KeyTemplate, error) {
+	if bits, ok := rsaKeyBits[keyType]; ok {
+		return &KeyTemplate{
+			Name:        keyType,
+			Class:       pkcs11.CKO_PRIVATE_KEY,
+			KeyType:     pkcs11.CKK_RSA,
+			Bits:        bits,
+			Usage:       []uint{pkcs11.CKA_SIGN, pkcs11.CKA_DECRYPT},
+			Extractable: extractable,
+		}, nil
+	}
+
+	spec, ok := secretKeySpecs[keyType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	t := &KeyTemplate{
+		Name:        keyType,
+		Class:       pkcs11.CKO_SECRET_KEY,
+		KeyType:     spec.keyType,
+		Bits:        spec.valueLen * 8,
+		Extractable: extractable,
+	}
+	if spec.cipher {
+		t.Usage = []uint{pkcs11.CKA_ENCRYPT, pkcs11.CKA_DECRYPT}
+	} else {
+		t.Usage = []uint{pkcs11.CKA_SIGN, pkcs11.CKA_VERIFY}
+	}
+	if !spec.cipher {
+		t.Usage = append(t.Usage, pkcs11.CKA_DERIVE)
+	}
+	return t, nil
+}
+
+// Validate checks the template is consistent and follows the key size policy:
+// RSA keys of at least 2048 bits, NIST curves and full length AES keys.
+func (t *KeyTemplate) Validate() error {
+	switch t.Class {
+	case pkcs11.CKO_SECRET_KEY:
+		switch t.KeyType {
+		case pkcs11.CKK_AES:
+			if t.Bits != 128 && t.Bits != 192 && t.Bits != 256 {
+				return fmt.Errorf("template %s: invalid AES key size: %d", t.Name, t.Bits)
+			}
+		case pkcs11.CKK_DES2, pkcs11.CKK_DES3:
+			if t.Bits != 0 {
+				return fmt.Errorf("template %s: DES key sizes are implied by the key type", t.Name)
+			}
+		case pkcs11.CKK_GENERIC_SECRET:
+			if t.Bits < 128 || t.Bits%8 != 0 {
+				return fmt.Errorf("template %s: invalid generic secret size: %d", t.Name, t.Bits)
+			}
+		default:
+			return fmt.Errorf("template %s: unsupported secret key type: %#x", t.Name, t.KeyType)
+		}
+
+	case pkcs11.CKO_PRIVATE_KEY:
+		switch t.KeyType {
+		case pkcs11.CKK_RSA:
+			if t.Bits < 2048 || t.Bits%8 != 0 {
+				return fmt.Errorf("template %s: invalid RSA key size: %d", t.Name, t.Bits)
+			}
+		case pkcs11.CKK_EC:
+			if _, ok := ecCurveNames[t.Bits]; !ok {
+				return fmt.Errorf("template %s: unsupported curve size: %d", t.Name, t.Bits)
+			}
+		default:
+			return fmt.Errorf("template %s: unsupported key pair type: %#x", t.Name, t.KeyType)
+		}
+		for _, u := range t.Usage {
+			if _, ok := publicUsage[u]; !ok && u != pkcs11.CKA_DERIVE {
+				return fmt.Errorf("template %s: usage %#x is not a private key usage", t.Name, u)
+			}
+		}
+
+	default:
+		return fmt.Errorf("template %s: class must be secret or private", t.Name)
+	}
+
+	if len(t.Usage) == 0 {
+		return fmt.Errorf("template %s: missing usage", t.Name)
+	}
+	if t.WrapWithTrusted && !t.Extractable {
+		return fmt.Errorf("template %s: wrap with trusted needs an extractable key", t.Name)
+	}
+	if t.RetailMac {
+		// a backup or an export would carry the DES2 key without its K1 key
+		if t.Class != pkcs11.CKO_SECRET_KEY || t.KeyType != pkcs11.CKK_DES2 || t.Extractable || t.Purpose != "" {
+			return fmt.Errorf("template %s: retail mac needs a non-extractable DES2 key without purpose", t.Name)
+		}
+	}
+	return nil
+}
+
+// GenerateFromTemplate generates a key or a key pair of the template, the private key
+// of a key pair comes first. The label and id must be free, a new id is generated when
+// it is empty.
+func GenerateFromTemplate(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, t *KeyTemplate, label string, id []byte) ([]pkcs11.ObjectHandle, error) {
+	if label == "" {
+		return nil, fmt.Errorf("missing key label")
+	}
+	id, err := uniqueKeyID(ctx, ss, label, id)
+	if err != nil {
+		return nil, err
+	}
+	return t.generate(ctx, ss, label, id)
+}
+
+// GenerateMechanism returns the mechanism generating the keys of the template and the
+// capability it needs from the token.
+func (t *KeyTemplate) GenerateMechanism() (uint, uint, bool) {
+	switch t.KeyType {
+	case pkcs11.CKK_RSA:
+		return pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, pkcs11.CKF_GENERATE_KEY_PAIR, true
+	case pkcs11.CKK_EC:
+		return pkcs11.CKM_EC_KEY_PAIR_GEN, pkcs11.CKF_GENERATE_KEY_PAIR, true
+	}
+	mech, ok := secretKeyGenMechanisms[t.KeyType]
+	return mech, pkcs11.CKF_GENERATE, ok
+}
+
+func (t *KeyTemplate) generate(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string, id []byte) ([]pkcs11.ObjectHandle, error) {
+	privateKeyTemplate, publicKeyTemplate := t.attributes(label, id)
+
+	var mech uint
+	switch t.KeyType {
+	case pkcs11.CKK_RSA:
+		mech = pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN
+		publicKeyTemplate = append(publicKeyTemplate,
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, t.Bits),
+		)
+	case pkcs11.CKK_EC:
+		params, err := asn1.Marshal(curveOIDs[ecCurveNames[t.Bits]])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ec params: %v", err)
+		}
+		mech = pkcs11.CKM_EC_KEY_PAIR_GEN
+		publicKeyTemplate = append(publicKeyTemplate, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params))
+	default:
+		genMech, ok := secretKeyGenMechanisms[t.KeyType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported secret key type: %#x", t.KeyType)
+		}
+		if t.RetailMac {
+			obj, err := GenerateRetailMacKey(ctx, ss, privateKeyTemplate)
+			if isAttributeTypeInvalid(err) && len(t.AllowedMechanisms) > 0 {
+				obj, err = GenerateRetailMacKey(ctx, ss, withoutAllowedMechanisms(privateKeyTemplate))
+			}
+			if err != nil {
+				return nil, err
+			}
+			return []pkcs11.ObjectHandle{obj}, nil
+		}
+		if t.Bits > 0 {
+			privateKeyTemplate = append(privateKeyTemplate, pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, t.Bits/8))
+		}
+		m := []*pkcs11.Mechanism{pkcs11.NewMechanism(genMech, nil)}
+		obj, err := ctx.GenerateKey(ss, m, privateKeyTemplate)
+		if isAttributeTypeInvalid(err) && len(t.AllowedMechanisms) > 0 {
+			obj, err = ctx.GenerateKey(ss, m, withoutAllowedMechanisms(privateKeyTemplate))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []pkcs11.ObjectHandle{obj}, nil
+	}
+
+	m := []*pkcs11.Mechanism{pkcs11.NewMechanism(mech, nil)}
+	pub, priv, err := ctx.GenerateKeyPair(ss, m, publicKeyTemplate, privateKeyTemplate)
+	if isAttributeTypeInvalid(err) && len(t.AllowedMechanisms) > 0 {
+		pub, priv, err = ctx.GenerateKeyPair(ss, m, withoutAllowedMechanisms(publicKeyTemplate), withoutAllowedMechanisms(privateKeyTemplate))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []pkcs11.ObjectHandle{priv, pub}, nil
+}
+
+// attributes returns the template of the secret or private key and the one of the public key,
+// without the attributes that only apply to generation or import.
+func (t *KeyTemplate) attributes(label string, id []byte) ([]*pkcs11.Attribute, []*pkcs11.Attribute) {
+	common := func(class uint) []*pkcs11.Attribute {
+		a := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, class), // O
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, t.KeyType),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		}
+		if len(id) > 0 {
+			a = append(a, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+		}
+		if len(t.AllowedMechanisms) > 0 {
+			a = append(a, pkcs11.NewAttribute(pkcs11.CKA_ALLOWED_MECHANISMS, mechanismList(t.AllowedMechanisms)))
+		}
+		return a
+	}
+
+	key := append(common(t.Class),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, t.Extractable),
+	)
+	if t.WrapWithTrusted {
+		key = append(key, pkcs11.NewAttribute(pkcs11.CKA_WRAP_WITH_TRUSTED, true))
+	}
+	for _, u := range t.Usage {
+		key = append(key, pkcs11.NewAttribute(u, true))
+	}
+	if t.Class != pkcs11.CKO_PRIVATE_KEY {
+		return key, nil
+	}
+
+	pub := common(pkcs11.CKO_PUBLIC_KEY)
+	for _, u := range t.Usage {
+		if p, ok := publicUsage[u]; ok {
+			pub = append(pub, pkcs11.NewAttribute(p, true))
+		}
+	}
+	return key, pub
+}
+
+// mechanismList encodes a CK_MECHANISM_TYPE array attribute value.
+func mechanismList(mechs []uint) []byte {
+	var b []byte
+	for _, m := range mechs {
+		b = append(b, uintToBytes(m)...)
+	}
+	return b
+}
+
+// MechanismList decodes a CK_MECHANISM_TYPE array attribute value such as CKA_ALLOWED_MECHANISMS.
+func MechanismList(b []byte) []uint {
+	size := len(uintToBytes(0))
+	mechs := make([]uint, 0, len(b)/size)
+	for i := 0; i+size <= len(b); i += size {
+		mechs = append(mechs, bytesToUint(b[i:i+size]))
+	}
+	return mechs
+}
+
+// withoutAllowedMechanisms drops CKA_ALLOWED_MECHANISMS for tokens that do not know it,
+// the service then enforces the mechanisms of the template itself.
+func withoutAllowedMechanisms(template []*pkcs11.Attribute) []*pkcs11.Attribute {
+	out := make([]*pkcs11.Attribute, 0, len(template))
+	for _, a := range template {
+		if a.Type != pkcs11.CKA_ALLOWED_MECHANISMS {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func isAttributeTypeInvalid(err error) bool {
+	e, ok := err.(pkcs11.Error)
+	return ok && e == pkcs11.CKR_ATTRIBUTE_TYPE_INVALID
+}
@@ -0,0 +1,104 @@
+package hsm_api
+
+import (
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestDefaultKeyTemplate(t *testing.T) {
+	for keyType := range secretKeySpecs {
+		tmpl, err := DefaultKeyTemplate(keyType, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tmpl.Validate(); err != nil {
+			t.Errorf("%s: %v", keyType, err)
+		}
+	}
+	for keyType := range rsaKeyBits {
+		tmpl, _ := DefaultKeyTemplate(keyType, false)
+		if err := tmpl.Validate(); err != nil {
+			t.Errorf("%s: %v", keyType, err)
+		}
+	}
+
+	if _, err := DefaultKeyTemplate("RSA1024", false); err == nil {
+		t.Error("expected error for unknown key type")
+	}
+
+	// the retail MAC derivation is not available on every token, it is asked for by a template
+	des2, _ := DefaultKeyTemplate(KeyTypeDES2, false)
+	for _, u := range des2.Usage {
+		if u == pkcs11.CKA_DERIVE {
+			t.Error("DES2 keys derive by default")
+		}
+	}
+}
+
+func TestKeyTemplateAttributes(t *testing.T) {
+	tmpl := &KeyTemplate{
+		Name:              "signing",
+		Class:             pkcs11.CKO_PRIVATE_KEY,
+		KeyType:           pkcs11.CKK_RSA,
+		Bits:              3072,
+		Usage:             []uint{pkcs11.CKA_SIGN},
+		AllowedMechanisms: []uint{pkcs11.CKM_SHA256_RSA_PKCS, pkcs11.CKM_SHA256_RSA_PKCS_PSS},
+	}
+	priv, pub := tmpl.attributes("signing-key", []byte{1})
+
+	find := func(attrs []*pkcs11.Attribute, typ uint) []byte {
+		for _, a := range attrs {
+			if a.Type == typ {
+				return a.Value
+			}
+		}
+		return nil
+	}
+	if !AttributeBool(find(priv, pkcs11.CKA_SIGN)) || find(priv, pkcs11.CKA_DECRYPT) != nil {
+		t.Error("private key usage")
+	}
+	if !AttributeBool(find(pub, pkcs11.CKA_VERIFY)) || find(pub, pkcs11.CKA_ENCRYPT) != nil {
+		t.Error("public key usage")
+	}
+	if AttributeBool(find(priv, pkcs11.CKA_EXTRACTABLE)) || !AttributeBool(find(priv, pkcs11.CKA_SENSITIVE)) {
+		t.Error("private key must be sensitive and not extractable")
+	}
+
+	mechs := find(priv, pkcs11.CKA_ALLOWED_MECHANISMS)
+	size := len(uintToBytes(0))
+	if len(mechs) != 2*size || AttributeUint(mechs[size:]) != pkcs11.CKM_SHA256_RSA_PKCS_PSS {
+		t.Errorf("allowed mechanisms: got %x", mechs)
+	}
+}
+
+func TestMechanismList(t *testing.T) {
+	mechs := []uint{pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_AES_GCM}
+	got := MechanismList(mechanismList(mechs))
+	if len(got) != 2 || got[0] != mechs[0] || got[1] != mechs[1] {
+		t.Errorf("got %v, want %v", got, mechs)
+	}
+	if MechanismName(pkcs11.CKM_AES_GCM) != "CKM_AES_GCM" {
+		t.Errorf("got %s", MechanismName(pkcs11.CKM_AES_GCM))
+	}
+}
+
+func TestMacMechanism(t *testing.T) {
+	for _, c := range []struct {
+		keyType   uint
+		algorithm int
+		want      uint
+	}{
+		{pkcs11.CKK_DES, MacAlgorithm1, pkcs11.CKM_DES_CBC},
+		{pkcs11.CKK_DES2, MacAlgorithm1, pkcs11.CKM_DES3_CBC},
+		{pkcs11.CKK_DES2, MacAlgorithm3, pkcs11.CKM_DES3_ECB},
+	} {
+		got, err := MacMechanism(c.keyType, c.algorithm)
+		if err != nil || got != c.want {
+			t.Errorf("MacMechanism(%#x, %d) = %#x, %v", c.keyType, c.algorithm, got, err)
+		}
+	}
+	if _, err := MacMechanism(pkcs11.CKK_AES, MacAlgorithm1); err == nil {
+		t.Error("expected error for aes key")
+	}
+}
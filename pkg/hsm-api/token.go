@@ -0,0 +1,96 @@
+package hsm_api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gemalto/pkcs11"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenSlot returns the slot of the initialized token with the label.
+func TokenSlot(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get slot list: %v", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+		}
+		if info.Flags&pkcs11.CKF_TOKEN_INITIALIZED != 0 && info.Label == label {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrTokenNotFound, label)
+}
+
+// FreeSlot returns the first slot holding a token that is not initialized.
+func FreeSlot(ctx *pkcs11.Ctx) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get slot list: %v", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+		}
+		if info.Flags&pkcs11.CKF_TOKEN_INITIALIZED == 0 {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no uninitialized token", ErrTokenNotFound)
+}
+
+// InitToken initializes the token of the slot with the SO PIN and label, every object of
+// an initialized token is destroyed.
+func InitToken(ctx *pkcs11.Ctx, slot uint, soPIN, label string) error {
+	if err := ctx.InitToken(slot, soPIN, label); err != nil {
+		return fmt.Errorf("failed to init token: %w", err)
+	}
+	return nil
+}
+
+// InitPIN sets the user PIN of the token in a session logged in as security officer.
+func InitPIN(ctx *pkcs11.Ctx, slot uint, soPIN, pin string) error {
+	return withLogin(ctx, slot, pkcs11.CKU_SO, soPIN, func(ss pkcs11.SessionHandle) error {
+		if err := ctx.InitPIN(ss, pin); err != nil {
+			return fmt.Errorf("failed to init pin: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetPIN changes the PIN of the user type, CKU_USER or CKU_SO, in a session of its own.
+func SetPIN(ctx *pkcs11.Ctx, slot, userType uint, oldPIN, newPIN string) error {
+	return withLogin(ctx, slot, userType, oldPIN, func(ss pkcs11.SessionHandle) error {
+		return SetSessionPIN(ctx, ss, oldPIN, newPIN)
+	})
+}
+
+// SetSessionPIN changes the PIN of the user logged in the session.
+func SetSessionPIN(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, oldPIN, newPIN string) error {
+	if err := ctx.SetPIN(ss, oldPIN, newPIN); err != nil {
+		return fmt.Errorf("failed to set pin: %w", err)
+	}
+	return nil
+}
+
+// withLogin runs f in a read-write session of the slot logged in as the user type.
+func withLogin(ctx *pkcs11.Ctx, slot, userType uint, pin string, f func(ss pkcs11.SessionHandle) error) error {
+	ss, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %v", err)
+	}
+	defer ctx.CloseSession(ss)
+
+	if err := ctx.Login(ss, userType, pin); err != nil {
+		return fmt.Errorf("failed to login: %w", err)
+	}
+	defer ctx.Logout(ss)
+
+	return f(ss)
+}
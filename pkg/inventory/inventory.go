@@ -0,0 +1,196 @@
+// Package inventory enumerates every object of the token with its decoded attributes and
+// flags the configurations an operator should review.
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// findings of an item
+const (
+	// a sensitive key that can still be wrapped out of the token
+	FindingExtractableSensitive = "extractable-sensitive"
+	// a secret or private key whose value can be read in clear
+	FindingNotSensitive = "not-sensitive"
+	// objects of the same class sharing the label and id, addressing them by label is ambiguous
+	FindingDuplicateLabel = "duplicate-label"
+)
+
+// output formats of Write
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Item is one object of the token, the key fields are empty for data objects.
+// Dates are YYYY-MM-DD, empty when not set.
+type Item struct {
+	Class       string   `json:"class"`
+	KeyType     string   `json:"key_type,omitempty"`
+	Bits        int      `json:"bits,omitempty"`
+	Label       string   `json:"label"`
+	ID          string   `json:"id,omitempty"`
+	Token       bool     `json:"token"`
+	Sensitive   bool     `json:"sensitive"`
+	Extractable bool     `json:"extractable"`
+	Local       bool     `json:"local"`
+	StartDate   string   `json:"start_date,omitempty"`
+	EndDate     string   `json:"end_date,omitempty"`
+	Application string   `json:"application,omitempty"`
+	Findings    []string `json:"findings,omitempty"`
+}
+
+// Report is the inventory of a slot, items are ordered by handle.
+type Report struct {
+	Slot  uint      `json:"slot"`
+	Taken time.Time `json:"taken"`
+	Items []*Item   `json:"items"`
+}
+
+// Take enumerates the objects the session can see on the slot.
+func Take(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, slot uint) (*Report, error) {
+	objs, _, err := hsm_api.SearchObjects(ctx, ss, hsm_api.ObjectFilter{}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate objects: %v", err)
+	}
+
+	items := make([]*Item, 0, len(objs))
+	for _, o := range objs {
+		item := &Item{
+			Class:       o.Class,
+			Label:       o.Label,
+			ID:          hex.EncodeToString(o.ID),
+			Token:       o.Token,
+			Application: o.Application,
+		}
+		if o.Key != nil {
+			attrs, err := hsm_api.GetAttributes(ctx, ss, o.Handle, pkcs11.CKA_LOCAL, pkcs11.CKA_START_DATE, pkcs11.CKA_END_DATE)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key %s: %v", o.Label, err)
+			}
+			item.KeyType = o.Key.KeyType
+			item.Bits = o.Key.Bits
+			item.Sensitive = o.Key.Sensitive
+			item.Extractable = o.Key.Extractable
+			item.Local = hsm_api.AttributeBool(attrs[pkcs11.CKA_LOCAL])
+			item.StartDate = date(attrs[pkcs11.CKA_START_DATE])
+			item.EndDate = date(attrs[pkcs11.CKA_END_DATE])
+		}
+		items = append(items, item)
+	}
+
+	review(items)
+	return &Report{Slot: slot, Taken: time.Now().UTC(), Items: items}, nil
+}
+
+// Risky counts the items with findings.
+func (r *Report) Risky() int {
+	n := 0
+	for _, item := range r.Items {
+		if len(item.Findings) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// review sets the findings of the items.
+func review(items []*Item) {
+	seen := map[string][]*Item{}
+	for _, item := range items {
+		item.Findings = nil
+		if item.Class == "secret" || item.Class == "private" {
+			if !item.Sensitive {
+				item.Findings = append(item.Findings, FindingNotSensitive)
+			} else if item.Extractable {
+				item.Findings = append(item.Findings, FindingExtractableSensitive)
+			}
+		}
+		key := item.Class + "/" + item.Label + "/" + item.ID
+		seen[key] = append(seen[key], item)
+	}
+
+	for _, item := range items {
+		if len(seen[item.Class+"/"+item.Label+"/"+item.ID]) > 1 {
+			item.Findings = append(item.Findings, FindingDuplicateLabel)
+		}
+	}
+}
+
+// Write writes the report in the format: table, json or csv.
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatTable, "":
+		return writeTable(w, r)
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	}
+	return fmt.Errorf("unknown inventory format %s, want table, json or csv", format)
+}
+
+var columns = []string{"class", "key_type", "bits", "label", "id", "token", "sensitive", "extractable", "local", "start_date", "end_date", "application", "findings"}
+
+func (item *Item) row() []string {
+	bits := ""
+	if item.Bits > 0 {
+		bits = strconv.Itoa(item.Bits)
+	}
+	return []string{
+		item.Class, item.KeyType, bits, item.Label, item.ID,
+		strconv.FormatBool(item.Token), strconv.FormatBool(item.Sensitive),
+		strconv.FormatBool(item.Extractable), strconv.FormatBool(item.Local),
+		item.StartDate, item.EndDate, item.Application, strings.Join(item.Findings, " "),
+	}
+}
+
+func writeTable(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, item := range r.Items {
+		fmt.Fprintln(tw, strings.Join(item.row(), "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "slot %d: %d objects, %d to review\n", r.Slot, len(r.Items), r.Risky())
+	return err
+}
+
+func writeCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, item := range r.Items {
+		if err := cw.Write(item.row()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func date(b []byte) string {
+	t := hsm_api.AttributeDate(b)
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testItems() []*Item {
+	return []*Item{
+		{Class: "secret", KeyType: "AES", Bits: 256, Label: "data-key", ID: "01", Sensitive: true},
+		{Class: "secret", KeyType: "AES", Bits: 256, Label: "export-key", ID: "02", Sensitive: true, Extractable: true},
+		{Class: "secret", KeyType: "GENERIC_SECRET", Bits: 256, Label: "clear-key", ID: "03"},
+		{Class: "private", KeyType: "RSA", Bits: 2048, Label: "sign-key", ID: "04", Sensitive: true},
+		{Class: "public", KeyType: "RSA", Bits: 2048, Label: "sign-key", ID: "04"},
+		{Class: "secret", KeyType: "AES", Bits: 256, Label: "twin-key", Sensitive: true},
+		{Class: "secret", KeyType: "AES", Bits: 256, Label: "twin-key", Sensitive: true},
+		{Class: "data", Label: "data-key", Application: "hsm-lifecycle"},
+	}
+}
+
+func TestReview(t *testing.T) {
+	items := testItems()
+	review(items)
+
+	want := [][]string{
+		nil,
+		{FindingExtractableSensitive},
+		{FindingNotSensitive},
+		nil,
+		nil,
+		{FindingDuplicateLabel},
+		{FindingDuplicateLabel},
+		nil,
+	}
+	for i, item := range items {
+		if !reflect.DeepEqual(item.Findings, want[i]) {
+			t.Errorf("%s %s: got findings %v, want %v", item.Class, item.Label, item.Findings, want[i])
+		}
+	}
+
+	r := &Report{Items: items}
+	if r.Risky() != 4 {
+		t.Errorf("got %d risky items, want 4", r.Risky())
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := &Report{Slot: 0, Items: testItems()}
+	review(r.Items)
+
+	b := &bytes.Buffer{}
+	if err := Write(b, r, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(r.Items)+1 || !reflect.DeepEqual(rows[0], columns) {
+		t.Fatalf("got %d csv rows with header %v", len(rows), rows[0])
+	}
+	if rows[2][2] != "256" || rows[2][12] != FindingExtractableSensitive {
+		t.Errorf("got csv row %v", rows[2])
+	}
+
+	b.Reset()
+	if err := Write(b, r, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	got := &Report{}
+	if err := json.Unmarshal(b.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Items, r.Items) {
+		t.Errorf("json items differ")
+	}
+
+	b.Reset()
+	if err := Write(b, r, FormatTable); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != len(r.Items)+2 {
+		t.Errorf("got %d table lines, want %d", len(lines), len(r.Items)+2)
+	}
+
+	if err := Write(b, r, "xml"); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+}
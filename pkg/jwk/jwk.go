@@ -0,0 +1,82 @@
+// Package jwk encodes public keys as JSON Web Keys (RFC 7517) with their RFC 7638 thumbprint.
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// Key is the public JWK of an RSA or EC key, Kid is the thumbprint.
+type Key struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// New returns the JWK of the public key with its thumbprint as key id.
+func New(pub crypto.PublicKey) (*Key, error) {
+	var k *Key
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		k = &Key{
+			Kty: "RSA",
+			N:   encode(p.N.Bytes()),
+			E:   encode(big.NewInt(int64(p.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		// coordinates are padded to the size of the curve (RFC 7518 6.2.1.2)
+		size := (p.Curve.Params().BitSize + 7) / 8
+		k = &Key{
+			Kty: "EC",
+			Crv: p.Curve.Params().Name,
+			X:   encode(pad(p.X.Bytes(), size)),
+			Y:   encode(pad(p.Y.Bytes(), size)),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+
+	kid, err := k.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	k.Kid = kid
+	return k, nil
+}
+
+// Thumbprint is the base64url SHA-256 of the required members in lexicographic order
+// without whitespace (RFC 7638).
+func (k *Key) Thumbprint() (string, error) {
+	var canonical string
+	switch k.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, k.E, k.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, k.Crv, k.X, k.Y)
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return encode(sum[:]), nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
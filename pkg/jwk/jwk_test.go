@@ -0,0 +1,40 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// RFC 7638 section 3.1 example
+func TestThumbprint(t *testing.T) {
+	n, _ := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	k, err := New(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.E != "AQAB" {
+		t.Errorf("e: got %s", k.E)
+	}
+	if k.Kid != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("thumbprint: got %s", k.Kid)
+	}
+}
+
+func TestECKey(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	k, err := New(&ec.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Kty != "EC" || k.Crv != "P-521" {
+		t.Errorf("got kty %s crv %s", k.Kty, k.Crv)
+	}
+	if x, _ := base64.RawURLEncoding.DecodeString(k.X); len(x) != 66 {
+		t.Errorf("x length: got %d, want 66", len(x))
+	}
+}
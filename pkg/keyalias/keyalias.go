@@ -0,0 +1,111 @@
+// Package keyalias loads the key aliases of the configuration, the type of an Encrypt or
+// Decrypt request names one of them to pick the key, the algorithm and the operations.
+package keyalias
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hsm/configs"
+)
+
+// policies of an alias
+const (
+	PolicyEncryptDecrypt = "encrypt-decrypt"
+	PolicyEncryptOnly    = "encrypt-only"
+	PolicyDecryptOnly    = "decrypt-only"
+)
+
+// ErrNotAllowed is returned for an operation the policy of the alias refuses.
+var ErrNotAllowed = errors.New("not allowed by key alias policy")
+
+// Alias is a parsed configs.KeyAlias, a key ring named by its label or by the CKA_ID of
+// one of its keys. An empty algorithm leaves the choice to the request.
+type Alias struct {
+	Type      string
+	Label     string
+	ID        []byte
+	Algorithm string
+	Encrypt   bool
+	Decrypt   bool
+}
+
+// Load parses and validates the aliases, types must be unique.
+func Load(conf []configs.KeyAlias) (map[string]*Alias, error) {
+	aliases := map[string]*Alias{}
+	for _, c := range conf {
+		if _, ok := aliases[c.Type]; ok {
+			return nil, fmt.Errorf("duplicate key alias: %s", c.Type)
+		}
+		a, err := Parse(c)
+		if err != nil {
+			return nil, err
+		}
+		aliases[c.Type] = a
+	}
+	return aliases, nil
+}
+
+// Parse validates the configured alias.
+func Parse(c configs.KeyAlias) (*Alias, error) {
+	if c.Type == "" {
+		return nil, fmt.Errorf("missing key alias type")
+	}
+	if (c.Label == "") == (c.ID == "") {
+		return nil, fmt.Errorf("alias %s: set either a label or an id", c.Type)
+	}
+
+	id, err := hex.DecodeString(c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("alias %s: invalid id: %v", c.Type, err)
+	}
+
+	a := &Alias{Type: c.Type, Label: c.Label, ID: id, Algorithm: strings.ToUpper(c.Algorithm)}
+	switch a.Algorithm {
+	case "", "CBC", "GCM":
+	default:
+		return nil, fmt.Errorf("alias %s: unknown algorithm: %s", c.Type, c.Algorithm)
+	}
+
+	switch strings.ToLower(c.Policy) {
+	case "", PolicyEncryptDecrypt:
+		a.Encrypt, a.Decrypt = true, true
+	case PolicyEncryptOnly:
+		a.Encrypt = true
+	case PolicyDecryptOnly:
+		a.Decrypt = true
+	default:
+		return nil, fmt.Errorf("alias %s: unknown policy: %s", c.Type, c.Policy)
+	}
+	return a, nil
+}
+
+// EncryptAlgorithm returns the algorithm to encrypt with, the one of the request must
+// agree with the alias when both are set.
+func (a *Alias) EncryptAlgorithm(requested string) (string, error) {
+	if !a.Encrypt {
+		return "", fmt.Errorf("%w: %s does not encrypt", ErrNotAllowed, a.Type)
+	}
+	requested = strings.ToUpper(requested)
+	if a.Algorithm == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != a.Algorithm {
+		return "", fmt.Errorf("%w: %s encrypts with %s", ErrNotAllowed, a.Type, a.Algorithm)
+	}
+	return a.Algorithm, nil
+}
+
+// CheckDecrypt refuses decryption when the policy does not allow it or the cipher text was
+// not produced with the algorithm of the alias.
+func (a *Alias) CheckDecrypt(algorithm string) error {
+	if !a.Decrypt {
+		return fmt.Errorf("%w: %s does not decrypt", ErrNotAllowed, a.Type)
+	}
+	if a.Algorithm != "" && a.Algorithm != strings.ToUpper(algorithm) {
+		return fmt.Errorf("%w: %s decrypts %s cipher texts", ErrNotAllowed, a.Type, a.Algorithm)
+	}
+	return nil
+}
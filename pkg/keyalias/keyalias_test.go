@@ -0,0 +1,71 @@
+package keyalias
+
+import (
+	"errors"
+	"testing"
+
+	"hsm/configs"
+)
+
+// the dev config aliases must stay valid
+func TestLoadDevConfig(t *testing.T) {
+	conf := configs.LoadConfig("../../configs", "dev")
+	if conf == nil {
+		t.Fatal("failed to load dev config")
+	}
+
+	aliases, err := Load(conf.KeyAliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := aliases["N2K_KGK"]; !ok || a.Label != conf.HSM.N2kLabel {
+		t.Errorf("N2K_KGK: got %+v", a)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]configs.KeyAlias{
+		"missing type":      {Label: "k"},
+		"no key":            {Type: "T"},
+		"label and id":      {Type: "T", Label: "k", ID: "01"},
+		"invalid id":        {Type: "T", ID: "zz"},
+		"unknown algorithm": {Type: "T", Label: "k", Algorithm: "ECB"},
+		"unknown policy":    {Type: "T", Label: "k", Policy: "sign-only"},
+	}
+	for name, c := range cases {
+		if _, err := Parse(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := Load([]configs.KeyAlias{{Type: "T", Label: "a"}, {Type: "T", Label: "b"}}); err == nil {
+		t.Error("expected error for a duplicate type")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	gcm, err := Parse(configs.KeyAlias{Type: "CARD", Label: "card-key", Algorithm: "gcm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg, err := gcm.EncryptAlgorithm(""); err != nil || alg != "GCM" {
+		t.Errorf("got %s, %v", alg, err)
+	}
+	if _, err := gcm.EncryptAlgorithm("CBC"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("expected refusal of CBC, got %v", err)
+	}
+	if err := gcm.CheckDecrypt("CBC"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("expected refusal of a CBC cipher text, got %v", err)
+	}
+
+	retired, err := Parse(configs.KeyAlias{Type: "OLD", Label: "old-key", Policy: PolicyDecryptOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retired.EncryptAlgorithm(""); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("expected refusal of encryption, got %v", err)
+	}
+	if err := retired.CheckDecrypt("CBC"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,82 @@
+// Package keytemplate loads the named key templates of the configuration, every key
+// created or imported through the API can name one of them.
+package keytemplate
+
+import (
+	"fmt"
+	"strings"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+	"hsm/pkg/policy"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// Load parses and validates the templates, names must be unique.
+func Load(conf []configs.KeyTemplate) (map[string]*hsm_api.KeyTemplate, error) {
+	templates := map[string]*hsm_api.KeyTemplate{}
+	for _, c := range conf {
+		if _, ok := templates[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate key template: %s", c.Name)
+		}
+		t, err := Parse(c)
+		if err != nil {
+			return nil, err
+		}
+		templates[c.Name] = t
+	}
+	return templates, nil
+}
+
+// Parse resolves the names of the configured template and validates it.
+func Parse(c configs.KeyTemplate) (*hsm_api.KeyTemplate, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("missing key template name")
+	}
+
+	class, ok := hsm_api.ClassByName(strings.ToLower(c.Class))
+	if !ok {
+		return nil, fmt.Errorf("template %s: unknown class: %s", c.Name, c.Class)
+	}
+	keyType, ok := hsm_api.KeyTypeByName(strings.ToUpper(c.KeyType))
+	if !ok {
+		return nil, fmt.Errorf("template %s: unknown key type: %s", c.Name, c.KeyType)
+	}
+
+	t := &hsm_api.KeyTemplate{
+		Name:            c.Name,
+		Class:           class,
+		KeyType:         keyType,
+		Bits:            c.Size,
+		Extractable:     c.Extractable,
+		WrapWithTrusted: c.WrapWithTrusted,
+		Purpose:         strings.ToLower(c.Purpose),
+		RetailMac:       c.RetailMac,
+	}
+	if !policy.ValidPurpose(t.Purpose) {
+		return nil, fmt.Errorf("template %s: unknown purpose: %s", c.Name, c.Purpose)
+	}
+	if t.Purpose != "" && (class != pkcs11.CKO_SECRET_KEY || (keyType != pkcs11.CKK_DES2 && keyType != pkcs11.CKK_DES3)) {
+		return nil, fmt.Errorf("template %s: purpose %s needs a DES2 or DES3 secret key", c.Name, t.Purpose)
+	}
+	for _, name := range c.Usage {
+		u, ok := hsm_api.UsageByName(strings.ToLower(name))
+		if !ok {
+			return nil, fmt.Errorf("template %s: unknown usage: %s", c.Name, name)
+		}
+		t.Usage = append(t.Usage, u)
+	}
+	for _, name := range c.AllowedMechanisms {
+		m, ok := hsm_api.MechanismNames[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("template %s: unknown mechanism: %s", c.Name, name)
+		}
+		t.AllowedMechanisms = append(t.AllowedMechanisms, m)
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
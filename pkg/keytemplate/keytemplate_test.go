@@ -0,0 +1,80 @@
+package keytemplate
+
+import (
+	"testing"
+
+	"hsm/configs"
+	"hsm/pkg/policy"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// the dev config templates must stay valid
+func TestLoadDevConfig(t *testing.T) {
+	conf := configs.LoadConfig("../../configs", "dev")
+	if conf == nil {
+		t.Fatal("failed to load dev config")
+	}
+
+	templates, err := Load(conf.KeyTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsa, ok := templates["rsa-signing"]
+	if !ok {
+		t.Fatal("missing rsa-signing template")
+	}
+	if rsa.Class != pkcs11.CKO_PRIVATE_KEY || rsa.KeyType != pkcs11.CKK_RSA || rsa.Bits != 3072 || len(rsa.AllowedMechanisms) != 2 {
+		t.Errorf("rsa-signing: got %+v", rsa)
+	}
+	if pvk := templates["pin-verification"]; pvk == nil || pvk.Purpose != policy.PurposePINVerification {
+		t.Errorf("pin-verification: got %+v", pvk)
+	}
+	if mac := templates["mac"]; mac == nil || !mac.RetailMac {
+		t.Errorf("mac: got %+v", mac)
+	}
+	if zpk := templates["pin-encryption"]; zpk == nil || zpk.Purpose != policy.PurposePINEncryption {
+		t.Errorf("pin-encryption: got %+v", zpk)
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := configs.KeyTemplate{Name: "t", Class: "secret", KeyType: "AES", Size: 256, Usage: []string{"encrypt", "decrypt"}}
+	if _, err := Parse(valid); err != nil {
+		t.Error(err)
+	}
+
+	cases := map[string]func(c *configs.KeyTemplate){
+		"short AES key":     func(c *configs.KeyTemplate) { c.Size = 64 },
+		"unknown usage":     func(c *configs.KeyTemplate) { c.Usage = []string{"print"} },
+		"no usage":          func(c *configs.KeyTemplate) { c.Usage = nil },
+		"unknown mechanism": func(c *configs.KeyTemplate) { c.AllowedMechanisms = []string{"CKM_NOPE"} },
+		"public class":      func(c *configs.KeyTemplate) { c.Class = "public" },
+		"small RSA key": func(c *configs.KeyTemplate) {
+			c.Class, c.KeyType, c.Size, c.Usage = "private", "RSA", 1024, []string{"sign"}
+		},
+		"RSA encrypt usage": func(c *configs.KeyTemplate) { c.Class, c.KeyType, c.Usage = "private", "RSA", []string{"encrypt"} },
+		"unknown curve": func(c *configs.KeyTemplate) {
+			c.Class, c.KeyType, c.Size, c.Usage = "private", "EC", 255, []string{"sign"}
+		},
+		"trusted not exported": func(c *configs.KeyTemplate) { c.WrapWithTrusted = true },
+		"unknown purpose":      func(c *configs.KeyTemplate) { c.Purpose = "mac" },
+		"AES pin key":          func(c *configs.KeyTemplate) { c.Purpose = "pin-verification" },
+		"AES retail mac":       func(c *configs.KeyTemplate) { c.RetailMac = true },
+		"exported retail mac": func(c *configs.KeyTemplate) {
+			c.KeyType, c.Size, c.Extractable, c.RetailMac = "DES2", 0, true, true
+		},
+	}
+	for name, change := range cases {
+		c := valid
+		change(&c)
+		if _, err := Parse(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := Load([]configs.KeyTemplate{valid, valid}); err == nil {
+		t.Error("expected error for duplicate template")
+	}
+}
@@ -0,0 +1,361 @@
+// Package lifecycle tracks the NIST SP 800-57 state of the token keys and refuses the
+// operations a state does not allow. The state of a key lives in a token data object
+// next to it, the activation and deactivation dates in CKA_START_DATE and CKA_END_DATE.
+// Keys without a record are active.
+package lifecycle
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hsm/pkg/audit"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// State is the lifecycle state of a key.
+type State string
+
+const (
+	PreActive   State = "PRE_ACTIVE"
+	Active      State = "ACTIVE"
+	Suspended   State = "SUSPENDED"
+	Deactivated State = "DEACTIVATED"
+	Compromised State = "COMPROMISED"
+	Destroyed   State = "DESTROYED"
+)
+
+// Purpose is what a key is used for in an operation.
+type Purpose int
+
+const (
+	Protect Purpose = iota // encrypt, sign, generate a mac or a pin offset
+	Process                // decrypt, verify
+)
+
+// Application of the data objects holding the records
+const Application = "hsm-lifecycle"
+
+var (
+	// ErrCompromised is returned for any use of a compromised key.
+	ErrCompromised = errors.New("key is compromised")
+	// ErrNotUsable is returned when the state of the key does not allow the operation.
+	ErrNotUsable = errors.New("key state does not allow the operation")
+)
+
+// allowed state transitions
+var transitions = map[State][]State{
+	PreActive:   {Active, Compromised, Destroyed},
+	Active:      {Suspended, Deactivated, Compromised},
+	Suspended:   {Active, Deactivated, Compromised},
+	Deactivated: {Compromised, Destroyed},
+	Compromised: {Destroyed},
+}
+
+type (
+	// Record is the lifecycle of one key, identified by its label and id.
+	Record struct {
+		Label   string       `json:"label"`
+		ID      string       `json:"id,omitempty"`
+		State   State        `json:"state"`
+		History []Transition `json:"history"`
+	}
+
+	Transition struct {
+		From   State     `json:"from"`
+		To     State     `json:"to"`
+		Actor  string    `json:"actor"`
+		Reason string    `json:"reason,omitempty"`
+		Time   time.Time `json:"time"`
+	}
+)
+
+// Manager reads and changes the key states with the session of the server. The records are
+// cached once read, so the token is only searched on the first use of a key: the service
+// is the only writer of the records while it runs.
+type Manager struct {
+	ctx   *pkcs11.Ctx
+	ss    pkcs11.SessionHandle
+	audit *audit.Logger
+	mu    sync.Mutex // transitions
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedRecord
+}
+
+// cachedRecord is a record as last read or saved, obj is 0 for a key without record.
+type cachedRecord struct {
+	r   *Record
+	obj pkcs11.ObjectHandle
+}
+
+func NewManager(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, audit *audit.Logger) *Manager {
+	return &Manager{ctx: ctx, ss: ss, audit: audit, cache: map[string]cachedRecord{}}
+}
+
+// Check returns an error wrapping ErrCompromised or ErrNotUsable when the key cannot be used
+// for the purpose. Deactivated keys only process, suspended and pre-active keys are not used.
+func (m *Manager) Check(key pkcs11.ObjectHandle, purpose Purpose) error {
+	label, state, err := m.State(key)
+	if err != nil {
+		return err
+	}
+	return Allows(label, state, purpose)
+}
+
+// Allows tells if a key in the state can be used for the purpose.
+func Allows(label string, state State, purpose Purpose) error {
+	switch {
+	case state == Active:
+		return nil
+	case state == Deactivated && purpose == Process:
+		return nil
+	case state == Compromised:
+		return fmt.Errorf("%s: %w", label, ErrCompromised)
+	}
+	return fmt.Errorf("%s is %s: %w", label, state, ErrNotUsable)
+}
+
+// State returns the label and the effective state of the key: an active key is pre-active
+// before its start date and deactivated after its end date.
+func (m *Manager) State(key pkcs11.ObjectHandle) (string, State, error) {
+	attrs, err := hsm_api.GetAttributes(m.ctx, m.ss, key,
+		pkcs11.CKA_LABEL, pkcs11.CKA_ID, pkcs11.CKA_START_DATE, pkcs11.CKA_END_DATE)
+	if err != nil {
+		return "", "", err
+	}
+	label := string(attrs[pkcs11.CKA_LABEL])
+
+	r, _, err := m.record(label, attrs[pkcs11.CKA_ID])
+	if err != nil {
+		return "", "", err
+	}
+	if r.State != Active {
+		return label, r.State, nil
+	}
+
+	today := time.Now().UTC().Format("20060102")
+	if start := string(attrs[pkcs11.CKA_START_DATE]); isDate(start) && today < start {
+		return label, PreActive, nil
+	}
+	if end := string(attrs[pkcs11.CKA_END_DATE]); isDate(end) && today > end {
+		return label, Deactivated, nil
+	}
+	return label, Active, nil
+}
+
+// Record returns the lifecycle of the key, a key never transitioned has an empty history.
+func (m *Manager) Record(label string, id []byte) (*Record, error) {
+	r, _, err := m.record(label, id)
+	return r, err
+}
+
+// Transition moves the key to a new state, sets its dates, destroys its objects when it
+// reaches Destroyed, and audits the change.
+func (m *Manager) Transition(label string, id []byte, to State, actor, reason string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, obj, err := m.record(label, id)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed(r.State, to) {
+		return nil, fmt.Errorf("%s cannot go from %s to %s", label, r.State, to)
+	}
+
+	keys, err := m.keys(label, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	for _, k := range keys {
+		switch to {
+		case Active:
+			err = m.setDate(k, pkcs11.CKA_START_DATE, now)
+		case Deactivated, Compromised:
+			if r.State != Deactivated {
+				err = m.setDate(k, pkcs11.CKA_END_DATE, now)
+			}
+		case Destroyed:
+			if err = hsm_api.RemoveKey(m.ctx, m.ss, k); err == nil {
+				err = hsm_api.RemoveRetailMacKey(m.ctx, m.ss, label, id)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if to == Destroyed {
+		if err := hsm_api.DestroyKeyVersions(m.ctx, m.ss, label); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.commit(r, obj, to, actor, reason, now)
+}
+
+// Register gives a new key its first state, keys generated before their activation are
+// pre-active.
+func (m *Manager) Register(label string, id []byte, state State, actor string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, obj, err := m.record(label, id)
+	if err != nil {
+		return nil, err
+	}
+	if obj != 0 {
+		return nil, fmt.Errorf("%s already has a lifecycle", label)
+	}
+	return m.commit(r, 0, state, actor, "registered", time.Now().UTC())
+}
+
+func (m *Manager) commit(r *Record, obj pkcs11.ObjectHandle, to State, actor, reason string, now time.Time) (*Record, error) {
+	from := r.State
+	r.History = append(r.History, Transition{From: from, To: to, Actor: actor, Reason: reason, Time: now})
+	r.State = to
+	obj, err := m.save(r, obj)
+	if err != nil {
+		return nil, err
+	}
+	m.remember(r, obj)
+
+	m.audit.Log(audit.Event{
+		Time:   now,
+		Actor:  actor,
+		Action: "key_state",
+		Key:    r.Label,
+		Detail: map[string]string{"id": r.ID, "from": string(from), "to": string(to), "reason": reason},
+	})
+	return r, nil
+}
+
+func allowed(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// keys finds the key objects of the label and id, both halves of a key pair.
+func (m *Manager) keys(label string, id []byte) ([]pkcs11.ObjectHandle, error) {
+	infos, err := hsm_api.ListKeys(m.ctx, m.ss, "", label, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []pkcs11.ObjectHandle
+	for _, info := range infos {
+		if hex.EncodeToString(info.ID) == hex.EncodeToString(id) {
+			keys = append(keys, info.Handle)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("not found key %s", label)
+	}
+	return keys, nil
+}
+
+func (m *Manager) setDate(key pkcs11.ObjectHandle, attr uint, t time.Time) error {
+	if err := m.ctx.SetAttributeValue(m.ss, key, []*pkcs11.Attribute{pkcs11.NewAttribute(attr, t)}); err != nil {
+		return fmt.Errorf("failed to set key date: %v", err)
+	}
+	return nil
+}
+
+// record returns a copy of the record of the key and the handle of its data object, 0 when
+// the key has none yet and is therefore active.
+func (m *Manager) record(label string, id []byte) (*Record, pkcs11.ObjectHandle, error) {
+	m.cacheMu.Lock()
+	c, ok := m.cache[label+"/"+hex.EncodeToString(id)]
+	m.cacheMu.Unlock()
+	if !ok {
+		r, obj, err := m.load(label, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		c = m.remember(r, obj)
+	}
+	return c.r.copy(), c.obj, nil
+}
+
+func (m *Manager) remember(r *Record, obj pkcs11.ObjectHandle) cachedRecord {
+	c := cachedRecord{r: r.copy(), obj: obj}
+	m.cacheMu.Lock()
+	m.cache[r.Label+"/"+r.ID] = c
+	m.cacheMu.Unlock()
+	return c
+}
+
+func (r *Record) copy() *Record {
+	c := *r
+	c.History = append([]Transition{}, r.History...)
+	return &c
+}
+
+// load reads the record of the key from the token.
+func (m *Manager) load(label string, id []byte) (*Record, pkcs11.ObjectHandle, error) {
+	objs, err := hsm_api.FindObjects(m.ctx, m.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	want := hex.EncodeToString(id)
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(m.ctx, m.ss, obj, pkcs11.CKA_VALUE)
+		if err != nil {
+			return nil, 0, err
+		}
+		r := &Record{}
+		if err := json.Unmarshal(attrs[pkcs11.CKA_VALUE], r); err != nil {
+			return nil, 0, fmt.Errorf("invalid lifecycle record of %s: %v", label, err)
+		}
+		if r.ID == want {
+			return r, obj, nil
+		}
+	}
+
+	return &Record{Label: label, ID: want, State: Active, History: []Transition{}}, 0, nil
+}
+
+func (m *Manager) save(r *Record, obj pkcs11.ObjectHandle) (pkcs11.ObjectHandle, error) {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal lifecycle record: %v", err)
+	}
+
+	if obj != 0 {
+		err = m.ctx.SetAttributeValue(m.ss, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, value)})
+	} else {
+		obj, err = m.ctx.CreateObject(m.ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, r.Label),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to save lifecycle record: %v", err)
+	}
+	return obj, nil
+}
+
+// isDate tells if a CK_DATE value is set, unset dates are empty or zeros.
+func isDate(d string) bool {
+	return len(d) == 8 && d != "00000000"
+}
@@ -0,0 +1,39 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		state   State
+		purpose Purpose
+		err     error
+	}{
+		{Active, Protect, nil},
+		{Active, Process, nil},
+		{Deactivated, Process, nil},
+		{Deactivated, Protect, ErrNotUsable},
+		{Suspended, Process, ErrNotUsable},
+		{PreActive, Protect, ErrNotUsable},
+		{Destroyed, Process, ErrNotUsable},
+		{Compromised, Process, ErrCompromised},
+		{Compromised, Protect, ErrCompromised},
+	}
+	for _, c := range cases {
+		err := Allows("key", c.state, c.purpose)
+		if !errors.Is(err, c.err) || (c.err == nil) != (err == nil) {
+			t.Errorf("%s/%d: got %v, want %v", c.state, c.purpose, err, c.err)
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	if !allowed(Active, Deactivated) || !allowed(Suspended, Active) || !allowed(Compromised, Destroyed) {
+		t.Error("expected transition to be allowed")
+	}
+	if allowed(Deactivated, Active) || allowed(Destroyed, Active) || allowed(Compromised, Active) {
+		t.Error("expected transition to be refused")
+	}
+}
@@ -0,0 +1,373 @@
+// Package metadata keeps what PKCS#11 objects cannot hold, owner, description, tags,
+// tenant and rotation policy, in a file sealed with AES-GCM under an HSM key. Entries are
+// linked to the token keys by CKA_ID.
+package metadata
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const (
+	defaultKeyLabel = "metadata-key"
+
+	// version of the sealed file
+	version = 1
+)
+
+// Entry is the metadata of the keys with the CKA_ID, a key pair shares one entry.
+type Entry struct {
+	ID             string            `json:"id"`
+	Label          string            `json:"label"`
+	Owner          string            `json:"owner,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Tenant         string            `json:"tenant,omitempty"`
+	RotationPolicy string            `json:"rotationPolicy,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// Filter selects entries, empty fields match everything. Tag is a name or name=value.
+type Filter struct {
+	Tenant string
+	Owner  string
+	Tag    string
+}
+
+// Report lists the token keys without metadata and the entries without token keys.
+type Report struct {
+	Objects []*hsm_api.KeyInfo
+	Entries []*Entry
+}
+
+// file is the sealed store on disk, the header is authenticated with the entries.
+type file struct {
+	Version  int    `json:"version"`
+	KeyLabel string `json:"keyLabel"`
+	Data     []byte `json:"data"`
+}
+
+type sealer interface {
+	seal(plain, aad []byte) ([]byte, error)
+	open(cipher, aad []byte) ([]byte, error)
+}
+
+type hsmSealer struct {
+	ctx *pkcs11.Ctx
+	ss  pkcs11.SessionHandle
+	key pkcs11.ObjectHandle
+}
+
+func (h hsmSealer) seal(plain, aad []byte) ([]byte, error) {
+	return hsm_api.EncryptGCM(h.ctx, h.ss, h.key, plain, aad)
+}
+
+func (h hsmSealer) open(cipher, aad []byte) ([]byte, error) {
+	return hsm_api.DecryptGCM(h.ctx, h.ss, h.key, cipher, aad)
+}
+
+// Store holds the entries in memory and rewrites the whole file on every change.
+type Store struct {
+	ctx      *pkcs11.Ctx
+	ss       pkcs11.SessionHandle
+	path     string
+	keyLabel string
+	sealer   sealer
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// KeyLabel returns the label of the sealing key of the config.
+func KeyLabel(conf *configs.Config) string {
+	if conf.Metadata.KeyLabel == "" {
+		return defaultKeyLabel
+	}
+	return conf.Metadata.KeyLabel
+}
+
+// NewStore opens the store of the config, the sealing key is generated when the token
+// does not have it yet.
+func NewStore(conf *configs.Config, ctx *pkcs11.Ctx, ss pkcs11.SessionHandle) (*Store, error) {
+	label := KeyLabel(conf)
+
+	key, err := sealingKey(ctx, ss, label)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := open(conf.Metadata.Path, label, hsmSealer{ctx: ctx, ss: ss, key: key})
+	if err != nil {
+		return nil, err
+	}
+	s.ctx, s.ss = ctx, ss
+	return s, nil
+}
+
+func sealingKey(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, label string) (pkcs11.ObjectHandle, error) {
+	objs, err := hsm_api.FindObjects(ctx, ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(objs) > 0 {
+		return objs[0], nil
+	}
+
+	objs, err = hsm_api.GenerateKey(ctx, ss, label, nil, "AES256", false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate metadata key: %v", err)
+	}
+	log.Printf("metadata key %s generated", label)
+	return objs[0], nil
+}
+
+// open reads the sealed file, a missing file is an empty store.
+func open(path, keyLabel string, sl sealer) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing metadata store path")
+	}
+	s := &Store{path: path, keyLabel: keyLabel, sealer: sl, entries: map[string]*Entry{}}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata store: %v", err)
+	}
+
+	f := &file{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("invalid metadata store: %v", err)
+	}
+	if f.Version != version || f.KeyLabel != keyLabel {
+		return nil, fmt.Errorf("metadata store of version %d under %s, want version %d under %s", f.Version, f.KeyLabel, version, keyLabel)
+	}
+
+	plain, err := sl.open(f.Data, aad(f.Version, f.KeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata store, it was modified or sealed under another key: %v", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, fmt.Errorf("invalid metadata store entries: %v", err)
+	}
+	for _, e := range entries {
+		s.entries[e.ID] = e
+	}
+	return s, nil
+}
+
+// Get returns a copy of the entry of the id.
+func (s *Store) Get(id []byte) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[hex.EncodeToString(id)]
+	if !ok {
+		return nil, false
+	}
+	return e.copy(), true
+}
+
+// Put creates or replaces the entry of e.ID, the creation time of an existing entry is kept.
+func (s *Store) Put(e *Entry) (*Entry, error) {
+	if e.ID == "" {
+		return nil, fmt.Errorf("metadata needs a key with a CKA_ID")
+	}
+	if _, err := hex.DecodeString(e.ID); err != nil {
+		return nil, fmt.Errorf("invalid key id %s: %v", e.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e = e.copy()
+	e.UpdatedAt = time.Now().UTC()
+	e.CreatedAt = e.UpdatedAt
+	old, ok := s.entries[e.ID]
+	if ok {
+		e.CreatedAt = old.CreatedAt
+	}
+
+	s.entries[e.ID] = e
+	if err := s.persist(); err != nil {
+		if ok {
+			s.entries[e.ID] = old
+		} else {
+			delete(s.entries, e.ID)
+		}
+		return nil, err
+	}
+	return e.copy(), nil
+}
+
+// Delete removes the entry of the id, removing a missing entry is not an error.
+func (s *Store) Delete(id []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hex.EncodeToString(id)
+	old, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	delete(s.entries, key)
+	if err := s.persist(); err != nil {
+		s.entries[key] = old
+		return err
+	}
+	return nil
+}
+
+// List returns copies of the entries matching the filter ordered by label then id.
+func (s *Store) List(f Filter) []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := []*Entry{}
+	for _, e := range s.entries {
+		if f.matches(e) {
+			entries = append(entries, e.copy())
+		}
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Reconcile compares the token keys with the entries, the sealing key of the store is left out.
+func (s *Store) Reconcile() (*Report, error) {
+	keys, err := hsm_api.ListKeys(s.ctx, s.ss, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	return s.reconcile(keys), nil
+}
+
+func (s *Store) reconcile(keys []*hsm_api.KeyInfo) *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &Report{Objects: []*hsm_api.KeyInfo{}, Entries: []*Entry{}}
+	ids := map[string]bool{}
+	for _, k := range keys {
+		if k.Label == s.keyLabel {
+			continue
+		}
+		id := hex.EncodeToString(k.ID)
+		ids[id] = true
+		if _, ok := s.entries[id]; !ok {
+			r.Objects = append(r.Objects, k)
+		}
+	}
+	for id, e := range s.entries {
+		if !ids[id] {
+			r.Entries = append(r.Entries, e.copy())
+		}
+	}
+	sortEntries(r.Entries)
+	return r
+}
+
+// persist seals the entries to a temporary file renamed over the store. Called with the lock held.
+func (s *Store) persist() error {
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sortEntries(entries)
+
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+	data, err := s.sealer.seal(plain, aad(version, s.keyLabel))
+	if err != nil {
+		return fmt.Errorf("failed to seal metadata: %v", err)
+	}
+	b, err := json.Marshal(&file{Version: version, KeyLabel: s.keyLabel, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata store: %v", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write metadata store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metadata store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metadata store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write metadata store: %v", err)
+	}
+	return nil
+}
+
+func aad(version int, keyLabel string) []byte {
+	return []byte(fmt.Sprintf("hsm-metadata:%d:%s", version, keyLabel))
+}
+
+func (f Filter) matches(e *Entry) bool {
+	if f.Tenant != "" && e.Tenant != f.Tenant {
+		return false
+	}
+	if f.Owner != "" && e.Owner != f.Owner {
+		return false
+	}
+	if f.Tag != "" {
+		name, value := f.Tag, ""
+		if i := strings.Index(f.Tag, "="); i >= 0 {
+			name, value = f.Tag[:i], f.Tag[i+1:]
+		}
+		v, ok := e.Tags[name]
+		if !ok || (value != "" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Entry) copy() *Entry {
+	c := *e
+	if e.Tags != nil {
+		c.Tags = make(map[string]string, len(e.Tags))
+		for k, v := range e.Tags {
+			c.Tags[k] = v
+		}
+	}
+	return &c
+}
+
+func sortEntries(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Label != entries[j].Label {
+			return entries[i].Label < entries[j].Label
+		}
+		return entries[i].ID < entries[j].ID
+	})
+}
@@ -0,0 +1,176 @@
+package metadata
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hsm_api "hsm/pkg/hsm-api"
+)
+
+// gcmSealer stands in for the HSM key.
+type gcmSealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(t *testing.T) gcmSealer {
+	key := make([]byte, 32)
+	rand.Read(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gcmSealer{aead: aead}
+}
+
+func (g gcmSealer) seal(plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, g.aead.NonceSize())
+	rand.Read(nonce)
+	return g.aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func (g gcmSealer) open(c, aad []byte) ([]byte, error) {
+	n := g.aead.NonceSize()
+	return g.aead.Open(nil, c[:n], c[n:], aad)
+}
+
+func tempStore(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "metadata.json"), func() { os.RemoveAll(dir) }
+}
+
+func TestStore(t *testing.T) {
+	path, cleanup := tempStore(t)
+	defer cleanup()
+	sl := newSealer(t)
+
+	s, err := open(path, "metadata-key", sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(&Entry{Label: "k"}); err == nil {
+		t.Error("expected error for an entry without id")
+	}
+	first, err := s.Put(&Entry{ID: "01", Label: "k", Owner: "payments", Tags: map[string]string{"env": "prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(&Entry{ID: "01", Label: "k", Owner: "cards"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = open(path, "metadata-key", sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := s.Get([]byte{1})
+	if !ok || e.Owner != "cards" || !e.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("got %+v", e)
+	}
+
+	if err := s.Delete([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get([]byte{1}); ok {
+		t.Error("entry not deleted")
+	}
+}
+
+func TestTamperedStore(t *testing.T) {
+	path, cleanup := tempStore(t)
+	defer cleanup()
+	sl := newSealer(t)
+
+	s, err := open(path, "metadata-key", sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(&Entry{ID: "01", Label: "k"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &file{}
+	if err := json.Unmarshal(b, f); err != nil {
+		t.Fatal(err)
+	}
+	f.Data[len(f.Data)-1] ^= 1
+	b, _ = json.Marshal(f)
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := open(path, "metadata-key", sl); err == nil {
+		t.Error("expected error for a modified store")
+	}
+	if _, err := open(path, "other-key", sl); err == nil {
+		t.Error("expected error for another sealing key")
+	}
+}
+
+func TestList(t *testing.T) {
+	s := &Store{entries: map[string]*Entry{
+		"01": {ID: "01", Label: "b", Tenant: "acme", Tags: map[string]string{"env": "prod"}},
+		"02": {ID: "02", Label: "a", Tenant: "acme", Tags: map[string]string{"env": "test"}},
+		"03": {ID: "03", Label: "c", Tenant: "other"},
+	}}
+
+	cases := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"a", "b", "c"}},
+		{Filter{Tenant: "acme"}, []string{"a", "b"}},
+		{Filter{Tag: "env"}, []string{"a", "b"}},
+		{Filter{Tag: "env=prod"}, []string{"b"}},
+	}
+	for _, c := range cases {
+		got := s.List(c.filter)
+		if len(got) != len(c.want) {
+			t.Errorf("%+v: got %d entries, want %v", c.filter, len(got), c.want)
+			continue
+		}
+		for i, e := range got {
+			if e.Label != c.want[i] {
+				t.Errorf("%+v: got %s at %d, want %s", c.filter, e.Label, i, c.want[i])
+			}
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := &Store{keyLabel: "metadata-key", entries: map[string]*Entry{
+		"01": {ID: "01", Label: "known"},
+		"02": {ID: "02", Label: "deleted"},
+	}}
+
+	r := s.reconcile([]*hsm_api.KeyInfo{
+		{Label: "known", ID: []byte{1}, Class: "private"},
+		{Label: "known", ID: []byte{1}, Class: "public"},
+		{Label: "unknown", ID: []byte{3}},
+		{Label: "no-id"},
+		{Label: "metadata-key"},
+	})
+
+	if len(r.Objects) != 2 || r.Objects[0].Label != "unknown" || r.Objects[1].Label != "no-id" {
+		t.Errorf("orphaned objects: got %+v", r.Objects)
+	}
+	if len(r.Entries) != 1 || r.Entries[0].ID != "02" {
+		t.Errorf("orphaned entries: got %+v", r.Entries)
+	}
+}
@@ -0,0 +1,272 @@
+// Package policy checks the usage and allowed mechanisms of a key before a request reaches
+// the HSM, so tokens that ignore CKA_ALLOWED_MECHANISMS are held to the same policy.
+package policy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"hsm/pkg/audit"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// CKA_APPLICATION of the policy data objects
+const Application = "hsm-policy"
+
+// purposes of the PIN keys, a key of a purpose only serves the operations of the purpose
+const (
+	PurposePINEncryption   = "pin-encryption"   // zpk, decrypts PIN blocks
+	PurposePINVerification = "pin-verification" // pvk, computes PVVs and PIN offsets
+)
+
+var purposes = []string{PurposePINEncryption, PurposePINVerification}
+
+// ErrNotAllowed is returned when the key policy refuses the operation or the mechanism.
+var ErrNotAllowed = errors.New("not allowed by key policy")
+
+// Record keeps the allowed mechanisms of a key whose token has no CKA_ALLOWED_MECHANISMS,
+// and the purpose of the key.
+type Record struct {
+	Label      string   `json:"label"`
+	ID         string   `json:"id"`
+	Mechanisms []string `json:"mechanisms"`
+	Purpose    string   `json:"purpose,omitempty"`
+}
+
+// ValidPurpose reports whether the purpose is empty or one of the Purpose* values.
+func ValidPurpose(purpose string) bool {
+	if purpose == "" {
+		return true
+	}
+	for _, p := range purposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+// Enforcer reads the policy from the key attributes, or from the records of the service
+// when the token does not support the attribute. Refusals are audited.
+type Enforcer struct {
+	ctx   *pkcs11.Ctx
+	ss    pkcs11.SessionHandle
+	audit *audit.Logger
+}
+
+func NewEnforcer(ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, audit *audit.Logger) *Enforcer {
+	return &Enforcer{ctx: ctx, ss: ss, audit: audit}
+}
+
+// Check refuses the use of the key for the usage attribute (CKA_ENCRYPT, CKA_SIGN, ...)
+// when the attribute is not set or the mechanism is not in the allowed ones. A key
+// without allowed mechanisms can be used with any mechanism.
+func (e *Enforcer) Check(key pkcs11.ObjectHandle, usage, mech uint) error {
+	attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID, usage, pkcs11.CKA_ALLOWED_MECHANISMS)
+	if err != nil {
+		return err
+	}
+	label, id := string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID]
+
+	if !hsm_api.AttributeBool(attrs[usage]) {
+		return e.deny(label, id, usage, mech, "usage")
+	}
+
+	mechs, err := e.allowed(attrs)
+	if err != nil {
+		return err
+	}
+	if !Allows(mechs, mech) {
+		return e.deny(label, id, usage, mech, "mechanism")
+	}
+	return nil
+}
+
+// CheckPurpose refuses the key unless its purpose is the one of the operation: the PIN
+// operations name their purpose, the other operations take the keys without purpose.
+func (e *Enforcer) CheckPurpose(key pkcs11.ObjectHandle, purpose string) error {
+	attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID)
+	if err != nil {
+		return err
+	}
+	label, id := string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID]
+	r, _, err := e.record(label, id)
+	if err != nil {
+		return err
+	}
+	if r.Purpose == purpose {
+		return nil
+	}
+
+	have, want := r.Purpose, purpose
+	if have == "" {
+		have = "none"
+	}
+	if want == "" {
+		want = "none"
+	}
+	e.audit.Log(audit.Event{
+		Action: "policy-violation",
+		Key:    label,
+		Detail: map[string]string{"id": hex.EncodeToString(id), "purpose": have, "reason": "purpose"},
+	})
+	return fmt.Errorf("%w: key %s has purpose %s, not %s", ErrNotAllowed, label, have, want)
+}
+
+// Purpose returns the purpose of the key, empty for the keys without one.
+func (e *Enforcer) Purpose(key pkcs11.ObjectHandle) (string, error) {
+	attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID)
+	if err != nil {
+		return "", err
+	}
+	r, _, err := e.record(string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID])
+	if err != nil {
+		return "", err
+	}
+	return r.Purpose, nil
+}
+
+// Mechanisms returns the allowed mechanisms of the key, from the token or the service record.
+func (e *Enforcer) Mechanisms(key pkcs11.ObjectHandle) ([]uint, error) {
+	attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID, pkcs11.CKA_ALLOWED_MECHANISMS)
+	if err != nil {
+		return nil, err
+	}
+	return e.allowed(attrs)
+}
+
+// Allows reports whether the mechanism is in the allowed ones, an empty list allows all.
+func Allows(allowed []uint, mech uint) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, m := range allowed {
+		if m == mech {
+			return true
+		}
+	}
+	return false
+}
+
+// Register keeps the purpose of a new key, and its allowed mechanisms when its token did
+// not store CKA_ALLOWED_MECHANISMS, in a record of the service.
+func (e *Enforcer) Register(key pkcs11.ObjectHandle, mechs []uint, purpose string) error {
+	if !ValidPurpose(purpose) {
+		return fmt.Errorf("unknown key purpose: %s", purpose)
+	}
+
+	attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, key, pkcs11.CKA_LABEL, pkcs11.CKA_ID, pkcs11.CKA_ALLOWED_MECHANISMS)
+	if err != nil {
+		return err
+	}
+	if _, ok := attrs[pkcs11.CKA_ALLOWED_MECHANISMS]; ok {
+		mechs = nil
+	}
+	if len(mechs) == 0 && purpose == "" {
+		return nil
+	}
+
+	label, id := string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID]
+	r, obj, err := e.record(label, id)
+	if err != nil {
+		return err
+	}
+
+	// a record of a deleted key with the same label and id is overwritten
+	r.Mechanisms = nil
+	for _, m := range mechs {
+		r.Mechanisms = append(r.Mechanisms, hsm_api.MechanismName(m))
+	}
+	r.Purpose = purpose
+	return e.save(r, obj)
+}
+
+func (e *Enforcer) allowed(attrs map[uint][]byte) ([]uint, error) {
+	if allowed, ok := attrs[pkcs11.CKA_ALLOWED_MECHANISMS]; ok {
+		return hsm_api.MechanismList(allowed), nil
+	}
+	r, _, err := e.record(string(attrs[pkcs11.CKA_LABEL]), attrs[pkcs11.CKA_ID])
+	if err != nil {
+		return nil, err
+	}
+	return r.mechanisms()
+}
+
+func (e *Enforcer) deny(label string, id []byte, usage, mech uint, reason string) error {
+	op, name := hsm_api.UsageName(usage), hsm_api.MechanismName(mech)
+	e.audit.Log(audit.Event{
+		Action: "policy-violation",
+		Key:    label,
+		Detail: map[string]string{"id": hex.EncodeToString(id), "operation": op, "mechanism": name, "reason": reason},
+	})
+	return fmt.Errorf("%w: %s of key %s with %s", ErrNotAllowed, op, label, name)
+}
+
+// mechanisms resolves the names of the record.
+func (r *Record) mechanisms() ([]uint, error) {
+	mechs := make([]uint, 0, len(r.Mechanisms))
+	for _, name := range r.Mechanisms {
+		m, ok := hsm_api.MechanismNames[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid policy record of %s: unknown mechanism %s", r.Label, name)
+		}
+		mechs = append(mechs, m)
+	}
+	return mechs, nil
+}
+
+func (e *Enforcer) record(label string, id []byte) (*Record, pkcs11.ObjectHandle, error) {
+	objs, err := hsm_api.FindObjects(e.ctx, e.ss, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	want := hex.EncodeToString(id)
+	for _, obj := range objs {
+		attrs, err := hsm_api.GetAttributes(e.ctx, e.ss, obj, pkcs11.CKA_VALUE)
+		if err != nil {
+			return nil, 0, err
+		}
+		r := &Record{}
+		if err := json.Unmarshal(attrs[pkcs11.CKA_VALUE], r); err != nil {
+			return nil, 0, fmt.Errorf("invalid policy record of %s: %v", label, err)
+		}
+		if r.ID == want {
+			return r, obj, nil
+		}
+	}
+
+	return &Record{Label: label, ID: want}, 0, nil
+}
+
+func (e *Enforcer) save(r *Record, obj pkcs11.ObjectHandle) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy record: %v", err)
+	}
+
+	if obj != 0 {
+		err = e.ctx.SetAttributeValue(e.ss, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, value)})
+	} else {
+		_, err = e.ctx.CreateObject(e.ss, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, Application),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, r.Label),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save policy record: %v", err)
+	}
+	return nil
+}
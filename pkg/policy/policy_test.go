@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/gemalto/pkcs11"
+)
+
+func TestAllows(t *testing.T) {
+	if !Allows(nil, pkcs11.CKM_AES_ECB) {
+		t.Error("a key without allowed mechanisms allows every mechanism")
+	}
+
+	allowed := []uint{pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_AES_GCM}
+	if !Allows(allowed, pkcs11.CKM_AES_GCM) {
+		t.Error("gcm refused")
+	}
+	if Allows(allowed, pkcs11.CKM_AES_ECB) {
+		t.Error("ecb allowed")
+	}
+}
+
+func TestRecordMechanisms(t *testing.T) {
+	r := &Record{Label: "k", Mechanisms: []string{"CKM_AES_CBC_PAD", "CKM_AES_GCM"}}
+	mechs, err := r.mechanisms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mechs) != 2 || mechs[1] != pkcs11.CKM_AES_GCM {
+		t.Errorf("got %v", mechs)
+	}
+
+	r.Mechanisms = append(r.Mechanisms, "CKM_UNKNOWN")
+	if _, err := r.mechanisms(); err == nil {
+		t.Error("expected error for unknown mechanism")
+	}
+}
+
+func TestValidPurpose(t *testing.T) {
+	for _, p := range []string{"", PurposePINEncryption, PurposePINVerification} {
+		if !ValidPurpose(p) {
+			t.Errorf("%q refused", p)
+		}
+	}
+	if ValidPurpose("mac") {
+		t.Error("unknown purpose accepted")
+	}
+}
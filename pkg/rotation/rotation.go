@@ -0,0 +1,215 @@
+// Package rotation rotates the key rings of the configured policies in the background.
+package rotation
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"hsm/configs"
+	"hsm/pkg/audit"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+const defaultCheckInterval = time.Hour
+
+// Schedule is the rotation state of a key ring.
+type Schedule struct {
+	Policy     configs.RotationPolicy
+	Primary    int
+	Since      time.Time
+	Operations int
+	Next       time.Time
+}
+
+// Scheduler counts the operations of the primary versions and rotates the key rings that
+// are due. Counts are kept in memory and added to the ring records on every check and on
+// Stop, so they survive restarts; a crash loses at most the counts of one check interval.
+// The session is the scheduler's own, the checks and the requests for a schedule take
+// turns on it.
+type Scheduler struct {
+	conf   configs.Rotation
+	ctx    *pkcs11.Ctx
+	ss     pkcs11.SessionHandle
+	rotate RotateFunc
+	audit  *audit.Logger
+
+	hsmMu sync.Mutex // uses of ss
+	mu    sync.Mutex
+	ops   map[opsKey]int
+	stop  chan struct{}
+}
+
+// RotateFunc rotates a key ring, such as hsm_api.RotateKey with the key policy of the
+// service. It must use the session of the scheduler.
+type RotateFunc func(ring string) (*hsm_api.KeyVersion, error)
+
+func NewScheduler(conf *configs.Config, ctx *pkcs11.Ctx, ss pkcs11.SessionHandle, rotate RotateFunc, audit *audit.Logger) *Scheduler {
+	return &Scheduler{
+		conf:   conf.Rotation,
+		ctx:    ctx,
+		ss:     ss,
+		rotate: rotate,
+		audit:  audit,
+		ops:    map[opsKey]int{},
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start checks the policies every check interval until Stop.
+func (s *Scheduler) Start() {
+	if len(s.conf.Policies) == 0 {
+		return
+	}
+
+	interval := s.conf.CheckInterval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			s.Check()
+			select {
+			case <-t.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the checks and saves the operation counts.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.Flush()
+}
+
+// Count records an operation with the version of the key ring.
+func (s *Scheduler) Count(ring string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[opsKey{ring, version}]++
+}
+
+// Flush adds the operations counted since the last flush to the ring records, the counts
+// that cannot be saved are kept for the next one.
+func (s *Scheduler) Flush() {
+	s.hsmMu.Lock()
+	defer s.hsmMu.Unlock()
+	s.flush()
+}
+
+func (s *Scheduler) flush() {
+	s.mu.Lock()
+	ops := s.ops
+	s.ops = map[opsKey]int{}
+	s.mu.Unlock()
+
+	for k, n := range ops {
+		if err := hsm_api.AddKeyVersionOperations(s.ctx, s.ss, k.ring, k.version, n); err != nil {
+			log.Printf("operations of %s version %d: %v", k.ring, k.version, err)
+			s.mu.Lock()
+			s.ops[k] += n
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Check saves the operation counts and rotates every key ring that is due.
+func (s *Scheduler) Check() {
+	s.hsmMu.Lock()
+	defer s.hsmMu.Unlock()
+
+	s.flush()
+	for _, p := range s.conf.Policies {
+		sched, err := s.schedule(p.Label)
+		if err != nil {
+			log.Printf("rotation of %s: %v", p.Label, err)
+			continue
+		}
+		if sched.Next.IsZero() || time.Now().UTC().Before(sched.Next) {
+			continue
+		}
+
+		v, err := s.rotate(p.Label)
+		if err != nil {
+			log.Printf("rotation of %s: %v", p.Label, err)
+			continue
+		}
+		log.Printf("key ring %s rotated to version %d", p.Label, v.Version)
+		s.audit.Log(audit.Event{
+			Actor:  "rotation-scheduler",
+			Action: "key_rotate",
+			Key:    p.Label,
+			Detail: map[string]string{
+				"from":       fmt.Sprintf("%d", sched.Primary),
+				"to":         fmt.Sprintf("%d", v.Version),
+				"operations": fmt.Sprintf("%d", sched.Operations),
+			},
+		})
+	}
+}
+
+// Schedule tells when the key ring is rotated next, from the creation time and the saved
+// and pending operation counts of the primary version.
+func (s *Scheduler) Schedule(ring string) (*Schedule, error) {
+	s.hsmMu.Lock()
+	defer s.hsmMu.Unlock()
+	return s.schedule(ring)
+}
+
+func (s *Scheduler) schedule(ring string) (*Schedule, error) {
+	p, ok := s.policy(ring)
+	if !ok {
+		return nil, fmt.Errorf("no rotation policy for %s", ring)
+	}
+
+	primary, err := hsm_api.PrimaryKeyVersion(s.ctx, s.ss, ring)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	ops := primary.Operations + s.ops[opsKey{ring, primary.Version}]
+	s.mu.Unlock()
+
+	return &Schedule{
+		Policy:     p,
+		Primary:    primary.Version,
+		Since:      primary.Since,
+		Operations: ops,
+		Next:       NextRotation(p, primary.Since, ops, time.Now().UTC()),
+	}, nil
+}
+
+// NextRotation is the end of the period, or now when the operations reached the maximum.
+// A policy without period and not yet exhausted never rotates, it returns the zero time.
+func NextRotation(p configs.RotationPolicy, since time.Time, ops int, now time.Time) time.Time {
+	if p.MaxOperations > 0 && ops >= p.MaxOperations {
+		return now
+	}
+	if p.Period <= 0 {
+		return time.Time{}
+	}
+	return since.Add(p.Period)
+}
+
+func (s *Scheduler) policy(ring string) (configs.RotationPolicy, bool) {
+	for _, p := range s.conf.Policies {
+		if p.Label == ring {
+			return p, true
+		}
+	}
+	return configs.RotationPolicy{}, false
+}
+
+type opsKey struct {
+	ring    string
+	version int
+}
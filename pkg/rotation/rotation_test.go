@@ -0,0 +1,41 @@
+package rotation
+
+import (
+	"testing"
+	"time"
+
+	"hsm/configs"
+)
+
+func TestNextRotation(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(24 * time.Hour)
+	period := 90 * 24 * time.Hour
+
+	cases := []struct {
+		name   string
+		policy configs.RotationPolicy
+		ops    int
+		want   time.Time
+	}{
+		{"period", configs.RotationPolicy{Period: period, MaxOperations: 10}, 5, since.Add(period)},
+		{"max operations", configs.RotationPolicy{Period: period, MaxOperations: 10}, 10, now},
+		{"operations only", configs.RotationPolicy{MaxOperations: 10}, 3, time.Time{}},
+		{"no criteria", configs.RotationPolicy{}, 100, time.Time{}},
+	}
+	for _, c := range cases {
+		if got := NextRotation(c.policy, since, c.ops, now); !got.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := NewScheduler(&configs.Config{}, nil, 0, nil, nil)
+	s.Count("ring", 1)
+	s.Count("ring", 1)
+	s.Count("ring", 2)
+	if s.ops[opsKey{"ring", 1}] != 2 || s.ops[opsKey{"ring", 2}] != 1 {
+		t.Errorf("got ops %v", s.ops)
+	}
+}
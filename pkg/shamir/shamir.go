@@ -0,0 +1,123 @@
+// Package shamir splits a secret into shares with Shamir's secret sharing over GF(2^8),
+// any threshold of them rebuild the secret and fewer reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+var expTable, logTable [256]byte
+
+func init() {
+	// 3 generates the multiplicative group of GF(2^8) with the AES polynomial
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		x = mul3(x)
+	}
+	expTable[255] = expTable[0]
+}
+
+func mul3(x byte) byte {
+	y := x << 1
+	if x&0x80 != 0 {
+		y ^= 0x1b
+	}
+	return y ^ x
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+// Split returns n shares of the secret, threshold of them are needed to combine it.
+// The first byte of a share is its x coordinate.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("empty secret")
+	}
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("invalid %d of %d shares", threshold, n)
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+
+	// one random polynomial of degree threshold-1 per secret byte
+	coef := make([]byte, threshold)
+	for j, s := range secret {
+		coef[0] = s
+		if _, err := io.ReadFull(rand.Reader, coef[1:]); err != nil {
+			return nil, fmt.Errorf("failed to generate coefficients: %v", err)
+		}
+		for _, share := range shares {
+			share[j+1] = eval(coef, share[0])
+		}
+	}
+	for i := range coef {
+		coef[i] = 0
+	}
+
+	return shares, nil
+}
+
+// eval computes the polynomial at x with Horner's method.
+func eval(coef []byte, x byte) byte {
+	y := byte(0)
+	for i := len(coef) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coef[i]
+	}
+	return y
+}
+
+// Combine rebuilds the secret by Lagrange interpolation at 0. Giving fewer shares than
+// the threshold returns a wrong secret, callers verify the result.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least 2 shares are needed")
+	}
+
+	size := len(shares[0])
+	seen := map[byte]bool{}
+	for _, share := range shares {
+		if len(share) != size || size < 2 {
+			return nil, fmt.Errorf("shares have different lengths")
+		}
+		if share[0] == 0 || seen[share[0]] {
+			return nil, fmt.Errorf("invalid or duplicate share %d", share[0])
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, size-1)
+	for i, si := range shares {
+		// lagrange basis polynomial of share i at 0
+		basis := byte(1)
+		for j, sj := range shares {
+			if i != j {
+				basis = mul(basis, div(sj[0], sj[0]^si[0]))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= mul(si[k+1], basis)
+		}
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,70 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares", len(shares))
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		picked := [][]byte{}
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		combined, err := Combine(picked)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Errorf("shares %v: secret mismatch", subset)
+		}
+	}
+
+	// below the threshold the secret is not recovered
+	combined, err := Combine(shares[:2])
+	if err != nil {
+		t.Error(err)
+	}
+	if bytes.Equal(combined, secret) {
+		t.Error("2 of 3 shares recovered the secret")
+	}
+}
+
+func TestInvalidShares(t *testing.T) {
+	if _, err := Split([]byte("s"), 2, 3); err == nil {
+		t.Error("expected error for threshold above the number of shares")
+	}
+	if _, err := Split(nil, 3, 2); err == nil {
+		t.Error("expected error for empty secret")
+	}
+
+	shares, _ := Split([]byte("secret"), 3, 2)
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Error("expected error for duplicate shares")
+	}
+	if _, err := Combine([][]byte{shares[0], shares[1][:3]}); err == nil {
+		t.Error("expected error for truncated share")
+	}
+}
+
+func TestField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if div(mul(byte(a), 0x53), 0x53) != byte(a) {
+			t.Fatalf("div(mul(%d)) mismatch", a)
+		}
+	}
+	// AES sample: {57} x {83} = {c1}
+	if mul(0x57, 0x83) != 0xc1 {
+		t.Errorf("mul: got %x", mul(0x57, 0x83))
+	}
+}
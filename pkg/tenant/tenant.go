@@ -0,0 +1,73 @@
+// Package tenant confines the keys of a tenant to the labels prefixed with its name and a
+// colon. The tenant of a request is the one of its API token, callers without tenant only
+// reach the labels outside every namespace.
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hsm_api "hsm/pkg/hsm-api"
+)
+
+// Separator ends the tenant prefix of a label.
+const Separator = ":"
+
+// ErrNotFound is returned for labels of another namespace, as for a missing key so the
+// caller cannot tell whether they exist.
+var ErrNotFound = errors.New("not found key")
+
+// Validate checks a tenant name of the config.
+func Validate(name string) error {
+	if strings.Contains(name, Separator) {
+		return fmt.Errorf("invalid tenant %s: it cannot contain %q", name, Separator)
+	}
+	return nil
+}
+
+// Label returns the token label of a label named by the tenant.
+func Label(tenant, label string) (string, error) {
+	if tenant == "" {
+		if strings.Contains(label, Separator) {
+			return "", ErrNotFound
+		}
+		return label, nil
+	}
+	return tenant + Separator + label, nil
+}
+
+// Owns reports whether the token label is in the namespace of the tenant.
+func Owns(tenant, label string) bool {
+	if tenant == "" {
+		return !strings.Contains(label, Separator)
+	}
+	return strings.HasPrefix(label, tenant+Separator)
+}
+
+// Of returns the tenant of a token label, empty outside every namespace.
+func Of(label string) string {
+	if i := strings.Index(label, Separator); i >= 0 {
+		return label[:i]
+	}
+	return ""
+}
+
+// Local returns the token label as the tenant names it.
+func Local(tenant, label string) string {
+	if tenant == "" {
+		return label
+	}
+	return strings.TrimPrefix(label, tenant+Separator)
+}
+
+// Keys keeps the keys of the namespace of the tenant.
+func Keys(tenant string, keys []*hsm_api.KeyInfo) []*hsm_api.KeyInfo {
+	owned := []*hsm_api.KeyInfo{}
+	for _, k := range keys {
+		if Owns(tenant, k.Label) {
+			owned = append(owned, k)
+		}
+	}
+	return owned
+}
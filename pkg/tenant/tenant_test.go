@@ -0,0 +1,79 @@
+package tenant
+
+import (
+	"testing"
+
+	hsm_api "hsm/pkg/hsm-api"
+)
+
+func TestLabel(t *testing.T) {
+	cases := []struct {
+		tenant, label, want string
+		err                 error
+	}{
+		{"cards", "n2k-master-key", "cards:n2k-master-key", nil},
+		{"cards", "loyalty:n2k-master-key", "cards:loyalty:n2k-master-key", nil},
+		{"", "n2k-master-key", "n2k-master-key", nil},
+		{"", "cards:n2k-master-key", "", ErrNotFound},
+	}
+	for _, c := range cases {
+		got, err := Label(c.tenant, c.label)
+		if got != c.want || err != c.err {
+			t.Errorf("Label(%q, %q) = %q, %v, want %q, %v", c.tenant, c.label, got, err, c.want, c.err)
+		}
+	}
+}
+
+// a label resolved for one tenant is never in the namespace of another one
+func TestIsolation(t *testing.T) {
+	tenants := []string{"", "a", "ab", "b"}
+	labels := []string{"key", "a:key", "ab:key", "b:key", ":key"}
+
+	for _, resolver := range tenants {
+		for _, label := range labels {
+			full, err := Label(resolver, label)
+			if err != nil {
+				continue
+			}
+			for _, other := range tenants {
+				if Owns(other, full) != (other == resolver) {
+					t.Errorf("label %q of tenant %q resolves to %q, owned by %q: %v", label, resolver, full, other, Owns(other, full))
+				}
+			}
+			if Of(full) != resolver || Local(resolver, full) != label {
+				t.Errorf("label %q of tenant %q resolves to %q", label, resolver, full)
+			}
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := []*hsm_api.KeyInfo{{Label: "n2k-master-key"}, {Label: "a:n2k-master-key"}, {Label: "ab:zpk"}, {Label: "b:pvk"}}
+
+	for tenant, want := range map[string][]string{
+		"":  {"n2k-master-key"},
+		"a": {"a:n2k-master-key"},
+		"b": {"b:pvk"},
+		"c": {},
+	} {
+		got := Keys(tenant, keys)
+		if len(got) != len(want) {
+			t.Errorf("tenant %q: got %d keys, want %v", tenant, len(got), want)
+			continue
+		}
+		for i, k := range got {
+			if k.Label != want[i] {
+				t.Errorf("tenant %q: got %s, want %s", tenant, k.Label, want[i])
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("cards"); err != nil {
+		t.Error(err)
+	}
+	if err := Validate("cards:eu"); err == nil {
+		t.Error("expected error for a name with the separator")
+	}
+}
@@ -0,0 +1,279 @@
+// Package tokenadmin initializes tokens and sets their PINs with C_InitToken, C_InitPIN
+// and C_SetPIN. New PINs are checked against the PIN policy of the config and the length
+// limits of the token. A change of the user PIN of the service token goes through the
+// logged in session of the service and updates its credentials.
+package tokenadmin
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode"
+
+	"hsm/configs"
+	hsm_api "hsm/pkg/hsm-api"
+
+	"github.com/gemalto/pkcs11"
+)
+
+// user types of a PIN
+const (
+	UserTypeUser = "user"
+	UserTypeSO   = "so"
+)
+
+var (
+	ErrPolicy      = errors.New("PIN refused by policy")
+	ErrInitialized = errors.New("token already initialized")
+	ErrLabelInUse  = errors.New("token label in use")
+	// ErrServiceLoggedIn is returned for SO logins on the token the service is logged in,
+	// a token takes one user type at a time.
+	ErrServiceLoggedIn = errors.New("the service is logged in the token")
+	// ErrNoPINFile is returned for a change of the service PIN without hsm.pin_file, the
+	// new PIN would be lost on restart.
+	ErrNoPINFile = errors.New("hsm.pin_file is not set")
+	// ErrPINNotSaved is returned when the PIN of the token changed but the PIN file could
+	// not be replaced: the service uses the new PIN until it restarts with the old one.
+	ErrPINNotSaved = errors.New("the token PIN changed but the PIN file was not updated")
+)
+
+// Admin administers the tokens of the module, ss is the logged in session of the service
+// and 0 outside the service.
+type Admin struct {
+	ctx  *pkcs11.Ctx
+	conf *configs.HSM
+	ss   pkcs11.SessionHandle
+}
+
+func New(ctx *pkcs11.Ctx, conf *configs.HSM, ss pkcs11.SessionHandle) *Admin {
+	return &Admin{ctx: ctx, conf: conf, ss: ss}
+}
+
+// InitToken initializes the token of the slot, the first uninitialized one when slot is
+// 0, and returns the slot of the token: the module may move it on initialization.
+// Initialized tokens are refused as their objects would be destroyed.
+func (a *Admin) InitToken(slot uint, label, soPIN string) (uint, error) {
+	if label == "" || len(label) > 32 {
+		return 0, fmt.Errorf("token label must have 1 to 32 characters")
+	}
+	if _, err := hsm_api.TokenSlot(a.ctx, label); err == nil {
+		return 0, fmt.Errorf("%w: %s", ErrLabelInUse, label)
+	}
+
+	if slot == 0 {
+		free, err := hsm_api.FreeSlot(a.ctx)
+		if err != nil {
+			return 0, err
+		}
+		slot = free
+	}
+
+	info, err := a.ctx.GetTokenInfo(slot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+	}
+	if info.Flags&pkcs11.CKF_TOKEN_INITIALIZED != 0 {
+		return 0, fmt.Errorf("%w: slot %d holds %s", ErrInitialized, slot, info.Label)
+	}
+	if err := checkPIN(a.conf.PinPolicy, info, soPIN); err != nil {
+		return 0, err
+	}
+
+	if err := hsm_api.InitToken(a.ctx, slot, soPIN, label); err != nil {
+		return 0, err
+	}
+	return hsm_api.TokenSlot(a.ctx, label)
+}
+
+// InitPIN sets the user PIN of the token with the label after an SO login.
+func (a *Admin) InitPIN(label, soPIN, pin string) error {
+	slot, info, err := a.token(label)
+	if err != nil {
+		return err
+	}
+	if a.logged(slot) {
+		return fmt.Errorf("%w: %s", ErrServiceLoggedIn, label)
+	}
+	if err := checkPIN(a.conf.PinPolicy, info, pin); err != nil {
+		return err
+	}
+	return hsm_api.InitPIN(a.ctx, slot, soPIN, pin)
+}
+
+// SetPIN changes the user or SO PIN of the token with the label. The user PIN of the
+// service token is changed in the session of the service, or in a session of its own
+// outside the service. It needs the PIN file, the new PIN is written next to it before
+// the change and replaces it after.
+func (a *Admin) SetPIN(label, userType, oldPIN, newPIN string) error {
+	typ, err := parseUserType(userType)
+	if err != nil {
+		return err
+	}
+	slot, info, err := a.token(label)
+	if err != nil {
+		return err
+	}
+	if newPIN == oldPIN {
+		return fmt.Errorf("%w: the new PIN is the old one", ErrPolicy)
+	}
+	if err := checkPIN(a.conf.PinPolicy, info, newPIN); err != nil {
+		return err
+	}
+
+	logged := a.logged(slot)
+	if logged && typ == pkcs11.CKU_SO {
+		return fmt.Errorf("%w: %s", ErrServiceLoggedIn, label)
+	}
+
+	var staged *stagedPIN
+	if typ == pkcs11.CKU_USER && a.service(slot) {
+		if staged, err = stagePIN(a.conf, newPIN); err != nil {
+			return err
+		}
+		defer staged.remove()
+	}
+
+	if logged {
+		err = hsm_api.SetSessionPIN(a.ctx, a.ss, oldPIN, newPIN)
+	} else {
+		err = hsm_api.SetPIN(a.ctx, slot, typ, oldPIN, newPIN)
+	}
+	if err != nil || staged == nil {
+		return err
+	}
+	return staged.commit()
+}
+
+// SavePIN updates the credentials of the service after a change of its user PIN: the
+// PIN file of the config is replaced.
+func SavePIN(conf *configs.HSM, pin string) error {
+	staged, err := stagePIN(conf, pin)
+	if err != nil {
+		return err
+	}
+	defer staged.remove()
+	return staged.commit()
+}
+
+// stagedPIN is a new PIN written next to the PIN file, so that a PIN file that cannot
+// be written is found before the PIN of the token changes.
+type stagedPIN struct {
+	conf *configs.HSM
+	pin  string
+	tmp  string
+}
+
+func stagePIN(conf *configs.HSM, pin string) (*stagedPIN, error) {
+	if conf.PinFile == "" {
+		return nil, fmt.Errorf("%w: the new PIN of the service would be lost on restart", ErrNoPINFile)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(conf.PinFile), ".pin-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save pin: %v", err)
+	}
+	if _, err := tmp.WriteString(pin + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to save pin: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to save pin: %v", err)
+	}
+	return &stagedPIN{conf: conf, pin: pin, tmp: tmp.Name()}, nil
+}
+
+// commit replaces the PIN file. The service takes the new PIN even when it fails, the
+// token already has it.
+func (p *stagedPIN) commit() error {
+	p.conf.Pin = p.pin
+	if err := os.Rename(p.tmp, p.conf.PinFile); err != nil {
+		return fmt.Errorf("%w: write the new PIN to %s before the next restart: %v", ErrPINNotSaved, p.conf.PinFile, err)
+	}
+	return nil
+}
+
+// remove drops the staged PIN when it was not committed.
+func (p *stagedPIN) remove() {
+	os.Remove(p.tmp)
+}
+
+// CheckPIN checks the PIN against the policy.
+func CheckPIN(p configs.PINPolicy, pin string) error {
+	if p.MinLength > 0 && len(pin) < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters", ErrPolicy, p.MinLength)
+	}
+	if p.MaxLength > 0 && len(pin) > p.MaxLength {
+		return fmt.Errorf("%w: at most %d characters", ErrPolicy, p.MaxLength)
+	}
+
+	var lower, upper, digit, other int
+	for _, r := range pin {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	if classes := lower + upper + digit + other; classes < p.MinClasses {
+		return fmt.Errorf("%w: mix at least %d of lower case, upper case, digits and other characters", ErrPolicy, p.MinClasses)
+	}
+	return nil
+}
+
+// checkPIN checks the PIN against the policy and the length limits of the token.
+func checkPIN(p configs.PINPolicy, info pkcs11.TokenInfo, pin string) error {
+	if n := uint(len(pin)); n < info.MinPinLen || (info.MaxPinLen > 0 && n > info.MaxPinLen) {
+		return fmt.Errorf("%w: the token takes %d to %d characters", ErrPolicy, info.MinPinLen, info.MaxPinLen)
+	}
+	return CheckPIN(p, pin)
+}
+
+func (a *Admin) token(label string) (uint, pkcs11.TokenInfo, error) {
+	slot, err := hsm_api.TokenSlot(a.ctx, label)
+	if err != nil {
+		return 0, pkcs11.TokenInfo{}, err
+	}
+	info, err := a.ctx.GetTokenInfo(slot)
+	if err != nil {
+		return 0, pkcs11.TokenInfo{}, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+	}
+	return slot, info, nil
+}
+
+// logged reports whether the service is logged in the slot.
+func (a *Admin) logged(slot uint) bool {
+	return a.ss != 0 && a.service(slot)
+}
+
+// service reports whether the slot holds the token of the service: the slot of its
+// session, or of the config where 0 is the first slot with a token.
+func (a *Admin) service(slot uint) bool {
+	if a.ss != 0 {
+		info, err := a.ctx.GetSessionInfo(a.ss)
+		return err == nil && info.SlotID == slot
+	}
+	if a.conf.SlotID != 0 {
+		return a.conf.SlotID == slot
+	}
+	slots, err := a.ctx.GetSlotList(true)
+	return err == nil && len(slots) > 0 && slots[0] == slot
+}
+
+func parseUserType(userType string) (uint, error) {
+	switch userType {
+	case "", UserTypeUser:
+		return pkcs11.CKU_USER, nil
+	case UserTypeSO:
+		return pkcs11.CKU_SO, nil
+	}
+	return 0, fmt.Errorf("user type must be %s or %s, got %s", UserTypeUser, UserTypeSO, userType)
+}
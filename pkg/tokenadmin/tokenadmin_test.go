@@ -0,0 +1,87 @@
+package tokenadmin
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hsm/configs"
+)
+
+func TestCheckPIN(t *testing.T) {
+	p := configs.PINPolicy{MinLength: 6, MaxLength: 12, MinClasses: 2}
+	cases := []struct {
+		pin string
+		ok  bool
+	}{
+		{"abc12", false},
+		{"abcdef", false},
+		{"abc123", true},
+		{"ABC-def", true},
+		{"abcdef1234567", false},
+	}
+	for _, c := range cases {
+		err := CheckPIN(p, c.pin)
+		if (err == nil) != c.ok {
+			t.Errorf("%q: got %v, want ok %v", c.pin, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrPolicy) {
+			t.Errorf("%q: got %v, want a policy error", c.pin, err)
+		}
+	}
+
+	if err := CheckPIN(configs.PINPolicy{}, "1"); err != nil {
+		t.Errorf("empty policy: %v", err)
+	}
+}
+
+func TestSavePIN(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &configs.HSM{Pin: "654321", PinFile: filepath.Join(dir, "hsm.pin")}
+	if err := SavePIN(conf, "123456"); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Pin != "123456" {
+		t.Errorf("pin: got %s", conf.Pin)
+	}
+	b, err := ioutil.ReadFile(conf.PinFile)
+	if err != nil || string(b) != "123456\n" {
+		t.Errorf("pin file: got %q, %v", b, err)
+	}
+
+	noFile := &configs.HSM{Pin: "654321"}
+	if err := SavePIN(noFile, "123456"); !errors.Is(err, ErrNoPINFile) || noFile.Pin != "654321" {
+		t.Errorf("without pin file: got %v, pin %s", err, noFile.Pin)
+	}
+
+	// the staged PIN is written but a directory cannot be replaced by a file
+	locked := filepath.Join(dir, "locked")
+	if err := os.MkdirAll(filepath.Join(locked, "keep"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	blocked := &configs.HSM{Pin: "654321", PinFile: locked}
+	if err := SavePIN(blocked, "123456"); !errors.Is(err, ErrPINNotSaved) || blocked.Pin != "123456" {
+		t.Errorf("pin file not replaced: got %v, pin %s", err, blocked.Pin)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("staged pin files left: %d files in %s", len(files), dir)
+	}
+}
+
+func TestParseUserType(t *testing.T) {
+	for _, typ := range []string{"", UserTypeUser, UserTypeSO} {
+		if _, err := parseUserType(typ); err != nil {
+			t.Errorf("%q: %v", typ, err)
+		}
+	}
+	if _, err := parseUserType("admin"); err == nil {
+		t.Error("expected error for unknown user type")
+	}
+}
@@ -10,8 +10,12 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// dev-key-reader of configs/dev.yml
+const bearer = "Bearer dev-app"
+
 func TestGRPC(t *testing.T) {
 	conn, err := grpc.Dial(":9999", grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Second*3))
 	if err != nil {
@@ -22,6 +26,7 @@ func TestGRPC(t *testing.T) {
 	c := NewCryptoClient(conn)
 
 	ctx, _ := context.WithTimeout(context.Background(), time.Second)
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", bearer)
 
 	res, err := c.Encrypt(ctx, &EncryptRequest{Type: "N2K_KGK", PlainText: "eyJ1c2VyX3NhZmVfaWQiOiJ0ZXN0X3VzZXJfc2FmZV9pZCIsInR5cGUiOiJpZF9jYXJkIiwiaW1hZ2UiOiJlbmNvZGVkIGJhc2U2NCBpZCBjYXJkIGltYWdlIn0="})
 	if err != nil {
@@ -44,6 +49,7 @@ func TestHTTP(t *testing.T) {
 		t.Error(err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", bearer)
 
 	// send request
 	httpRes, err := http.DefaultClient.Do(httpReq)